@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
 )
 
 var notebookCmd = &cobra.Command{
@@ -26,7 +28,13 @@ Examples:
   opennotes notebook create --name "Work Notes"
 
   # Register existing notebook globally
-  opennotes notebook register /path/to/notebook`,
+  opennotes notebook register /path/to/notebook
+
+  # Check for broken links and orphan notes
+  opennotes notebook check
+
+  # Export the link graph for Graphviz
+  opennotes notebook graph --format dot`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Default: show current notebook info
 		nb, err := notebookService.Infer("")
@@ -34,7 +42,15 @@ Examples:
 			return err
 		}
 
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
 		if nb == nil {
+			if format != services.OutputText {
+				return fmt.Errorf("no notebook found")
+			}
 			fmt.Println("No notebook found.")
 			fmt.Println("")
 			fmt.Println("Create one with:")
@@ -42,6 +58,10 @@ Examples:
 			return nil
 		}
 
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, nb.Config)
+		}
+
 		return displayNotebookInfo(nb)
 	},
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notebookCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check a notebook for broken links and orphan notes",
+	Long: `Checks every note in the notebook and reports:
+
+  missing-note        links to notes that don't exist
+  missing-heading     links to #headings that don't exist in the linked note
+  missing-attachment  images and other files that are linked but don't exist
+  orphan              notes with no links to or from other notes
+
+Exits with status 1 when there are problems, so it can run in CI or a
+pre-commit hook. Use --skip to leave out kinds of problem.
+
+Examples:
+  # Check the current notebook
+  opennotes notebook check
+
+  # Check links only, as JSON
+  opennotes notebook check --skip orphan --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		skip, _ := cmd.Flags().GetStringSlice("skip")
+		for _, kind := range skip {
+			if !slices.Contains(services.ProblemKinds, kind) {
+				return fmt.Errorf("unknown problem kind %q (want one of %s)", kind, strings.Join(services.ProblemKinds, ", "))
+			}
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		report, err := nb.Notes.Check(context.Background())
+		if err != nil {
+			return err
+		}
+		report = report.Without(skip...)
+
+		if format != services.OutputText {
+			if err := services.WriteOutput(os.Stdout, format, report); err != nil {
+				return err
+			}
+		} else {
+			printCheckReport(report)
+		}
+
+		if len(report.Problems) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("notebook check found %d problem(s)", len(report.Problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	notebookCheckCmd.Flags().StringSlice("skip", nil, "Kinds of problem to leave out ("+strings.Join(services.ProblemKinds, ", ")+")")
+	notebookCmd.AddCommand(notebookCheckCmd)
+}
+
+// printCheckReport prints each problem as note:line: kind: message, then a
+// summary.
+func printCheckReport(report *services.CheckReport) {
+	if len(report.Problems) == 0 {
+		fmt.Printf("No problems in %d notes.\n", report.Notes)
+		return
+	}
+
+	notes := make(map[string]bool)
+	for _, problem := range report.Problems {
+		location := problem.Note
+		if problem.Line > 0 {
+			location = fmt.Sprintf("%s:%d", problem.Note, problem.Line)
+		}
+		message := problem.Message
+		if problem.Link != "" {
+			message = fmt.Sprintf("%s: %s", problem.Link, problem.Message)
+		}
+		fmt.Printf("%s: %s: %s\n", location, problem.Kind, message)
+		notes[problem.Note] = true
+	}
+	fmt.Printf("\n%d problem(s) in %d of %d notes.\n", len(report.Problems), len(notes), report.Notes)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notebookGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the notebook's link graph",
+	Long: `Writes the links between notes as a graph for Graphviz (dot), Mermaid,
+GraphML tools such as Gephi or yEd, or as JSON. Notes are labelled with
+their titles, and notebook groups become clusters (dot) or subgraphs
+(mermaid). Links to missing notes and links from a note to itself are left
+out.
+
+Filters narrow the notes in the graph, and only links between the remaining
+notes are kept:
+  --group    notes matching a notebook group, as in notes list --group
+  --tag      notes tagged with a tag (globs allowed, as tag: in notes search)
+  --where    a SQL predicate on the notes view, as in notes search --sql
+
+With --from the graph is limited to the notes within --depth links of a
+note, following links in either direction.
+
+Examples:
+  # Render the whole notebook with Graphviz
+  opennotes notebook graph | dot -Tsvg > notebook.svg
+
+  # Mermaid diagram of the decision records, for a wiki page
+  opennotes notebook graph --format mermaid --tag adr
+
+  # Notes within two links of a note
+  opennotes notebook graph --from "Use DuckDB" --depth 2
+
+  # GraphML of recently changed notes
+  opennotes notebook graph --format graphml --where "mtime > now() - INTERVAL 30 DAY"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("format")
+		format, err := services.ParseGraphFormat(name)
+		if err != nil {
+			return err
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		opts := services.GraphOptions{}
+		opts.Group, _ = cmd.Flags().GetString("group")
+		opts.Tag, _ = cmd.Flags().GetString("tag")
+		opts.Where, _ = cmd.Flags().GetString("where")
+		opts.Depth, _ = cmd.Flags().GetInt("depth")
+
+		ctx := context.Background()
+		if from, _ := cmd.Flags().GetString("from"); from != "" {
+			note, err := nb.Notes.FindNote(ctx, from)
+			if err != nil {
+				return err
+			}
+			opts.From = note.File.Relative
+		}
+
+		graph, err := nb.Notes.Graph(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return services.WriteGraph(os.Stdout, format, graph)
+	},
+}
+
+func init() {
+	names := make([]string, len(services.GraphFormats))
+	for i, format := range services.GraphFormats {
+		names[i] = string(format)
+	}
+
+	// Graphs have their own formats, so this replaces the global --format
+	notebookGraphCmd.Flags().String("format", string(services.GraphDOT), "Graph format ("+strings.Join(names, ", ")+")")
+	notebookGraphCmd.Flags().String("group", "", "Only notes in this notebook group")
+	notebookGraphCmd.Flags().String("tag", "", "Only notes with this tag")
+	notebookGraphCmd.Flags().String("where", "", "Only notes matching this SQL predicate on the notes view")
+	notebookGraphCmd.Flags().String("from", "", "Centre the graph on this note")
+	notebookGraphCmd.Flags().Int("depth", 1, "With --from, how many links away notes are kept")
+	notebookCmd.AddCommand(notebookGraphCmd)
+}
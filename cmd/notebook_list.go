@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/zenobi-us/opennotes/internal/services"
@@ -19,13 +20,28 @@ Shows notebooks from:
 
 Examples:
   # List all known notebooks
-  opennotes notebook list`,
+  opennotes notebook list
+
+  # Notebook names and roots as TSV
+  opennotes notebook list --format tsv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		notebooks, err := notebookService.List("")
 		if err != nil {
 			return err
 		}
 
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != services.OutputText {
+			configs := make([]services.NotebookConfig, len(notebooks))
+			for i, nb := range notebooks {
+				configs[i] = nb.Config
+			}
+			return services.WriteOutput(os.Stdout, format, configs)
+		}
+
 		if len(notebooks) == 0 {
 			fmt.Println("No notebooks found.")
 			fmt.Println("")
@@ -7,7 +7,7 @@ import (
 var notesCmd = &cobra.Command{
 	Use:   "notes",
 	Short: "Manage notes",
-	Long: `Commands for managing notes - list, search, add, and remove notes.
+	Long: `Commands for managing notes - list, show, search, query, add, remove and watch notes.
 
 Notes are markdown files stored in the notebook's notes directory.
 The notebook is automatically discovered from the current directory,
@@ -20,11 +20,35 @@ Examples:
   # Add a new note with title
   opennotes notes add --title "Meeting Notes"
 
+  # Show a note
+  opennotes notes show "Meeting Notes"
+
+  # Edit a note in $EDITOR
+  opennotes notes edit "Meeting Notes"
+
   # Search notes by content
   opennotes notes search "project deadline"
 
+  # Query notes interactively with SQL
+  opennotes notes sql
+
+  # Run a query saved in .opennotes.json
+  opennotes notes query open_actions
+
+  # List the notes linking to a note
+  opennotes notes backlinks "Meeting Notes"
+
+  # Rename a note and update links to it
+  opennotes notes move meeting-notes team-sync
+
+  # Check frontmatter against the notebook's schema
+  opennotes notes lint
+
   # Remove a note
-  opennotes notes remove my-note.md`,
+  opennotes notes remove my-note.md
+
+  # Stream note changes
+  opennotes notes watch --ndjson`,
 }
 
 func init() {
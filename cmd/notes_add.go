@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/services"
+	"golang.org/x/term"
 )
 
 var notesAddCmd = &cobra.Command{
@@ -17,7 +21,43 @@ var notesAddCmd = &cobra.Command{
 	Long: `Creates a new markdown note in the current notebook with optional template support.
 
 If no name is provided, generates one from the title or timestamp.
-Templates can be defined in the notebook's .opennotes.json config.
+Templates are read from the notebook's .opennotes/templates directory, the
+user's templates directory and the notebook's .opennotes.json config. See
+opennotes templates.
+
+Templates are Go text/templates. They can use .Title, .Date (when the note
+is created), .Notebook, .Group, .Context, .Path and .Vars, which holds the
+values given with --var key=value, and the functions now, date, dateAdd,
+slugify, env, default, upper, lower and yaml. Pipe the title, .Vars and
+prompt answers through yaml in frontmatter, so values like "Bug: crash on
+save" are quoted:
+
+  ---
+  title: {{ yaml .Title }}
+  due: {{ .Date | dateAdd "7d" | date "2006-01-02" }}
+  owner: {{ .Vars.owner | default (env "USER") | yaml }}
+  ---
+
+Templates can declare prompts in their frontmatter. On a terminal, notes add
+asks for each prompt not given with --var; otherwise prompts take their
+defaults and --var must set the rest. Answers are used as .Vars:
+
+  ---
+  attendees: {{ yaml .Vars.attendees }}
+  prompts:
+    - attendees
+    - name: kind
+      choices: [planning, retro]
+      default: planning
+  ---
+
+With --group the note is created in the group's directory (the fixed part
+of its first glob), from the group's template unless --template is given,
+and with the group's metadata added to its frontmatter.
+
+Fields missing from the new note get their defaults from the notebook's
+frontmatter schema, and a note whose frontmatter wouldn't satisfy the schema
+isn't created. See notes lint.
 
 Examples:
   # Add note with auto-generated name
@@ -27,7 +67,19 @@ Examples:
   opennotes notes add my-note.md --title "My Note"
 
   # Add note using template
-  opennotes notes add --title "Bug Report" --template bug`,
+  opennotes notes add --title "Bug Report" --template bug
+
+  # Fill in template variables
+  opennotes notes add --title "Incident" --template incident --var severity=high
+
+  # Answer a template's prompts without asking
+  opennotes notes add --title "Sync" --template meeting --var attendees="ana, bo" --var kind=retro
+
+  # Add a note to the meetings group
+  opennotes notes add --title "Standup" --group meetings
+
+  # Add a note and open it in your editor
+  opennotes notes add --title "Retro" --edit`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		nb, err := requireNotebook(cmd)
@@ -37,6 +89,21 @@ Examples:
 
 		template, _ := cmd.Flags().GetString("template")
 		title, _ := cmd.Flags().GetString("title")
+		rawVars, _ := cmd.Flags().GetStringArray("var")
+		vars, err := parseNameValues("var", rawVars)
+		if err != nil {
+			return err
+		}
+
+		var group *services.NotebookGroup
+		if name, _ := cmd.Flags().GetString("group"); name != "" {
+			if group, err = nb.Config.FindGroup(name); err != nil {
+				return err
+			}
+			if template == "" {
+				template = group.Template
+			}
+		}
 
 		// Determine filename
 		var filename string
@@ -63,27 +130,54 @@ Examples:
 
 		// Full path to the note
 		notePath := filepath.Join(nb.Config.Root, filename)
+		if group != nil {
+			notePath = filepath.Join(nb.Config.Root, filepath.FromSlash(group.Dir()), filename)
+		}
 
 		// Check if file already exists
 		if _, err := os.Stat(notePath); err == nil {
 			return fmt.Errorf("note already exists: %s", notePath)
 		}
 
+		// Generate content, with group metadata and schema defaults filled in
+		relative, _ := filepath.Rel(nb.Config.Root, notePath)
+		data := services.NoteTemplateData{
+			Title:    title,
+			Date:     time.Now(),
+			Notebook: nb.Config.Name,
+			Context:  noteContext(nb),
+			Path:     filepath.ToSlash(relative),
+			Vars:     vars,
+		}
+		if group != nil {
+			data.Group = group.Name
+		}
+		content, err := generateNoteContent(template, nb, data, promptInput())
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if content, err = nb.Config.PrepareNote(data.Path, content, group); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
 		// Create directories if needed
 		noteDir := filepath.Dir(notePath)
 		if err := os.MkdirAll(noteDir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		// Generate content
-		content := generateNoteContent(title, template, nb.Config.Templates)
-
 		// Write the file
 		if err := os.WriteFile(notePath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("failed to create note: %w", err)
 		}
 
 		fmt.Printf("Created note: %s\n", notePath)
+
+		if edit, _ := cmd.Flags().GetBool("edit"); edit {
+			return editNote(context.Background(), notePath, nb.Config.SchemaFor(data.Path))
+		}
 		return nil
 	},
 }
@@ -91,35 +185,64 @@ Examples:
 func init() {
 	notesAddCmd.Flags().StringP("template", "t", "", "Template to use")
 	notesAddCmd.Flags().String("title", "", "Note title")
+	notesAddCmd.Flags().StringP("group", "g", "", "Notebook group to add the note to")
+	notesAddCmd.Flags().StringArray("var", nil, "Template variable as key=value, used as {{ .Vars.key }} (repeatable)")
+	notesAddCmd.Flags().BoolP("edit", "e", false, "Open the new note in your editor")
 	notesCmd.AddCommand(notesAddCmd)
 }
 
-// generateNoteContent creates the initial note content.
-func generateNoteContent(title, templateName string, templates map[string]string) string {
-	var content strings.Builder
-
-	// If a template is specified and exists, use it
-	if templateName != "" && templates != nil {
-		if tmplContent, ok := templates[templateName]; ok {
-			// Replace {{title}} placeholder if present
-			if title != "" {
-				return strings.ReplaceAll(tmplContent, "{{title}}", title)
-			}
-			return tmplContent
+// generateNoteContent creates the initial note content, rendering the named
+// template with data when there is one. The template's prompts are asked on
+// in, or take their defaults when in is nil.
+func generateNoteContent(templateName string, nb *services.Notebook, data services.NoteTemplateData, in io.Reader) (string, error) {
+	if templateName != "" {
+		templates, err := loadNoteTemplates(nb)
+		if err != nil {
+			return "", err
+		}
+		prompts, err := templates.Prompts(templateName)
+		if err != nil {
+			return "", err
+		}
+		if data.Vars, err = services.AskTemplatePrompts(prompts, data.Vars, in, os.Stdout); err != nil {
+			return "", err
 		}
+		return templates.Render(templateName, data)
 	}
 
 	// Default content with frontmatter
-	content.WriteString("---\n")
-	if title != "" {
-		content.WriteString(fmt.Sprintf("title: %s\n", title))
+	frontmatter := core.NewFrontmatter()
+	if data.Title != "" {
+		if err := frontmatter.Set("title", data.Title); err != nil {
+			return "", err
+		}
+	}
+	if err := frontmatter.Set("created", data.Date.Truncate(time.Second)); err != nil {
+		return "", err
 	}
-	content.WriteString(fmt.Sprintf("created: %s\n", time.Now().Format(time.RFC3339)))
-	content.WriteString("---\n\n")
 
-	if title != "" {
-		content.WriteString(fmt.Sprintf("# %s\n\n", title))
+	var body string
+	if data.Title != "" {
+		body = fmt.Sprintf("# %s\n\n", data.Title)
 	}
+	return frontmatter.Render(body)
+}
 
-	return content.String()
+// promptInput returns stdin when it's a terminal, for asking template
+// prompts, and nil otherwise.
+func promptInput() io.Reader {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return os.Stdin
+	}
+	return nil
+}
+
+// noteContext returns the notebook context the working directory is in, or
+// the working directory itself.
+func noteContext(nb *services.Notebook) string {
+	cwd, _ := os.Getwd()
+	if match := nb.MatchContext(cwd); match != "" {
+		return match
+	}
+	return cwd
 }
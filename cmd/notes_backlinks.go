@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var notesBacklinksCmd = &cobra.Command{
+	Use:   "backlinks <note>",
+	Short: "List the notes that link to a note",
+	Long: `Lists the markdown links and wikilinks in other notes that point to a
+note, with the linking note and line.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title, as with notes show.
+
+Examples:
+  # List the links to a note
+  opennotes notes backlinks "Release Plan"
+
+  # Find notes nothing links to
+  opennotes notes search --sql "SELECT relative FROM notes WHERE backlink_count = 0"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		note, err := nb.Notes.FindNote(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		links, err := nb.Notes.Backlinks(ctx, note)
+		if err != nil {
+			return err
+		}
+		return printNoteLinks(cmd, links, fmt.Sprintf("No notes link to %s.", note.File.Relative))
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesBacklinksCmd)
+}
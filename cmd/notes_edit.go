@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesEditCmd = &cobra.Command{
+	Use:   "edit <note>",
+	Short: "Open a note in your editor",
+	Long: `Opens a note in your editor and waits for it to close.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title, as with notes show.
+
+The editor is the "editor" setting of the global config (or
+OPENNOTES_EDITOR), else $VISUAL, else $EDITOR, else vi. Editors that return
+straight away need their wait flag, e.g. "code --wait".
+
+If the note changed, its frontmatter is checked, including against the
+notebook's schema, and the "updated" field is set to the current time.
+Invalid frontmatter is reported and left for you to fix; your edits are
+kept.
+
+Examples:
+  # Edit a note by filename
+  opennotes notes edit meeting-notes
+
+  # Edit a note with a one-off editor
+  EDITOR=nano opennotes notes edit "Release Plan"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		note, err := nb.Notes.FindNote(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		return editNote(ctx, note.File.Filepath, nb.Config.SchemaFor(note.File.Relative))
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesEditCmd)
+}
+
+// editNote opens the note at path in the configured editor. The edited
+// frontmatter is checked against schema.
+func editNote(ctx context.Context, path string, schema core.FrontmatterSchema) error {
+	editor := services.EditorCommand(cfgService.Store.Editor)
+
+	changed, err := services.EditFile(ctx, editor, path, schema)
+	if err != nil {
+		if changed {
+			return fmt.Errorf("%s was saved, but %w", path, err)
+		}
+		return err
+	}
+
+	if changed {
+		fmt.Printf("Updated note: %s\n", path)
+	}
+	return nil
+}
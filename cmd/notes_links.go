@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesLinksCmd = &cobra.Command{
+	Use:   "links <note>",
+	Short: "List the links in a note to other notes",
+	Long: `Lists the markdown links and wikilinks in a note that point to other
+notes, with the line they're on. Links to notes that don't exist are shown
+as missing.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title, as with notes show.
+
+Links are also available to --sql queries in the note_links view, and the
+notes view has link_count and backlink_count columns.
+
+Examples:
+  # List a note's links
+  opennotes notes links "Release Plan"
+
+  # List a note's links as JSON
+  opennotes notes links projects/release.md --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		note, err := nb.Notes.FindNote(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		links, err := nb.Notes.Links(ctx, note)
+		if err != nil {
+			return err
+		}
+		return printNoteLinks(cmd, links, fmt.Sprintf("No links in %s.", note.File.Relative))
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesLinksCmd)
+}
+
+// printNoteLinks prints links one per line as source:line, the link as
+// written and the note it points to.
+func printNoteLinks(cmd *cobra.Command, links []services.NoteLink, empty string) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != services.OutputText {
+		return services.WriteOutput(os.Stdout, format, links)
+	}
+
+	if len(links) == 0 {
+		fmt.Println(empty)
+		return nil
+	}
+
+	for _, link := range links {
+		written := "(" + link.Link + link.Fragment + ")"
+		if link.Kind == services.NoteLinkWiki {
+			written = "[[" + link.Link + link.Fragment + "]]"
+		}
+		target := link.Target
+		if link.Broken() {
+			target = "(missing)"
+		}
+		fmt.Printf("%s:%d\t%s -> %s\n", link.Source, link.Line, written, target)
+	}
+	return nil
+}
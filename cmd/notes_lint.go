@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the frontmatter of every note against the notebook's schema",
+	Long: `Validates the frontmatter of every note and reports the fields that break
+the notebook's frontmatter schema, or that opennotes can't read (title must
+be a string, tags a string or list of strings, created and updated dates).
+
+Schemas are declared in .opennotes.json under "schema", for every note, and
+under a group's "schema", for the notes in that group. A group's fields
+replace the notebook's fields with the same key. Each field can have:
+  type       string, number, boolean, date, list or enum
+  required   true if every note must set the field
+  default    the value notes add gives new notes without the field
+  pattern    a regular expression strings (and list items) must match
+  values     the allowed values of an enum, or of a list's items
+
+  "schema": {
+    "title":  { "type": "string", "required": true },
+    "status": { "type": "enum", "values": ["draft", "accepted"], "default": "draft" },
+    "tags":   { "type": "list", "pattern": "^[a-z-]+$" }
+  }
+
+Exits with status 1 when there are problems.
+
+Examples:
+  # Lint the current notebook
+  opennotes notes lint
+
+  # Lint as JSON
+  opennotes notes lint --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		report, err := nb.Notes.Lint(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if format != services.OutputText {
+			if err := services.WriteOutput(os.Stdout, format, report); err != nil {
+				return err
+			}
+		} else {
+			printLintReport(report)
+		}
+
+		if len(report.Problems) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("notes lint found %d problem(s)", len(report.Problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesLintCmd)
+}
+
+// printLintReport prints each problem as note: field: message, then a summary.
+func printLintReport(report *services.LintReport) {
+	if len(report.Problems) == 0 {
+		fmt.Printf("No problems in %d notes.\n", report.Notes)
+		return
+	}
+
+	notes := make(map[string]bool)
+	for _, problem := range report.Problems {
+		if problem.Field == "" {
+			fmt.Printf("%s: %s\n", problem.Note, problem.Message)
+		} else {
+			fmt.Printf("%s: %s: %s\n", problem.Note, problem.Field, problem.Message)
+		}
+		notes[problem.Note] = true
+	}
+	fmt.Printf("\n%d problem(s) in %d of %d notes.\n", len(report.Problems), len(notes), report.Notes)
+}
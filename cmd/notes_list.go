@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/zenobi-us/opennotes/internal/services"
@@ -15,35 +16,66 @@ var notesListCmd = &cobra.Command{
 	Long: `Lists all markdown notes in the current notebook.
 
 Shows all .md files in the notebook's notes directory with metadata.
+With --group, only the notes matching one of the group's globs are listed.
 
 Examples:
   # List notes in current notebook
   opennotes notes list
 
   # List notes from specific notebook
-  opennotes notes list --notebook /path/to/notebook`,
+  opennotes notes list --notebook /path/to/notebook
+
+  # List the notes in a group
+  opennotes notes list --group meetings
+
+  # List notes as CSV
+  opennotes notes list --format csv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		nb, err := requireNotebook(cmd)
 		if err != nil {
 			return err
 		}
 
+		var group *services.NotebookGroup
+		if name, _ := cmd.Flags().GetString("group"); name != "" {
+			if group, err = nb.Config.FindGroup(name); err != nil {
+				return err
+			}
+		}
+
 		notes, err := nb.Notes.SearchNotes(context.Background(), "")
 		if err != nil {
-			// DuckDB returns an error when the glob pattern matches no files
-			// Treat this as an empty notebook
-			return displayNoteList([]services.Note{})
+			return fmt.Errorf("failed to list notes: %w", err)
+		}
+
+		if group != nil {
+			var matched []services.Note
+			for _, note := range notes {
+				if group.Matches(note.File.Relative) {
+					matched = append(matched, note)
+				}
+			}
+			notes = matched
 		}
 
-		return displayNoteList(notes)
+		return displayNoteList(cmd, notes)
 	},
 }
 
 func init() {
+	notesListCmd.Flags().StringP("group", "g", "", "Only list notes in this notebook group")
 	notesCmd.AddCommand(notesListCmd)
 }
 
-func displayNoteList(notes []services.Note) error {
+func displayNoteList(cmd *cobra.Command, notes []services.Note) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != services.OutputText {
+		return services.WriteOutput(os.Stdout, format, notes)
+	}
+
 	output, err := services.TuiRender("note-list", map[string]any{
 		"Notes": notes,
 	})
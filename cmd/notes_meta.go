@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesMetaCmd = &cobra.Command{
+	Use:   "meta [note] <list|get|set|unset> [field...]",
+	Short: "Get, set and unset a note's frontmatter fields",
+	Long: `Reads and changes the frontmatter of a note in place. The body and the
+other fields, with their order and comments, are left as they are.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title, as with notes show. With --where the note is left out
+and the action applies to every note matching a SQL predicate on the notes
+view, as used with notes list --sql.
+
+Actions:
+  list                    Print every field
+  get <field>...          Print fields' values
+  set <change>...         Change fields: key=value sets a field, key+=value
+                          adds a value to a list and key-=value removes one
+  unset <field>...        Remove fields
+
+Values are read as YAML, so priority=2 is a number, done=true a boolean,
+due=2025-06-01 a date and tags=[a, b] a list. Changes that would make the
+changed fields invalid for the notebook's frontmatter schema are refused.
+
+Examples:
+  # Mark a note done and tag it
+  opennotes notes meta "Release Plan" set status=done tags+=release
+
+  # Print a field
+  opennotes notes meta release get status
+
+  # Remove a field
+  opennotes notes meta release unset draft
+
+  # Triage every open bug at once, checking first
+  opennotes notes meta --where "metadata['status'] = 'open' AND relative LIKE 'bugs/%'" set status=triaged --dry-run
+
+  # List a note's frontmatter as JSON
+  opennotes notes meta release list --format json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		var notes []string
+		where, _ := cmd.Flags().GetString("where")
+		if where != "" {
+			if notes, err = nb.Notes.SelectNotes(ctx, where); err != nil {
+				return err
+			}
+		} else {
+			if len(args) < 2 {
+				return fmt.Errorf("expected a note and an action, e.g. notes meta <note> list")
+			}
+			note, err := nb.Notes.FindNote(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			notes = []string{note.File.Relative}
+			args = args[1:]
+		}
+
+		action, fields := args[0], args[1:]
+		switch action {
+		case "list", "get":
+			if action == "get" && len(fields) == 0 {
+				return fmt.Errorf("get needs at least one field")
+			}
+			if action == "list" && len(fields) > 0 {
+				return fmt.Errorf("list takes no fields")
+			}
+			return printNoteMeta(cmd, nb, notes, fields, where != "")
+		case "set", "unset":
+			if len(fields) == 0 {
+				return fmt.Errorf("%s needs at least one field", action)
+			}
+			changes, err := metaChanges(action, fields)
+			if err != nil {
+				return err
+			}
+			return updateNoteMeta(cmd, nb, notes, changes)
+		default:
+			return fmt.Errorf("unknown action %q (expected list, get, set or unset)", action)
+		}
+	},
+}
+
+func init() {
+	notesMetaCmd.Flags().String("where", "", "Apply to every note matching this SQL predicate on the notes view")
+	notesMetaCmd.Flags().Bool("dry-run", false, "Show which notes would change without changing them")
+	notesCmd.AddCommand(notesMetaCmd)
+}
+
+// noteMeta is a note's frontmatter fields, for machine-readable output.
+type noteMeta struct {
+	Relative string         `json:"relative"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// printNoteMeta prints the fields of notes, or every field when fields is
+// empty. A single note's fields are printed one per line as key: value, or
+// for get with one field just the value; a missing field is an error. With
+// many notes, each note's fields are printed on one line after its path.
+func printNoteMeta(cmd *cobra.Command, nb *services.Notebook, notes, fields []string, many bool) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	results := make([]noteMeta, 0, len(notes))
+	var lines []string
+	for _, relative := range notes {
+		frontmatter, err := nb.Notes.ReadMeta(relative)
+		if err != nil {
+			return err
+		}
+
+		keys := fields
+		if len(keys) == 0 {
+			keys = frontmatter.Keys()
+		}
+		meta := noteMeta{Relative: relative, Metadata: make(map[string]any, len(keys))}
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value, ok, err := frontmatter.Get(key)
+			if err != nil {
+				return err
+			}
+			if !ok && !many {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%s has no field %s", relative, key)
+			}
+			if ok {
+				meta.Metadata[key] = value
+			}
+			values = append(values, metaValueText(key, value, len(fields) != 1 || many))
+		}
+		results = append(results, meta)
+
+		switch {
+		case many:
+			lines = append(lines, relative+"\t"+strings.Join(values, "\t"))
+		default:
+			lines = append(lines, values...)
+		}
+	}
+
+	if format != services.OutputText {
+		if !many {
+			return services.WriteOutput(os.Stdout, format, results[0].Metadata)
+		}
+		return services.WriteOutput(os.Stdout, format, results)
+	}
+
+	if many && len(notes) == 0 {
+		fmt.Println("No notes match.")
+		return nil
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// metaValueText returns a field's value as text, after its key when keyed.
+func metaValueText(key string, value any, keyed bool) string {
+	text := services.MetadataValueString(value)
+	if keyed {
+		return key + ": " + text
+	}
+	return text
+}
+
+// metaChanges parses the fields of set or unset.
+func metaChanges(action string, fields []string) ([]services.MetaChange, error) {
+	if action == "set" {
+		return services.ParseMetaChanges(fields)
+	}
+
+	changes := make([]services.MetaChange, len(fields))
+	for i, key := range fields {
+		if strings.Contains(key, "=") {
+			return nil, fmt.Errorf("unset takes field names, not %q", key)
+		}
+		changes[i] = services.MetaChange{Key: key, Op: services.MetaUnset}
+	}
+	return changes, nil
+}
+
+// metaUpdate is whether a note changed, for machine-readable output.
+type metaUpdate struct {
+	Relative string `json:"relative"`
+	Changed  bool   `json:"changed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// updateNoteMeta applies changes to every note. A note that can't be changed
+// is reported and the rest are still changed.
+func updateNoteMeta(cmd *cobra.Command, nb *services.Notebook, notes []string, changes []services.MetaChange) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	updates := make([]metaUpdate, 0, len(notes))
+	changed, failed := 0, 0
+	for _, relative := range notes {
+		update := metaUpdate{Relative: relative}
+		update.Changed, err = nb.Notes.UpdateMeta(relative, changes, dryRun)
+		if err != nil && len(notes) == 1 {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if err != nil {
+			update.Error = err.Error()
+			failed++
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		if update.Changed {
+			changed++
+			if format == services.OutputText {
+				verb := "Updated"
+				if dryRun {
+					verb = "Would update"
+				}
+				fmt.Printf("%s note: %s\n", verb, relative)
+			}
+		}
+		updates = append(updates, update)
+	}
+
+	if format != services.OutputText {
+		if err := services.WriteOutput(os.Stdout, format, updates); err != nil {
+			return err
+		}
+	} else if len(notes) == 0 {
+		fmt.Println("No notes match.")
+	} else if changed == 0 && failed == 0 {
+		fmt.Println("No changes.")
+	}
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d notes not updated", failed, len(notes))
+	}
+	return nil
+}
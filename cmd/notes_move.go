@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesMoveCmd = &cobra.Command{
+	Use:     "move <note> <destination>",
+	Aliases: []string{"mv", "rename"},
+	Short:   "Move or rename a note and update links to it",
+	Long: `Moves a note to a new path and rewrites the links that point at it.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title, as with notes show. The destination is relative to the
+notebook root. A destination ending in / or naming a directory keeps the
+note's filename, and .md is added when missing.
+
+Markdown links ([text](path.md)), reference definitions ([id]: path.md) and
+wikilinks ([[path]], [[path|alias]]) to the note are rewritten across the
+notebook, keeping their #fragments and aliases. Links in the moved note
+itself are rewritten so they still resolve from its new directory. Links in
+code blocks and code spans are left alone.
+
+With --to-notebook the note moves into another notebook, given by path or
+name. Markdown links still follow it, but wikilinks can't cross notebooks
+and are listed as warnings.
+
+Use --dry-run to see the changes as a diff without making them.
+
+Examples:
+  # Rename a note
+  opennotes notes move meeting-notes team-sync
+
+  # Move a note into a folder
+  opennotes notes move "Release Plan" archive/
+
+  # Preview the link changes
+  opennotes notes move projects/release.md archive/2025/release.md --dry-run
+
+  # Move a note into another notebook
+  opennotes notes move ideas/garden.md inbox/ --to-notebook ~/personal`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		note, err := nb.Notes.FindNote(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		root := nb.Config.Root
+		if target, _ := cmd.Flags().GetString("to-notebook"); target != "" {
+			other, err := findNotebook(target)
+			if err != nil {
+				return err
+			}
+			root = other.Config.Root
+		}
+
+		dest, err := services.MoveDestination(root, args[1], note.File.Filepath)
+		if err != nil {
+			return err
+		}
+
+		plan, err := nb.Notes.PlanMove(ctx, note, dest)
+		if err != nil {
+			return err
+		}
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if !dryRun {
+			if err := plan.Apply(); err != nil {
+				return err
+			}
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, plan)
+		}
+
+		if dryRun {
+			fmt.Print(plan.Diff())
+			return nil
+		}
+
+		fmt.Printf("Moved note: %s -> %s\n", plan.From, plan.To)
+		if links := plan.LinkCount(); links > 0 {
+			fmt.Printf("Updated %d link(s) in %d note(s)\n", links, len(plan.Edits))
+		}
+		return nil
+	},
+}
+
+func init() {
+	notesMoveCmd.Flags().Bool("dry-run", false, "Show the changes as a diff without making them")
+	notesMoveCmd.Flags().String("to-notebook", "", "Move the note into another notebook (path or name)")
+	notesCmd.AddCommand(notesMoveCmd)
+}
+
+// findNotebook opens the notebook at path ref, or the known notebook named ref.
+func findNotebook(ref string) (*services.Notebook, error) {
+	if notebookService.HasNotebook(ref) {
+		return notebookService.Open(ref)
+	}
+
+	notebooks, err := notebookService.List("")
+	if err != nil {
+		return nil, err
+	}
+	for _, nb := range notebooks {
+		if nb.Config.Name == ref {
+			return nb, nil
+		}
+	}
+	return nil, fmt.Errorf("no notebook found at or named %q", ref)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesQueryCmd = &cobra.Command{
+	Use:   "query [name]",
+	Short: "Run a saved query",
+	Long: `Runs a named SQL query saved in the notebook's .opennotes.json.
+Without a name, lists the saved queries.
+
+Saved queries live in the "queries" section of the notebook config:
+
+  "queries": [
+    {
+      "name": "open_actions",
+      "description": "Unchecked tasks",
+      "sql": "SELECT relative, text FROM tasks WHERE NOT done"
+    },
+    {
+      "name": "modified_since",
+      "sql": "SELECT title, mtime FROM notes WHERE mtime >= $since ORDER BY mtime DESC",
+      "params": [{"name": "since", "default": "2025-01-01"}],
+      "format": "csv"
+    }
+  ]
+
+$name in the SQL refers to a parameter, set with --param name=value. Values
+are passed as SQL strings, and DuckDB converts them where a date or number is
+expected. Parameters without a default must be given. "format" sets the
+default output format, --format overrides it.
+
+Queries whose parameters all have defaults are also available as views in
+--sql and notes sql, e.g. SELECT * FROM open_actions.
+
+Examples:
+  # List saved queries
+  opennotes notes query
+
+  # Run a saved query
+  opennotes notes query open_actions
+
+  # Run a saved query with a parameter
+  opennotes notes query modified_since --param since=2025-06-01`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return listSavedQueries(cmd, nb.Notes.SavedQueries())
+		}
+
+		rawParams, _ := cmd.Flags().GetStringArray("param")
+		params, err := parseNameValues("param", rawParams)
+		if err != nil {
+			return err
+		}
+
+		query, ok := nb.Notes.SavedQuery(args[0])
+		if !ok {
+			return fmt.Errorf("no saved query named %q (run opennotes notes query to list them)", args[0])
+		}
+
+		results, err := nb.Notes.RunSavedQuery(context.Background(), query.Name, params)
+		if err != nil {
+			return fmt.Errorf("saved query failed: %w", err)
+		}
+
+		// The query's format applies unless --format was given
+		if query.Format != "" && !cmd.Flags().Changed("format") {
+			format, err := services.ParseOutputFormat(query.Format)
+			if err != nil {
+				return fmt.Errorf("query %s: %w", query.Name, err)
+			}
+			if err := cmd.Flags().Set("format", string(format)); err != nil {
+				return err
+			}
+		}
+
+		return printSQLResults(cmd, results)
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesQueryCmd)
+
+	notesQueryCmd.Flags().StringArrayP("param", "p", nil, "Query parameter as name=value (repeatable)")
+}
+
+// parseNameValues parses the name=value pairs given with --flag.
+func parseNameValues(flag string, raw []string) (map[string]string, error) {
+	params := make(map[string]string, len(raw))
+	for _, pair := range raw {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --%s %q, expected name=value", flag, pair)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// listSavedQueries prints the saved queries and their parameters.
+func listSavedQueries(cmd *cobra.Command, queries []services.SavedQuery) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != services.OutputText {
+		return services.WriteOutput(os.Stdout, format, queries)
+	}
+
+	if len(queries) == 0 {
+		fmt.Println("No saved queries. Add them to the \"queries\" section of .opennotes.json.")
+		return nil
+	}
+
+	for _, query := range queries {
+		fmt.Print(query.Name)
+		if query.Description != "" {
+			fmt.Printf(" - %s", query.Description)
+		}
+		fmt.Println()
+
+		for _, param := range query.Params {
+			fmt.Printf("  --param %s=", param.Name)
+			if param.Default != nil {
+				fmt.Printf("%s (default)", *param.Default)
+			} else {
+				fmt.Print("<required>")
+			}
+			if param.Description != "" {
+				fmt.Printf("  %s", param.Description)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
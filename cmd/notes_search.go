@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/zenobi-us/opennotes/internal/services"
@@ -11,26 +13,69 @@ import (
 var notesSearchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search notes",
-	Long: `Searches notes by content or filename using DuckDB SQL.
-
-The query searches both file names and content of markdown files.
+	Long: `Searches notes by title, file name and content.
+
+Results are ranked by relevance (BM25), best match first, with a snippet
+showing where each note matched. Every word must appear in a note; words
+match their variants ("meeting" finds "meetings") and prefixes ("java" finds
+"javascript"). Wrap words in double quotes to match an exact phrase.
+Chinese, Japanese and Korean text matches character by character, so 测试
+finds "这是测试文档". When nothing matches this way, notes containing the
+query as written are returned, so "eeting" still finds "meeting".
+
+Filters narrow the results by path or frontmatter field:
+  tag:work                 note is tagged work or a tag under it, e.g. work/api
+  title:"release plan"     title contains "release plan"
+  path:projects/*          note is under projects/ (globs allowed)
+  status:done              any frontmatter field equals a value (globs allowed)
+  created:>2025-01-01      compare dates or numbers with >, >=, < or <=
+  -status:done             prefix a filter or word with - to exclude it
+
+A query made only of filters lists the matching notes in path order. When
+the query starts with -, put -- before it so it isn't read as a flag:
+  opennotes notes search -- '-status:done deploy'
 
 Examples:
   # Search for notes containing "meeting"
   opennotes notes search "meeting"
 
+  # Search for an exact phrase
+  opennotes notes search '"release plan" draft'
+
+  # Open work notes created this year, mentioning deploys
+  opennotes notes search 'tag:work -status:done created:>2025-01-01 deploy'
+
+  # Notes that aren't done; -- keeps the leading - from being read as a flag
+  opennotes notes search -- '-status:done'
+
   # Search with specific notebook
   opennotes notes search "todo" --notebook ~/notes
 
-  # Execute custom SQL query to find all notes
-  opennotes notes search --sql "SELECT filepath, content FROM read_markdown('**/*.md', include_filepath:=true) LIMIT 10"
+  # Ranked results with scores as newline-delimited JSON
+  opennotes notes search "deploy" --format ndjson
+
+  # Execute custom SQL query against the notes view
+  opennotes notes search --sql "SELECT title, relative FROM notes ORDER BY mtime DESC LIMIT 10"
 
   # Find notes with Python code blocks
-  opennotes notes search --sql "SELECT filepath FROM read_markdown('**/*.md', include_filepath:=true) WHERE content LIKE '%python%'"
+  opennotes notes search --sql "SELECT DISTINCT relative FROM code_blocks WHERE language = 'python'"
+
+SQL Views:
+  notes (filepath, relative, title, metadata, content, mtime, size, groups,
+  group, tags, link_count, backlink_count); groups lists every notebook
+  group the note matches, group is the first of them
+  note_links (source, target, kind, link, fragment, line)
+  links, headings, code_blocks, tasks (one row per element, with filepath
+  and relative); see docs/sql-guide.md.
 
 SQL Security:
-  Only SELECT queries allowed. Read-only access enforced.
-  30-second timeout per query. No data modification possible.`,
+  Queries must be a single SELECT statement and can only read files inside
+  the notebook. Relative read_markdown patterns are resolved against the
+  notebook root; read_text, read_csv and read_json need absolute paths.
+  30-second timeout per query. Memory, threads and returned rows are limited
+  (see OPENNOTES_SQL_* in opennotes --help).
+
+For an interactive SQL session use opennotes notes sql.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get --sql flag if provided
@@ -49,13 +94,7 @@ SQL Security:
 				return fmt.Errorf("SQL query failed: %w", err)
 			}
 
-			// Create display service and render results
-			display, err := services.NewDisplay()
-			if err != nil {
-				return fmt.Errorf("failed to create display: %w", err)
-			}
-
-			return display.RenderSQLResults(results)
+			return printSQLResults(cmd, results)
 		}
 
 		// Normal search mode - require a query argument
@@ -73,13 +112,21 @@ SQL Security:
 			return fmt.Errorf("failed to search notes: %w", err)
 		}
 
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, notes)
+		}
+
 		if len(notes) == 0 {
 			fmt.Printf("No notes found matching '%s'\n", args[0])
 			return nil
 		}
 
 		fmt.Printf("Found %d note(s) matching '%s':\n\n", len(notes), args[0])
-		return displayNoteList(notes)
+		return displayNoteList(cmd, notes)
 	},
 }
 
@@ -92,4 +139,13 @@ func init() {
 		"",
 		"Execute custom SQL query against notes (read-only, 30s timeout, SELECT/WITH only)",
 	)
+
+	// A query starting with a negated term, e.g. -status:done, looks like a
+	// shorthand flag to cobra, so point at the -- form instead.
+	notesSearchCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		if strings.HasPrefix(err.Error(), "unknown shorthand flag") {
+			return fmt.Errorf("%w\nqueries starting with - must follow --, e.g. opennotes notes search -- '-status:done'", err)
+		}
+		return err
+	})
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesShowCmd = &cobra.Command{
+	Use:     "show <note>",
+	Aliases: []string{"cat"},
+	Short:   "Show a note",
+	Long: `Shows a note's frontmatter and content, rendered for the terminal.
+
+The note can be given as a path relative to the notebook root, a filename,
+a slug or a title. The .md extension is optional. If the name matches more
+than one note, the matching paths are listed so you can pick one.
+
+Use --raw to print the file as it is on disk, and --section to print only
+one heading and everything under it.
+
+Examples:
+  # Show a note by filename
+  opennotes notes show meeting-notes
+
+  # Show a note by title
+  opennotes notes show "Release Plan"
+
+  # Print the unrendered file
+  opennotes notes show projects/release.md --raw
+
+  # Print just one section
+  opennotes notes show "Release Plan" --section "Open Questions"
+
+  # Print the note as JSON
+  opennotes notes show release --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		note, err := nb.Notes.FindNote(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+
+		raw, _ := cmd.Flags().GetBool("raw")
+		section, _ := cmd.Flags().GetString("section")
+
+		if section != "" {
+			content, ok := core.ExtractSection(note.Content, section)
+			if !ok {
+				return fmt.Errorf("section %q not found in %s", section, note.File.Relative)
+			}
+			note.Content = content
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, note)
+		}
+
+		if raw {
+			if section != "" {
+				fmt.Print(note.Content)
+				return nil
+			}
+			data, err := os.ReadFile(note.File.Filepath)
+			if err != nil {
+				return fmt.Errorf("failed to read note: %w", err)
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		// Lists and maps are shown as YAML, as they're written in frontmatter
+		metadata := make(map[string]string, len(note.Metadata))
+		for key, value := range note.Metadata {
+			metadata[key] = services.MetadataValueYAML(value)
+		}
+
+		output, err := services.TuiRender("note-detail", map[string]any{
+			"Title":    note.DisplayName(),
+			"File":     note.File,
+			"Metadata": metadata,
+			"Groups":   note.Groups,
+			"Tags":     note.Tags,
+			"Content":  note.Content,
+		})
+		if err != nil {
+			// Fallback to the unrendered content
+			fmt.Print(note.Content)
+			return nil
+		}
+
+		fmt.Print(output)
+		return nil
+	},
+}
+
+func init() {
+	notesShowCmd.Flags().Bool("raw", false, "Print the note without rendering")
+	notesShowCmd.Flags().String("section", "", "Only show the section under this heading")
+	notesCmd.AddCommand(notesShowCmd)
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+	"golang.org/x/term"
+)
+
+var notesSQLCmd = &cobra.Command{
+	Use:   "sql [query]",
+	Short: "Query notes with SQL",
+	Long: `Runs SQL against the notebook, or starts an interactive SQL session.
+
+With a query argument the query is run once, like notes search --sql.
+Without one an interactive session starts. Statements end with ";" and may
+span several lines. Press Tab to complete table, column and frontmatter key
+names, and the arrow keys to recall earlier statements.
+
+Meta-commands:
+  .tables           List the tables and views you can query
+  .schema [name]    Show the columns of a table or view
+  .help             Show help
+  .quit             Leave the session (or press Ctrl-D)
+
+Queries have the same views and restrictions as notes search --sql: a
+single SELECT statement, files inside the notebook only, a 30-second
+timeout and a row limit.
+
+When input isn't a terminal, statements are read from it without prompts,
+so a file of queries can be piped in.
+
+Examples:
+  # Start an interactive session
+  opennotes notes sql
+
+  # Run a single query
+  opennotes notes sql "SELECT title FROM notes ORDER BY mtime DESC LIMIT 5"
+
+  # Run queries from a file as JSON
+  opennotes notes sql --format ndjson < queries.sql`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			results, err := nb.Notes.ExecuteSQLSafe(context.Background(), args[0])
+			if err != nil {
+				return fmt.Errorf("SQL query failed: %w", err)
+			}
+			return printSQLResults(cmd, results)
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		display, err := services.NewDisplay()
+		if err != nil {
+			return fmt.Errorf("failed to create display: %w", err)
+		}
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			repl := services.NewSQLRepl(nb.Notes, display, format, os.Stdout, nil)
+			return runSQLScript(repl, os.Stdin)
+		}
+
+		historyFile := cfgService.Store.SQLHistoryFile
+		if historyFile == "" {
+			historyFile = services.DefaultSQLHistoryFile()
+		}
+		history, err := services.LoadSQLHistory(historyFile, services.DefaultSQLHistorySize)
+		if err != nil {
+			return err
+		}
+
+		return runSQLRepl(nb.Notes, display, format, history)
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesSQLCmd)
+}
+
+// runSQLRepl runs an interactive session on the terminal.
+func runSQLRepl(notes *services.NoteService, display *services.Display, format services.OutputFormat, history *services.SQLHistory) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(fd, state)
+	}()
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, services.SQLPrompt)
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		_ = terminal.SetSize(width, height)
+	}
+
+	ctx := context.Background()
+	repl := services.NewSQLRepl(notes, display, format, terminal, history)
+	if err := repl.LoadCompletions(ctx); err != nil {
+		log := services.Log("SQLRepl")
+		log.Warn().Err(err).Msg("tab completion unavailable")
+	}
+	terminal.History = history
+	terminal.AutoCompleteCallback = repl.Complete
+
+	fmt.Fprintln(terminal, "Enter .help for help, .quit or Ctrl-D to leave.")
+	for {
+		terminal.SetPrompt(repl.Prompt())
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := repl.HandleLine(ctx, line); errors.Is(err, services.ErrQuit) {
+			return nil
+		}
+	}
+}
+
+// runSQLScript runs the statements read from r, as piped input.
+func runSQLScript(repl *services.SQLRepl, r io.Reader) error {
+	ctx := context.Background()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := repl.HandleLine(ctx, scanner.Text()); errors.Is(err, services.ErrQuit) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read queries: %w", err)
+	}
+
+	repl.Flush(ctx)
+	return nil
+}
+
+// printSQLResults writes query results in the --format output format.
+func printSQLResults(cmd *cobra.Command, results *services.ResultSet) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != services.OutputText {
+		if results.Truncated {
+			fmt.Fprintf(os.Stderr, "results truncated at %d rows\n", results.Len())
+		}
+		return services.WriteSQLResults(os.Stdout, format, results)
+	}
+
+	// Create display service and render results
+	display, err := services.NewDisplay()
+	if err != nil {
+		return fmt.Errorf("failed to create display: %w", err)
+	}
+
+	return display.RenderSQLResults(results)
+}
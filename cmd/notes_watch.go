@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var notesWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the notebook for note changes",
+	Long: `Watches the notebook's notes directory and prints an event whenever a
+note is created, modified, renamed or deleted.
+
+The note index is refreshed as changes happen and is only held open while
+a batch of changes is applied, so other commands stay fast while a watcher
+is running. Press Ctrl+C to stop.
+
+Renames are reported as a "renamed" event for the old path followed by a
+"created" event for the new one.
+
+Examples:
+  # Watch the current notebook
+  opennotes notes watch
+
+  # Stream events as newline-delimited JSON for scripts
+  opennotes notes watch --format ndjson | jq -r 'select(.type == "created") | .path'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ndjson, _ := cmd.Flags().GetBool("ndjson")
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		switch format {
+		case services.OutputText:
+		case services.OutputJSON, services.OutputNDJSON:
+			// Events are a stream, so JSON output is always one event per line
+			ndjson = true
+		default:
+			return fmt.Errorf("notes watch does not support --format %s (use json or ndjson)", format)
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		encoder := json.NewEncoder(os.Stdout)
+		if !ndjson {
+			fmt.Fprintf(os.Stderr, "Watching %s (Ctrl+C to stop)\n", nb.Config.Root)
+		}
+
+		err = nb.Notes.Watch(ctx, func(event services.WatchEvent) {
+			if ndjson {
+				if err := encoder.Encode(event); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to write event: %v\n", err)
+				}
+				return
+			}
+			fmt.Printf("%s  %-8s  %s\n", event.Time.Format("15:04:05"), event.Type, event.Relative)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch notebook: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	notesCmd.AddCommand(notesWatchCmd)
+
+	notesWatchCmd.Flags().Bool("ndjson", false, "Emit events as newline-delimited JSON (same as --format ndjson)")
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/zenobi-us/opennotes/internal/services"
 )
@@ -21,9 +23,30 @@ queried using DuckDB's powerful SQL capabilities.
 
 Environment Variables:
   OPENNOTES_CONFIG    Path to config file (default: ~/.config/opennotes/config.json)
+  OPENNOTES_MARKDOWN_EXTENSION
+                      Local markdown extension file, or "builtin" to skip
+                      the download and use the built-in markdown reader
+  OPENNOTES_INDEX_DIR Directory for the persistent note index
+                      (default: ~/.cache/opennotes/index)
+  OPENNOTES_SQL_MEMORY_LIMIT
+                      Memory limit for --sql queries (default: 512MB)
+  OPENNOTES_SQL_THREADS
+                      Threads for --sql queries (default: 2)
+  OPENNOTES_SQL_MAX_ROWS
+                      Most rows a --sql query returns (default: 10000)
+  OPENNOTES_SQL_HISTORY_FILE
+                      History file for notes sql
+                      (default: ~/.cache/opennotes/sql_history)
+  OPENNOTES_EDITOR    Editor for notes edit (default: $VISUAL, then $EDITOR, then vi)
   DEBUG               Enable debug logging (set to any value)
   LOG_LEVEL           Set log level (debug, info, warn, error)
 
+Output Formats:
+  Commands that list or show data accept --format to print machine-readable
+  output instead of the default text: json, ndjson, csv, tsv, yaml or
+  markdown-table. Field names match the JSON field names, and the tabular
+  formats flatten nested fields into dotted columns such as file.relative.
+
 Examples:
   # Initialize configuration
   opennotes init
@@ -35,11 +58,22 @@ Examples:
   opennotes notes list
 
   # Search for notes containing "todo"
-  opennotes notes search "todo"`,
+  opennotes notes search "todo"
+
+  # List notes as JSON
+  opennotes notes list --format json`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize logger first
 		services.InitLogger()
 
+		// Reject unknown output formats before doing any work. Commands with
+		// their own --format, such as notebook graph, check it themselves.
+		if cmd.LocalNonPersistentFlags().Lookup("format") == nil {
+			if _, err := outputFormat(cmd); err != nil {
+				return err
+			}
+		}
+
 		// Initialize config service
 		var err error
 		cfgService, err = services.NewConfigService()
@@ -48,7 +82,13 @@ Examples:
 		}
 
 		// Initialize database service
-		dbService = services.NewDbService()
+		dbService = services.NewDbServiceWithOptions(services.DbOptions{
+			MarkdownExtension: cfgService.Store.MarkdownExtension,
+			IndexDir:          cfgService.Store.IndexDir,
+			SQLMemoryLimit:    cfgService.Store.SQLMemoryLimit,
+			SQLThreads:        cfgService.Store.SQLThreads,
+			SQLMaxRows:        cfgService.Store.SQLMaxRows,
+		})
 
 		// Initialize notebook service
 		notebookService = services.NewNotebookService(cfgService, dbService)
@@ -74,4 +114,19 @@ func Execute() error {
 func init() {
 	// Global flags available to all commands
 	rootCmd.PersistentFlags().String("notebook", "", "Path to notebook")
+	rootCmd.PersistentFlags().String("format", string(services.OutputText), "Output format ("+formatNames()+")")
+}
+
+// outputFormat returns the output format selected with --format.
+func outputFormat(cmd *cobra.Command) (services.OutputFormat, error) {
+	name, _ := cmd.Flags().GetString("format")
+	return services.ParseOutputFormat(name)
+}
+
+func formatNames() string {
+	names := make([]string, len(services.OutputFormats))
+	for i, format := range services.OutputFormats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, ", ")
 }
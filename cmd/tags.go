@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:     "tags",
+	Aliases: []string{"tag"},
+	Short:   "List and rename tags",
+	Long: `Commands for the tags of the notes in the notebook.
+
+A note's tags are the tags in its frontmatter tags field, a list or a string
+of tags separated by commas or spaces, and the #hashtags in its body.
+Hashtags start a line or follow a space and are made of letters, digits,
+_, - and /, with at least one letter; hashtags in code are ignored. Tags are
+compared in lower case with spaces as dashes, so "Release Plan" and
+#release-plan are the same tag.
+
+Tags nest with /: a note tagged project/alpha is also under project, so
+tag:project in notes search finds it.
+
+Examples:
+  # List the tags with their note counts
+  opennotes tags list
+
+  # Rename a tag, and the tags under it, in every note
+  opennotes tags rename project client`,
+}
+
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tags with their note counts",
+	Long: `Lists every tag in the notebook with the number of notes that have it.
+A nested tag also counts for the tags above it, so project counts the notes
+tagged project/alpha.
+
+Examples:
+  # List the tags
+  opennotes tags list
+
+  # Most used tags first
+  opennotes tags list --sort count
+
+  # List as JSON
+  opennotes tags list --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		sortBy, _ := cmd.Flags().GetString("sort")
+		if sortBy != "name" && sortBy != "count" {
+			return fmt.Errorf("invalid --sort %q (expected name or count)", sortBy)
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		tags, err := nb.Notes.Tags(context.Background())
+		if err != nil {
+			return err
+		}
+		if sortBy == "count" {
+			sort.SliceStable(tags, func(i, j int) bool { return tags[i].Count > tags[j].Count })
+		}
+
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, tags)
+		}
+
+		if len(tags) == 0 {
+			fmt.Println("No tags found.")
+			return nil
+		}
+		for _, tag := range tags {
+			fmt.Printf("%s\t%d\n", tag.Tag, tag.Count)
+		}
+		return nil
+	},
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:     "rename <old> <new>",
+	Aliases: []string{"mv"},
+	Short:   "Rename or merge a tag across the notebook",
+	Long: `Renames a tag in every note's frontmatter tags and #hashtags. Tags under
+it are renamed too, so renaming project to client turns project/alpha into
+client/alpha. Renaming to a tag that's already used merges the two, and a
+tags list left with the same tag twice keeps one.
+
+The rest of each note, including the other frontmatter fields with their
+order and comments, is left as it is. Notes whose frontmatter can't be read
+are skipped with a warning.
+
+Use --dry-run to see the changes as a diff without making them.
+
+Examples:
+  # Rename a tag
+  opennotes tags rename todo later
+
+  # Move a tag under another
+  opennotes tags rename alpha project/alpha
+
+  # Merge two tags, checking first
+  opennotes tags rename meetings meeting --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		nb, err := requireNotebook(cmd)
+		if err != nil {
+			return err
+		}
+
+		plan, err := nb.Notes.PlanTagRename(context.Background(), args[0], args[1])
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if !dryRun {
+			if err := plan.Apply(); err != nil {
+				return err
+			}
+		}
+
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, plan)
+		}
+
+		if dryRun {
+			fmt.Print(plan.Diff())
+			return nil
+		}
+
+		fmt.Printf("Renamed tag: %s -> %s\n", plan.From, plan.To)
+		fmt.Printf("Updated %d tag(s) in %d note(s)\n", plan.TagCount(), len(plan.Edits))
+		return nil
+	},
+}
+
+func init() {
+	tagsListCmd.Flags().String("sort", "name", "Sort by name or count")
+	tagsRenameCmd.Flags().Bool("dry-run", false, "Show the changes as a diff without making them")
+	tagsCmd.AddCommand(tagsListCmd, tagsRenameCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenobi-us/opennotes/internal/services"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"template"},
+	Short:   "Manage note templates",
+	Long: `Commands for managing the templates notes add creates notes from.
+
+Templates are markdown files in the notebook's .opennotes/templates
+directory and in the templates directory next to the global config file,
+named after the file without .md. Templates can also be written inline in
+the "templates" of .opennotes.json. A template in .opennotes.json hides a
+notebook template of the same name, which hides a user template.
+
+A template can extend another by naming it in its frontmatter:
+
+  ---
+  extends: base
+  tags: [meeting]
+  ---
+  {{ define "content" }}## Agenda{{ end }}
+
+Its frontmatter fields replace the base's fields with the same key, or are
+added after them, and its {{ define "name" }} blocks replace the base's
+{{ block "name" . }} blocks.
+
+A template's prompts, declared in its frontmatter, are asked for by notes
+add. See notes add --help.
+
+Examples:
+  # List the templates
+  opennotes templates list
+
+  # Show a template
+  opennotes templates show meeting
+
+  # Create a notebook template extending base
+  opennotes templates new meeting --extends base`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the note templates",
+	Long: `Lists the templates available to the current notebook, with where each
+comes from and the template it extends. Outside a notebook only the user's
+templates are listed.
+
+Examples:
+  # List the templates
+  opennotes templates list
+
+  # List as JSON
+  opennotes templates list --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		nb, err := optionalNotebook(cmd)
+		if err != nil {
+			return err
+		}
+		templates, err := loadNoteTemplates(nb)
+		if err != nil {
+			return err
+		}
+
+		list := templates.List()
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, list)
+		}
+
+		if len(list) == 0 {
+			fmt.Println("No templates found.")
+			return nil
+		}
+		for _, tmpl := range list {
+			line := fmt.Sprintf("%s\t%s", tmpl.Name, tmpl.Source)
+			if tmpl.Extends != "" {
+				line += "\textends " + tmpl.Extends
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+var templatesShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a note template",
+	Long: `Prints a template as written. With --render, prints the note it creates,
+with the templates it extends applied and --title and --var filled in.
+Prompts without a --var take their defaults.
+
+Examples:
+  # Show a template
+  opennotes templates show meeting
+
+  # Preview the note it creates
+  opennotes templates show meeting --render --title "Sync" --var team=api`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		nb, err := optionalNotebook(cmd)
+		if err != nil {
+			return err
+		}
+		templates, err := loadNoteTemplates(nb)
+		if err != nil {
+			return err
+		}
+		tmpl, err := templates.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		prompts, err := templates.Prompts(tmpl.Name)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		content := tmpl.Content
+		if render, _ := cmd.Flags().GetBool("render"); render {
+			data, err := templateData(cmd, nb)
+			if err != nil {
+				return err
+			}
+			if data.Vars, err = services.AskTemplatePrompts(prompts, data.Vars, nil, nil); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			if content, err = templates.Render(tmpl.Name, data); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+
+		if format != services.OutputText {
+			return services.WriteOutput(os.Stdout, format, struct {
+				services.NoteTemplate
+				Prompts []services.TemplatePrompt `json:"prompts,omitempty"`
+				Content string                    `json:"content"`
+			}{*tmpl, prompts, content})
+		}
+		fmt.Print(content)
+		return nil
+	},
+}
+
+var templatesNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a note template",
+	Long: `Creates a starter template in the notebook's .opennotes/templates
+directory, or with --user in the user's templates directory.
+
+Examples:
+  # Create a notebook template
+  opennotes templates new meeting
+
+  # Create a template extending base and open it in your editor
+  opennotes templates new standup --extends base --edit
+
+  # Create a template for every notebook
+  opennotes templates new daily --user`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := cfgService.TemplatesDir()
+		if user, _ := cmd.Flags().GetBool("user"); !user {
+			nb, err := requireNotebook(cmd)
+			if err != nil {
+				return err
+			}
+			dir = nb.Config.TemplatesDir()
+		}
+
+		extends, _ := cmd.Flags().GetString("extends")
+		path, err := services.NewTemplateFile(dir, args[0], extends)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created template: %s\n", path)
+
+		if edit, _ := cmd.Flags().GetBool("edit"); edit {
+			// Templates aren't notes, so no schema applies
+			return editNote(context.Background(), path, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	templatesShowCmd.Flags().Bool("render", false, "Print the note the template creates")
+	templatesShowCmd.Flags().String("title", "", "Note title to render with")
+	templatesShowCmd.Flags().StringArray("var", nil, "Template variable as key=value to render with (repeatable)")
+
+	templatesNewCmd.Flags().String("extends", "", "Template the new template extends")
+	templatesNewCmd.Flags().Bool("user", false, "Create the template in the user's templates directory")
+	templatesNewCmd.Flags().BoolP("edit", "e", false, "Open the new template in your editor")
+
+	templatesCmd.AddCommand(templatesListCmd, templatesShowCmd, templatesNewCmd)
+	rootCmd.AddCommand(templatesCmd)
+}
+
+// optionalNotebook returns the notebook given with --notebook or inferred
+// from the working directory, or nil when there's none.
+func optionalNotebook(cmd *cobra.Command) (*services.Notebook, error) {
+	if notebookPath, _ := cmd.Flags().GetString("notebook"); notebookPath != "" {
+		return notebookService.Open(notebookPath)
+	}
+	return notebookService.Infer("")
+}
+
+// loadNoteTemplates loads the notebook's templates and the user's, or only
+// the user's when nb is nil.
+func loadNoteTemplates(nb *services.Notebook) (*services.NoteTemplates, error) {
+	if nb == nil {
+		return services.LoadNoteTemplates(nil, "", cfgService.TemplatesDir())
+	}
+	return services.LoadNoteTemplates(nb.Config.Templates, nb.Config.TemplatesDir(), cfgService.TemplatesDir())
+}
+
+// templateData returns the data templates are previewed with: --title,
+// --var, and the notebook when there is one.
+func templateData(cmd *cobra.Command, nb *services.Notebook) (services.NoteTemplateData, error) {
+	title, _ := cmd.Flags().GetString("title")
+	rawVars, _ := cmd.Flags().GetStringArray("var")
+	vars, err := parseNameValues("var", rawVars)
+	if err != nil {
+		return services.NoteTemplateData{}, err
+	}
+
+	data := services.NoteTemplateData{Title: title, Date: time.Now(), Vars: vars}
+	if nb != nil {
+		data.Notebook = nb.Config.Name
+		data.Context = noteContext(nb)
+	}
+	return data, nil
+}
@@ -0,0 +1,709 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontmatterFormat is the syntax a frontmatter block is written in.
+type FrontmatterFormat string
+
+const (
+	// FrontmatterYAML is YAML between --- lines
+	FrontmatterYAML FrontmatterFormat = "yaml"
+	// FrontmatterTOML is TOML between +++ lines
+	FrontmatterTOML FrontmatterFormat = "toml"
+	// FrontmatterJSON is a JSON object at the start of the document
+	FrontmatterJSON FrontmatterFormat = "json"
+)
+
+// Frontmatter block delimiters.
+const (
+	frontmatterDelimiter     = "---"
+	tomlFrontmatterDelimiter = "+++"
+)
+
+// SplitFrontmatter separates a leading frontmatter block from the body: YAML
+// between --- lines, TOML between +++ lines or a JSON object. The
+// frontmatter is returned without its delimiters. Returns ok=false (and the
+// untouched content as body) when there is none.
+func SplitFrontmatter(content string) (frontmatter, body string, ok bool) {
+	_, frontmatter, body, ok = splitFrontmatter(content)
+	return frontmatter, body, ok
+}
+
+func splitFrontmatter(content string) (format FrontmatterFormat, frontmatter, body string, ok bool) {
+	text := strings.TrimPrefix(content, "\ufeff")
+
+	if strings.HasPrefix(text, "{") {
+		if frontmatter, body, ok = splitJSONFrontmatter(text); ok {
+			return FrontmatterJSON, frontmatter, body, true
+		}
+		return "", "", content, false
+	}
+
+	firstLine, rest, found := strings.Cut(text, "\n")
+	if !found {
+		return "", "", content, false
+	}
+	delimiter := strings.TrimRight(firstLine, " \t\r")
+	switch delimiter {
+	case frontmatterDelimiter:
+		format = FrontmatterYAML
+	case tomlFrontmatterDelimiter:
+		format = FrontmatterTOML
+	default:
+		return "", "", content, false
+	}
+
+	offset := 0
+	for offset <= len(rest) {
+		line := rest[offset:]
+		end := strings.IndexByte(line, '\n')
+		if end >= 0 {
+			line = line[:end]
+		}
+
+		if strings.TrimRight(line, " \t\r") == delimiter {
+			frontmatter = rest[:offset]
+			if end < 0 {
+				return format, frontmatter, "", true
+			}
+			return format, frontmatter, rest[offset+end+1:], true
+		}
+
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	return "", "", content, false
+}
+
+// Frontmatter is the frontmatter of a note, read so that fields can be
+// changed and written back in the same format with the order, comments and
+// style of the other fields kept. Whatever its format, the fields are held as
+// a YAML mapping.
+type Frontmatter struct {
+	Format FrontmatterFormat
+	// doc is the YAML document holding fields, to keep the comments of YAML
+	// frontmatter that aren't on a field
+	doc    *yaml.Node
+	fields *yaml.Node
+	// present is whether the document had a frontmatter block
+	present bool
+	// layout is how the block was written, nil for a new block
+	layout *frontmatterLayout
+	// newline is the line ending of the document, used on every line written
+	newline string
+}
+
+// NewFrontmatter returns empty YAML frontmatter, for a document that has
+// none.
+func NewFrontmatter() *Frontmatter {
+	return newFrontmatter(FrontmatterYAML, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+}
+
+func newFrontmatter(format FrontmatterFormat, fields *yaml.Node) *Frontmatter {
+	return &Frontmatter{
+		Format:  format,
+		doc:     &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{fields}},
+		fields:  fields,
+		newline: "\n",
+	}
+}
+
+// ReadFrontmatter reads the frontmatter of a markdown document and returns it
+// with the body. A document without frontmatter gets empty YAML frontmatter.
+func ReadFrontmatter(content string) (*Frontmatter, string, error) {
+	format, raw, body, ok := splitFrontmatter(content)
+	if !ok {
+		f := NewFrontmatter()
+		f.newline = lineEnding(content)
+		return f, content, nil
+	}
+
+	var f *Frontmatter
+	var err error
+	switch format {
+	case FrontmatterTOML:
+		var fields *yaml.Node
+		var layout *frontmatterLayout
+		if fields, layout, err = parseTOMLFrontmatter(raw); err == nil {
+			f = newFrontmatter(format, fields)
+			f.layout = layout
+		}
+	case FrontmatterJSON:
+		var fields *yaml.Node
+		var layout *frontmatterLayout
+		if fields, layout, err = parseJSONFrontmatter(raw); err == nil {
+			f = newFrontmatter(format, fields)
+			f.layout = layout
+		}
+	default:
+		f, err = parseYAMLFrontmatter(raw)
+	}
+	if err != nil {
+		return nil, body, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	f.present = true
+	f.newline = lineEnding(content)
+	return f, body, nil
+}
+
+// lineEnding returns the line ending of the first line of content: "\r\n"
+// for Windows line endings, else "\n".
+func lineEnding(content string) string {
+	if end := strings.IndexByte(content, '\n'); end > 0 && content[end-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+func parseYAMLFrontmatter(raw string) (*Frontmatter, error) {
+	var doc yaml.Node
+	if strings.TrimSpace(raw) != "" {
+		if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Kind == 0 {
+		f := NewFrontmatter()
+		f.layout = &frontmatterLayout{raw: raw, head: raw}
+		return f, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping of fields")
+	}
+	return &Frontmatter{Format: FrontmatterYAML, doc: &doc, fields: doc.Content[0], layout: yamlLayout(raw, doc.Content[0])}, nil
+}
+
+// yamlLayout records how the fields of YAML frontmatter were written: each
+// field runs from its key's line to the next field, less the comment and
+// blank lines at column 0 that lead into the next field. Returns nil for
+// fields it can't split by line, such as a flow mapping.
+func yamlLayout(raw string, fields *yaml.Node) *frontmatterLayout {
+	if fields.Style&yaml.FlowStyle != 0 {
+		return nil
+	}
+
+	var lineStarts []int
+	for offset := 0; offset < len(raw); {
+		lineStarts = append(lineStarts, offset)
+		end := strings.IndexByte(raw[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	var starts []int
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key := fields.Content[i]
+		line := key.Line - 1
+		if key.Column != 1 || line < 0 || line >= len(lineStarts) || (len(starts) > 0 && lineStarts[line] <= starts[len(starts)-1]) {
+			return nil
+		}
+		starts = append(starts, lineStarts[line])
+	}
+
+	layout := &frontmatterLayout{raw: raw, head: raw}
+	if len(starts) == 0 {
+		return layout
+	}
+	layout.head = raw[:starts[0]]
+	lead := ""
+	for i, start := range starts {
+		end := len(raw)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		text, comments := splitTrailingComments(raw[start:end])
+		layout.add(fields.Content[2*i].Value, lead, text, fields.Content[2*i+1])
+		lead = comments
+	}
+	layout.tail = lead
+	return layout
+}
+
+// splitTrailingComments splits text before the comment and blank lines it
+// ends with. Indented comments belong to the text, as they may be part of
+// its value.
+func splitTrailingComments(text string) (string, string) {
+	end := len(text)
+	for end > 0 {
+		start := strings.LastIndexByte(text[:end-1], '\n') + 1
+		line := strings.TrimRight(text[start:end], " \t\r\n")
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		end = start
+	}
+	return text[:end], text[end:]
+}
+
+// frontmatterLayout is how a frontmatter block was written, so that the
+// fields that haven't changed can be written back as they were, with their
+// quoting, indentation and line breaks, rather than encoded again.
+type frontmatterLayout struct {
+	raw string
+	// head is the text before the first field and tail the text after the
+	// last, such as comments about the whole block
+	head string
+	tail string
+	keys []string
+	// fields are the fields as read, by key
+	fields map[string]fieldLayout
+	// literal is set when TOML strings were written in single quotes
+	literal bool
+}
+
+// fieldLayout is a field as it was read.
+type fieldLayout struct {
+	// lead is the text between the field and the one before it, such as
+	// comment lines about the field
+	lead string
+	// text is the field as written, empty for a TOML table as tables are
+	// kept together in the tail
+	text string
+	// value is a copy of the value as read, to tell whether it has changed
+	value *yaml.Node
+}
+
+// add records the field key, written as text after lead, with its value.
+func (l *frontmatterLayout) add(key, lead, text string, value *yaml.Node) {
+	if l.fields == nil {
+		l.fields = make(map[string]fieldLayout)
+	}
+	l.keys = append(l.keys, key)
+	l.fields[key] = fieldLayout{lead: lead, text: text, value: copyYAMLNode(value)}
+}
+
+// field returns the field key as read, when it was. A nil layout has no
+// fields.
+func (l *frontmatterLayout) field(key string) (fieldLayout, bool) {
+	if l == nil {
+		return fieldLayout{}, false
+	}
+	field, ok := l.fields[key]
+	return field, ok
+}
+
+// unchanged reports whether fields are the fields as read, in the same order
+// with the same values.
+func (l *frontmatterLayout) unchanged(fields *yaml.Node) bool {
+	if l == nil || len(fields.Content) != 2*len(l.keys) {
+		return false
+	}
+	for i, key := range l.keys {
+		if fields.Content[2*i].Value != key || !sameYAMLNode(l.fields[key].value, fields.Content[2*i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyYAMLNode returns a deep copy of node. Aliases still point at the
+// original anchors.
+func copyYAMLNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyYAMLNode(child)
+	}
+	return &copied
+}
+
+// sameYAMLNode reports whether two nodes would be written the same way:
+// same values, styles and comments.
+func sameYAMLNode(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Style != b.Style || a.Tag != b.Tag || a.Value != b.Value || a.Anchor != b.Anchor || a.Alias != b.Alias ||
+		a.HeadComment != b.HeadComment || a.LineComment != b.LineComment || a.FootComment != b.FootComment ||
+		len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameYAMLNode(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns the field names in order.
+func (f *Frontmatter) Keys() []string {
+	keys := make([]string, 0, len(f.fields.Content)/2)
+	for i := 0; i+1 < len(f.fields.Content); i += 2 {
+		keys = append(keys, f.fields.Content[i].Value)
+	}
+	return keys
+}
+
+// Has reports whether the frontmatter has the field key.
+func (f *Frontmatter) Has(key string) bool {
+	return f.index(key) >= 0
+}
+
+// Get returns the value of the field key, decoded as by Map.
+func (f *Frontmatter) Get(key string) (any, bool, error) {
+	i := f.index(key)
+	if i < 0 {
+		return nil, false, nil
+	}
+	var value any
+	if err := f.fields.Content[i+1].Decode(&value); err != nil {
+		return nil, true, fmt.Errorf("invalid frontmatter field %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// Set sets the field key to value, in place when the field exists and after
+// the other fields otherwise.
+func (f *Frontmatter) Set(key string, value any) error {
+	node, err := encodeYAMLNode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	if i := f.index(key); i >= 0 {
+		existing := f.fields.Content[i+1]
+		node.HeadComment = existing.HeadComment
+		node.LineComment = existing.LineComment
+		*existing = node
+		return nil
+	}
+	f.fields.Content = append(f.fields.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &node)
+	return nil
+}
+
+// encodeYAMLNode encodes value as a YAML node. Values YAML can't hold, such
+// as channels, are errors rather than the panics yaml.v3 raises for them.
+func encodeYAMLNode(value any) (node yaml.Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	err = node.Encode(value)
+	return node, err
+}
+
+// Append adds value to the list field key unless the list has it already. A
+// field holding one value becomes a list, and a missing or empty field a list
+// of value. Reports whether the field changed.
+func (f *Frontmatter) Append(key string, value any) (bool, error) {
+	item, err := encodeYAMLNode(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	i := f.index(key)
+	if i < 0 {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{&item}}
+		f.fields.Content = append(f.fields.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, list)
+		return true, nil
+	}
+
+	existing := f.fields.Content[i+1]
+	switch {
+	case existing.Kind == yaml.SequenceNode:
+		for _, other := range existing.Content {
+			if sameYAMLValue(other, &item) {
+				return false, nil
+			}
+		}
+		existing.Content = append(existing.Content, &item)
+	case existing.ShortTag() == "!!null" || (existing.Kind == yaml.ScalarNode && existing.Value == ""):
+		*existing = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{&item}, LineComment: existing.LineComment}
+	default:
+		if sameYAMLValue(existing, &item) {
+			return false, nil
+		}
+		first := *existing
+		first.LineComment = ""
+		*existing = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{&first, &item}, LineComment: existing.LineComment}
+	}
+	return true, nil
+}
+
+// Remove removes value from the list field key, or the field when it holds
+// just value. Reports whether the field changed.
+func (f *Frontmatter) Remove(key string, value any) (bool, error) {
+	item, err := encodeYAMLNode(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	i := f.index(key)
+	if i < 0 {
+		return false, nil
+	}
+
+	existing := f.fields.Content[i+1]
+	if existing.Kind != yaml.SequenceNode {
+		if !sameYAMLValue(existing, &item) {
+			return false, nil
+		}
+		return f.Delete(key), nil
+	}
+
+	kept := existing.Content[:0]
+	for _, other := range existing.Content {
+		if !sameYAMLValue(other, &item) {
+			kept = append(kept, other)
+		}
+	}
+	changed := len(kept) != len(existing.Content)
+	existing.Content = kept
+	return changed, nil
+}
+
+// sameYAMLValue reports whether two nodes decode to the same value.
+func sameYAMLValue(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// ParseFieldValue parses a field value given as text, e.g. on the command
+// line, as YAML: 3 is a number, true a boolean, 2025-01-01 a date and [a, b]
+// a list. Text that isn't a single YAML value, such as "a: b" or "x # y", is
+// a string. The value can be passed to Set, Append and Remove.
+func ParseFieldValue(text string) any {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil || doc.Kind == 0 {
+		return text
+	}
+	value := doc.Content[0]
+	if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 {
+		return text
+	}
+	if doc.HeadComment != "" || doc.FootComment != "" || value.HeadComment != "" || value.LineComment != "" || value.FootComment != "" {
+		return text
+	}
+	return value
+}
+
+// Delete removes the field key, reporting whether there was one.
+func (f *Frontmatter) Delete(key string) bool {
+	i := f.index(key)
+	if i < 0 {
+		return false
+	}
+	f.fields.Content = append(f.fields.Content[:i], f.fields.Content[i+2:]...)
+	return true
+}
+
+// Map returns the fields decoded: strings, numbers, booleans, time.Time for
+// dates, []any and map[string]any.
+func (f *Frontmatter) Map() (map[string]any, error) {
+	metadata := make(map[string]any)
+	if err := f.fields.Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return metadata, nil
+}
+
+// String returns the frontmatter block, delimiters included, in its format.
+// Fields that haven't changed since the block was read are written as they
+// were, and new fields go after the others.
+func (f *Frontmatter) String() (string, error) {
+	var text string
+	var err error
+	switch f.Format {
+	case FrontmatterTOML:
+		text, err = writeTOMLFrontmatter(f.fields, f.layout)
+		text = tomlFrontmatterDelimiter + "\n" + text + tomlFrontmatterDelimiter + "\n"
+	case FrontmatterJSON:
+		text, err = writeJSONFrontmatter(f.fields, f.layout)
+	default:
+		text, err = writeYAMLFrontmatter(f.doc, f.layout)
+		text = frontmatterDelimiter + "\n" + text + frontmatterDelimiter + "\n"
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write frontmatter: %w", err)
+	}
+	if f.newline == "\r\n" {
+		// Fields written again end in \n, while those kept have the document's
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	}
+	return text, nil
+}
+
+// Render returns the frontmatter block followed by body. A block added to a
+// document that had none goes straight before the body, and an empty one
+// isn't added at all.
+func (f *Frontmatter) Render(body string) (string, error) {
+	if !f.present && len(f.fields.Content) == 0 {
+		return body, nil
+	}
+
+	frontmatter, err := f.String()
+	if err != nil {
+		return "", err
+	}
+	return frontmatter + body, nil
+}
+
+// index returns the index of key's key node in the mapping, or -1.
+func (f *Frontmatter) index(key string) int {
+	for i := 0; i+1 < len(f.fields.Content); i += 2 {
+		if f.fields.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeYAMLFrontmatter writes the fields of doc as YAML. With a layout, the
+// fields that haven't changed are written as they were read and the others
+// are encoded on their own in their place.
+func writeYAMLFrontmatter(doc *yaml.Node, layout *frontmatterLayout) (string, error) {
+	fields := doc.Content[0]
+	if layout == nil {
+		if len(fields.Content) == 0 {
+			return "", nil
+		}
+		return encodeYAML(doc)
+	}
+	if layout.unchanged(fields) {
+		return layout.raw, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(layout.head)
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key, value := fields.Content[i], fields.Content[i+1]
+		if read, ok := layout.field(key.Value); ok {
+			b.WriteString(read.lead)
+			if sameYAMLNode(read.value, value) {
+				b.WriteString(read.text)
+				continue
+			}
+		}
+
+		// The comments around the field are in the layout already
+		k, v := *key, *value
+		k.HeadComment, k.FootComment, v.FootComment = "", "", ""
+		text, err := encodeYAML(&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{&k, &v}})
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	b.WriteString(layout.tail)
+	return b.String(), nil
+}
+
+func encodeYAML(node *yaml.Node) (string, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ParseFrontmatter parses the frontmatter of a markdown document.
+// Returns the decoded metadata (empty when there is no frontmatter) and the body.
+func ParseFrontmatter(content string) (map[string]any, string, error) {
+	f, body, err := ReadFrontmatter(content)
+	if err != nil {
+		return make(map[string]any), body, err
+	}
+
+	metadata, err := f.Map()
+	if err != nil {
+		return make(map[string]any), body, err
+	}
+	return metadata, body, nil
+}
+
+// SetFrontmatterField sets key to value in the frontmatter of content,
+// keeping the order, comments and style of the other fields. A frontmatter
+// block is added when content has none.
+func SetFrontmatterField(content, key string, value any) (string, error) {
+	f, body, err := ReadFrontmatter(content)
+	if err != nil {
+		return "", err
+	}
+	if err := f.Set(key, value); err != nil {
+		return "", err
+	}
+	return f.Render(body)
+}
+
+// frontmatterDateLayouts are the string date formats accepted in frontmatter
+// date fields, besides YAML timestamps.
+var frontmatterDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ValidateFrontmatter checks the types of the fields opennotes itself reads:
+// title must be a string, tags a string or a list of strings, and created and
+// updated dates.
+func ValidateFrontmatter(metadata map[string]any) ValidationErrors {
+	v := NewValidator()
+
+	if title, ok := metadata["title"]; ok {
+		if _, isString := title.(string); !isString {
+			v.WithPath("title").AddError("must be a string")
+		}
+	}
+
+	if tags, ok := metadata["tags"]; ok {
+		switch t := tags.(type) {
+		case string, nil:
+		case []any:
+			for i, tag := range t {
+				if _, isString := tag.(string); !isString {
+					v.WithPath(fmt.Sprintf("tags[%d]", i)).AddError("must be a string")
+				}
+			}
+		default:
+			v.WithPath("tags").AddError("must be a string or a list of strings")
+		}
+	}
+
+	for _, key := range []string{"created", "updated"} {
+		value, ok := metadata[key]
+		if !ok {
+			continue
+		}
+		if !isFrontmatterDate(value) {
+			v.WithPath(key).AddError("must be a date, e.g. 2006-01-02 or 2006-01-02T15:04:05Z")
+		}
+	}
+
+	return v.Errors()
+}
+
+func isFrontmatterDate(value any) bool {
+	switch v := value.(type) {
+	case time.Time:
+		return true
+	case string:
+		for _, layout := range frontmatterDateLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
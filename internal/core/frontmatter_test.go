@@ -0,0 +1,350 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		frontmatter string
+		body        string
+		ok          bool
+	}{
+		{
+			name:        "with frontmatter",
+			input:       "---\ntitle: Hello\n---\n\n# Hello\n",
+			frontmatter: "title: Hello\n",
+			body:        "\n# Hello\n",
+			ok:          true,
+		},
+		{
+			name:        "windows line endings",
+			input:       "---\r\ntitle: Hello\r\n---\r\nbody",
+			frontmatter: "title: Hello\r\n",
+			body:        "body",
+			ok:          true,
+		},
+		{
+			name:        "empty frontmatter",
+			input:       "---\n---\nbody",
+			frontmatter: "",
+			body:        "body",
+			ok:          true,
+		},
+		{
+			name:        "frontmatter at end of file",
+			input:       "---\ntitle: Hello\n---",
+			frontmatter: "title: Hello\n",
+			body:        "",
+			ok:          true,
+		},
+		{
+			name:        "toml frontmatter",
+			input:       "+++\ntitle = \"Hello\"\n+++\nbody",
+			frontmatter: "title = \"Hello\"\n",
+			body:        "body",
+			ok:          true,
+		},
+		{
+			name:        "json frontmatter",
+			input:       "{\n  \"title\": \"Hello\"\n}\nbody",
+			frontmatter: "{\n  \"title\": \"Hello\"\n}",
+			body:        "body",
+			ok:          true,
+		},
+		{
+			name:  "brace that isn't json frontmatter",
+			input: "{not json}\nbody",
+			body:  "{not json}\nbody",
+			ok:    false,
+		},
+		{
+			name:  "no frontmatter",
+			input: "# Hello\n\n---\n",
+			body:  "# Hello\n\n---\n",
+			ok:    false,
+		},
+		{
+			name:  "unterminated frontmatter",
+			input: "---\ntitle: Hello\n# Hello\n",
+			body:  "---\ntitle: Hello\n# Hello\n",
+			ok:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frontmatter, body, ok := SplitFrontmatter(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.frontmatter, frontmatter)
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestParseFrontmatter(t *testing.T) {
+	metadata, body, err := ParseFrontmatter("---\ntitle: Hello\ntags: [a, b]\n---\n# Hello\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello", metadata["title"])
+	assert.Equal(t, []any{"a", "b"}, metadata["tags"])
+	assert.Equal(t, "# Hello\n", body)
+}
+
+func TestParseFrontmatter_NoFrontmatter(t *testing.T) {
+	metadata, body, err := ParseFrontmatter("# Hello\n")
+	require.NoError(t, err)
+
+	assert.Empty(t, metadata)
+	assert.Equal(t, "# Hello\n", body)
+}
+
+func TestParseFrontmatter_InvalidYAML(t *testing.T) {
+	_, body, err := ParseFrontmatter("---\ntitle: [unclosed\n---\n# Hello\n")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid frontmatter")
+	assert.Equal(t, "# Hello\n", body)
+}
+
+func TestSetFrontmatterField(t *testing.T) {
+	updated := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "adds field",
+			input:    "---\ntitle: Hello\ntags: [a, b]\n---\n\n# Hello\n",
+			expected: "---\ntitle: Hello\ntags: [a, b]\nupdated: 2025-06-01T10:30:00Z\n---\n\n# Hello\n",
+		},
+		{
+			name:     "replaces field in place",
+			input:    "---\nupdated: 2020-01-01 # last edit\ntitle: Hello\n---\nbody",
+			expected: "---\nupdated: 2025-06-01T10:30:00Z # last edit\ntitle: Hello\n---\nbody",
+		},
+		{
+			name:     "empty frontmatter",
+			input:    "---\n---\nbody",
+			expected: "---\nupdated: 2025-06-01T10:30:00Z\n---\nbody",
+		},
+		{
+			name:     "no frontmatter",
+			input:    "# Hello\n",
+			expected: "---\nupdated: 2025-06-01T10:30:00Z\n---\n# Hello\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetFrontmatterField(tt.input, "updated", updated)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+
+			metadata, _, err := ParseFrontmatter(result)
+			require.NoError(t, err)
+			assert.Equal(t, updated, metadata["updated"])
+		})
+	}
+}
+
+func TestSetFrontmatterField_Invalid(t *testing.T) {
+	_, err := SetFrontmatterField("---\ntitle: [unclosed\n---\nbody", "updated", "now")
+	assert.ErrorContains(t, err, "invalid frontmatter")
+
+	_, err = SetFrontmatterField("---\n- a\n- b\n---\nbody", "updated", "now")
+	assert.ErrorContains(t, err, "expected a mapping")
+}
+
+func TestReadFrontmatter(t *testing.T) {
+	f, body, err := ReadFrontmatter("---\n# about the note\n\ntitle: Hello # shown\nstatus: draft\ntags:\n  - a\n---\n# Hello\n")
+	require.NoError(t, err)
+	assert.Equal(t, FrontmatterYAML, f.Format)
+	assert.Equal(t, "# Hello\n", body)
+	assert.Equal(t, []string{"title", "status", "tags"}, f.Keys())
+
+	value, ok, err := f.Get("tags")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []any{"a"}, value)
+	_, ok, _ = f.Get("missing")
+	assert.False(t, ok)
+
+	require.NoError(t, f.Set("title", "Hello: again"))
+	require.NoError(t, f.Set("metadata", map[string]any{"author": "John", "reviewed": true}))
+	assert.True(t, f.Delete("status"))
+	assert.False(t, f.Delete("status"))
+	assert.False(t, f.Has("status"))
+
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "---\n# about the note\n\ntitle: 'Hello: again' # shown\ntags:\n  - a\nmetadata:\n  author: John\n  reviewed: true\n---\n# Hello\n", result)
+}
+
+func TestFrontmatter_KeepsLayout(t *testing.T) {
+	content := "---\n# about the note\ntitle:   \"Hi\"\ntags:\n- a\n- b   # first two\nstatus: draft # for now\nnotes: |\n  # not a comment\n\n  more\n\n# the end\n---\nbody\n"
+
+	tests := []struct {
+		name     string
+		change   func(f *Frontmatter) error
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			change:   func(f *Frontmatter) error { return nil },
+			expected: content,
+		},
+		{
+			name:     "one field",
+			change:   func(f *Frontmatter) error { return f.Set("status", "done") },
+			expected: strings.Replace(content, "status: draft # for now", "status: done # for now", 1),
+		},
+		{
+			name:     "list",
+			change:   func(f *Frontmatter) error { _, err := f.Append("tags", "c"); return err },
+			expected: strings.Replace(content, "tags:\n- a\n- b   # first two\n", "tags:\n  - a\n  - b # first two\n  - c\n", 1),
+		},
+		{
+			name:     "new field before the last comment",
+			change:   func(f *Frontmatter) error { return f.Set("owner", "ana") },
+			expected: strings.Replace(content, "\n# the end\n", "owner: ana\n\n# the end\n", 1),
+		},
+		{
+			name: "deleted field",
+			change: func(f *Frontmatter) error {
+				f.Delete("tags")
+				return nil
+			},
+			expected: strings.Replace(content, "tags:\n- a\n- b   # first two\n", "", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, body, err := ReadFrontmatter(content)
+			require.NoError(t, err)
+			require.NoError(t, tt.change(f))
+			result, err := f.Render(body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewFrontmatter(t *testing.T) {
+	f := NewFrontmatter()
+
+	result, err := f.Render("# Hello\n")
+	require.NoError(t, err)
+	assert.Equal(t, "# Hello\n", result, "empty frontmatter isn't added")
+
+	require.NoError(t, f.Set("title", "Hello"))
+	result, err = f.Render("# Hello\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Hello\n---\n# Hello\n", result, "the body follows the block")
+}
+
+func TestFrontmatter_AppendRemove(t *testing.T) {
+	f, body, err := ReadFrontmatter("---\ntags: [a] # topics\nstatus: draft\nempty:\n---\nbody\n")
+	require.NoError(t, err)
+
+	changed, err := f.Append("tags", "b")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = f.Append("tags", "a")
+	require.NoError(t, err)
+	assert.False(t, changed, "values already in the list aren't added")
+
+	changed, err = f.Append("status", "review")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = f.Append("empty", 1)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = f.Append("aliases", "x")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = f.Remove("tags", "a")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = f.Remove("tags", "missing")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	changed, err = f.Remove("aliases", "y")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntags: [b] # topics\nstatus:\n  - draft\n  - review\nempty:\n  - 1\naliases:\n  - x\n---\nbody\n", result)
+
+	f, _, err = ReadFrontmatter("---\nstatus: draft\n---\n")
+	require.NoError(t, err)
+	changed, err = f.Remove("status", "draft")
+	require.NoError(t, err)
+	assert.True(t, changed, "a field holding just the value is removed")
+	assert.False(t, f.Has("status"))
+}
+
+func TestParseFieldValue(t *testing.T) {
+	tests := []struct {
+		text string
+		want any
+	}{
+		{"done", "done"},
+		{"2", 2},
+		{"1.5", 1.5},
+		{"true", true},
+		{"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"[a, b]", []any{"a", "b"}},
+		{"{a: 1}", map[string]any{"a": 1}},
+		{"'quoted: text'", "quoted: text"},
+		{"", ""},
+		{"a: b", "a: b"},
+		{"x # y", "x # y"},
+		{"[unclosed", "[unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			f := NewFrontmatter()
+			require.NoError(t, f.Set("field", ParseFieldValue(tt.text)))
+			value, _, err := f.Get("field")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+func TestValidateFrontmatter(t *testing.T) {
+	metadata, _, err := ParseFrontmatter("---\ntitle: Hello\ntags: [a, b]\ncreated: 2025-01-01T09:00:00Z\nupdated: \"2025-01-02\"\n---\n")
+	require.NoError(t, err)
+	assert.Empty(t, ValidateFrontmatter(metadata))
+
+	assert.Empty(t, ValidateFrontmatter(map[string]any{"tags": "single"}))
+	assert.Empty(t, ValidateFrontmatter(map[string]any{}))
+
+	errs := ValidateFrontmatter(map[string]any{
+		"title":   []any{"a"},
+		"tags":    []any{"ok", 3},
+		"created": "last tuesday",
+		"updated": 42,
+	})
+	require.Len(t, errs, 4)
+	assert.Equal(t, "title", errs[0].Path)
+	assert.Equal(t, "tags[1]", errs[1].Path)
+	assert.Equal(t, "created", errs[2].Path)
+	assert.Equal(t, "updated", errs[3].Path)
+
+	errs = ValidateFrontmatter(map[string]any{"tags": map[string]any{"a": 1}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "tags: must be a string or a list of strings", errs[0].Error())
+}
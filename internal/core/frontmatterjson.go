@@ -0,0 +1,281 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// splitJSONFrontmatter separates the JSON object text starts with from the
+// body. The object must end its line.
+func splitJSONFrontmatter(text string) (frontmatter, body string, ok bool) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	var object json.RawMessage
+	if err := dec.Decode(&object); err != nil {
+		return "", "", false
+	}
+
+	end := int(dec.InputOffset())
+	line, body, _ := strings.Cut(text[end:], "\n")
+	if strings.TrimSpace(line) != "" {
+		return "", "", false
+	}
+	return text[:end], body, true
+}
+
+// parseJSONFrontmatter parses a JSON object into a YAML mapping, keeping its
+// key order, and records the text of each member.
+func parseJSONFrontmatter(raw string) (*yaml.Node, *frontmatterLayout, error) {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+
+	fields, err := jsonNode(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fields.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("expected an object of fields")
+	}
+	return fields, jsonLayout(raw, fields), nil
+}
+
+// jsonLayout records how the members of a JSON object were written: the
+// head is the opening brace, each member's lead the space before it and the
+// tail the space before the closing brace.
+func jsonLayout(raw string, fields *yaml.Node) *frontmatterLayout {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+
+	layout := &frontmatterLayout{raw: raw}
+	end := int(dec.InputOffset())
+	layout.head = raw[:end]
+	for i := 0; dec.More(); i += 2 {
+		if _, err := dec.Token(); err != nil || i+1 >= len(fields.Content) {
+			return nil
+		}
+		start := end + strings.IndexByte(raw[end:], '"')
+		lead := raw[end:start]
+		if i > 0 {
+			lead = lead[strings.IndexByte(lead, ',')+1:]
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil
+		}
+		end = int(dec.InputOffset())
+		layout.add(fields.Content[i].Value, lead, raw[start:end], fields.Content[i+1])
+	}
+	layout.tail = raw[end:]
+	return layout
+}
+
+// jsonNode reads the next JSON value from dec as a YAML node.
+func jsonNode(dec *json.Decoder) (*yaml.Node, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := jsonNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		// The closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: t.String()}, nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// writeJSONFrontmatter writes a YAML mapping as an indented JSON object,
+// keeping its key order. With a layout, the members that haven't changed are
+// written as they were read. A changed member is written on one line when
+// it was on one line, and new members are placed like the last one.
+func writeJSONFrontmatter(fields *yaml.Node, layout *frontmatterLayout) (string, error) {
+	if layout == nil {
+		return writeIndentedJSON(fields, "")
+	}
+	if layout.unchanged(fields) {
+		return layout.raw + "\n", nil
+	}
+
+	newLead, tail := "\n  ", layout.tail
+	if len(layout.keys) > 0 {
+		newLead = layout.fields[layout.keys[len(layout.keys)-1]].lead
+	} else if strings.TrimSpace(tail) == "}" {
+		tail = "\n}"
+	}
+
+	var b strings.Builder
+	b.WriteString(layout.head)
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key, node := fields.Content[i], fields.Content[i+1]
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		read, ok := layout.field(key.Value)
+		if !ok {
+			read.lead = newLead
+		}
+		b.WriteString(read.lead)
+		if ok && sameYAMLNode(read.value, node) {
+			b.WriteString(read.text)
+			continue
+		}
+
+		name, _ := json.Marshal(key.Value)
+		b.Write(name)
+		b.WriteString(jsonKeySeparator(read.text))
+		var value string
+		var err error
+		if (ok && !strings.Contains(read.text, "\n")) || (!ok && !strings.Contains(read.lead, "\n")) {
+			value, err = writeInlineJSON(node)
+		} else {
+			value, err = writeIndentedJSON(node, read.lead[strings.LastIndexByte(read.lead, '\n')+1:])
+			value = strings.TrimSuffix(value, "\n")
+		}
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(tail)
+	b.WriteByte('\n')
+	return b.String(), nil
+}
+
+// jsonKeySeparator returns the text between the key and the value of member,
+// ": " when there's no member.
+func jsonKeySeparator(member string) string {
+	dec := json.NewDecoder(strings.NewReader(member))
+	if _, err := dec.Token(); err != nil {
+		return ": "
+	}
+	rest := member[dec.InputOffset():]
+	return rest[:len(rest)-len(strings.TrimLeft(rest, " \t\r\n:"))]
+}
+
+// writeInlineJSON writes node as JSON on one line, with a space after each
+// comma and colon.
+func writeInlineJSON(node *yaml.Node) (string, error) {
+	var compact bytes.Buffer
+	if err := writeJSONNode(&compact, node); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	inString, escaped := false, false
+	for _, c := range compact.Bytes() {
+		b.WriteByte(c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ',' || c == ':'):
+			b.WriteByte(' ')
+		}
+	}
+	return b.String(), nil
+}
+
+// writeIndentedJSON writes node as indented JSON, with prefix before every
+// line but the first.
+func writeIndentedJSON(node *yaml.Node, prefix string) (string, error) {
+	var compact bytes.Buffer
+	if err := writeJSONNode(&compact, node); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), prefix, "  "); err != nil {
+		return "", err
+	}
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+func writeJSONNode(b *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeJSONNode(b, node.Alias)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close := byte('['), byte(']')
+		step := 1
+		if node.Kind == yaml.MappingNode {
+			open, close = '{', '}'
+			step = 2
+		}
+
+		b.WriteByte(open)
+		for i := 0; i+step-1 < len(node.Content); i += step {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if step == 2 {
+				key, _ := json.Marshal(node.Content[i].Value)
+				b.Write(key)
+				b.WriteByte(':')
+			}
+			if err := writeJSONNode(b, node.Content[i+step-1]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(close)
+		return nil
+	}
+
+	// Numbers keep how they were written
+	if tag := node.ShortTag(); (tag == "!!int" || tag == "!!float") && json.Valid([]byte(node.Value)) {
+		b.WriteString(node.Value)
+		return nil
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
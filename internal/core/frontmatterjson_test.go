@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFrontmatter_JSON(t *testing.T) {
+	content := "{\n  \"title\": \"Hello\",\n  \"weight\": 1.50,\n  \"draft\": \"true\",\n  \"meta\": {\"z\": 1, \"a\": [true, null]}\n}\n\n# Hello\n"
+
+	f, body, err := ReadFrontmatter(content)
+	require.NoError(t, err)
+	assert.Equal(t, FrontmatterJSON, f.Format)
+	assert.Equal(t, "\n# Hello\n", body)
+	assert.Equal(t, []string{"title", "weight", "draft", "meta"}, f.Keys())
+
+	metadata, err := f.Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"title":  "Hello",
+		"weight": 1.5,
+		"draft":  "true",
+		"meta":   map[string]any{"z": 1, "a": []any{true, nil}},
+	}, metadata)
+
+	require.NoError(t, f.Set("status", "done"))
+	assert.True(t, f.Delete("draft"))
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"title\": \"Hello\",\n  \"weight\": 1.50,\n  \"meta\": {\"z\": 1, \"a\": [true, null]},\n  \"status\": \"done\"\n}\n\n# Hello\n", result)
+}
+
+func TestFrontmatter_JSONKeepsLayout(t *testing.T) {
+	content := "{\n  \"title\": \"Hi\",\n  \"tags\": [\"a\"],\n  \"status\":\"draft\"\n}\nbody\n"
+
+	f, body, err := ReadFrontmatter(content)
+	require.NoError(t, err)
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, content, result)
+
+	require.NoError(t, f.Set("status", "done"))
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"title\": \"Hi\",\n  \"tags\": [\"a\"],\n  \"status\":\"done\"\n}\nbody\n", result)
+
+	_, err = f.Append("tags", "b")
+	require.NoError(t, err)
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"title\": \"Hi\",\n  \"tags\": [\"a\", \"b\"],\n  \"status\":\"done\"\n}\nbody\n", result)
+
+	f, body, err = ReadFrontmatter("{\"title\": \"Hi\", \"draft\": true}\nbody\n")
+	require.NoError(t, err)
+	f.Delete("draft")
+	require.NoError(t, f.Set("tags", []string{"a"}))
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"title\": \"Hi\", \"tags\": [\"a\"]}\nbody\n", result)
+}
+
+func TestReadFrontmatter_JSONNotObject(t *testing.T) {
+	_, _, err := ReadFrontmatter("[1, 2]\nbody")
+	require.NoError(t, err, "a document starting with [ has no frontmatter")
+
+	f, body, err := ReadFrontmatter("{\"title\": \"Hello\"} trailing\nbody")
+	require.NoError(t, err)
+	assert.Empty(t, f.Keys())
+	assert.Equal(t, "{\"title\": \"Hello\"} trailing\nbody", body)
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// parseTOMLFrontmatter parses TOML frontmatter into a YAML mapping. Top-level
+// keys keep their order, the comment lines before them and the comment after
+// their value. Keys of tables are sorted. The layout records the lines of
+// each top-level key, with the tables kept together as the tail; it's nil
+// when a top-level key is dotted.
+func parseTOMLFrontmatter(raw string) (*yaml.Node, *frontmatterLayout, error) {
+	var values map[string]any
+	if err := toml.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, nil, err
+	}
+
+	fields := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	seen := make(map[string]bool)
+	var comments []string
+	inTable := false
+
+	layout := &frontmatterLayout{raw: raw}
+	end := 0
+	quoted, literal := false, false
+
+	parser := unstable.Parser{KeepComments: true}
+	parser.Reset([]byte(raw))
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		switch expr.Kind {
+		case unstable.Comment:
+			comments = append(comments, string(expr.Data))
+			continue
+		case unstable.Table, unstable.ArrayTable:
+			inTable = true
+		case unstable.KeyValue:
+			// Strings written again are quoted like the first one read
+			if value := expr.Value(); !quoted && value.Kind == unstable.String {
+				quoted = true
+				literal = raw[parser.Range(value.Data).Offset-1] == '\''
+			}
+			if inTable {
+				comments = nil
+				continue
+			}
+		}
+
+		keys := expr.Key()
+		keys.Next()
+		key := string(keys.Node().Data)
+		if keys.Next() && expr.Kind == unstable.KeyValue {
+			layout = nil
+		}
+		if seen[key] {
+			comments = nil
+			continue
+		}
+		seen[key] = true
+
+		var value yaml.Node
+		if err := value.Encode(fromTOMLValue(values[key])); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if comment := expr.Next(); expr.Kind == unstable.KeyValue && comment.Valid() && comment.Kind == unstable.Comment {
+			value.LineComment = string(comment.Data)
+		}
+		fields.Content = append(fields.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key, HeadComment: strings.Join(comments, "\n")},
+			&value)
+		comments = nil
+
+		if layout == nil {
+			continue
+		}
+		if expr.Kind != unstable.KeyValue {
+			layout.add(key, "", "", &value)
+			continue
+		}
+		// The key's lines, from the start of its first to the end of its last
+		start := strings.LastIndexByte(raw[:expr.Raw.Offset], '\n') + 1
+		if len(layout.keys) == 0 {
+			layout.head, end = raw[:start], start
+		}
+		lead := raw[end:start]
+		end = int(expr.Raw.Offset + expr.Raw.Length)
+		if next := strings.IndexByte(raw[end:], '\n'); next >= 0 {
+			end += next + 1
+		} else {
+			end = len(raw)
+		}
+		layout.add(key, lead, raw[start:end], &value)
+	}
+	if err := parser.Error(); err != nil {
+		return nil, nil, err
+	}
+
+	fields.FootComment = strings.Join(comments, "\n")
+	if layout != nil {
+		layout.tail = raw[end:]
+		layout.literal = literal
+	}
+	return fields, layout, nil
+}
+
+// fromTOMLValue turns TOML local dates and times into the values YAML
+// frontmatter has for them: local dates and date-times become UTC times and
+// local times strings.
+func fromTOMLValue(value any) any {
+	switch v := value.(type) {
+	case toml.LocalDate:
+		return v.AsTime(time.UTC)
+	case toml.LocalDateTime:
+		return v.AsTime(time.UTC)
+	case toml.LocalTime:
+		return v.String()
+	case map[string]any:
+		for key, item := range v {
+			v[key] = fromTOMLValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = fromTOMLValue(item)
+		}
+	}
+	return value
+}
+
+// toTOMLValue turns times at midnight UTC back into TOML local dates.
+func toTOMLValue(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		if v.Location() == time.UTC && v.Equal(v.Truncate(24*time.Hour)) {
+			return toml.LocalDate{Year: v.Year(), Month: int(v.Month()), Day: v.Day()}
+		}
+	case map[string]any:
+		for key, item := range v {
+			v[key] = toTOMLValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = toTOMLValue(item)
+		}
+	}
+	return value
+}
+
+// writeTOMLFrontmatter writes a YAML mapping as TOML: fields with plain
+// values first, in order, then tables and arrays of tables, in order. With
+// a layout, the fields that haven't changed are written as they were read,
+// and so are the tables when none of them changed.
+func writeTOMLFrontmatter(fields *yaml.Node, layout *frontmatterLayout) (string, error) {
+	if layout.unchanged(fields) {
+		return layout.raw, nil
+	}
+
+	var values, tables strings.Builder
+	var tableKeys []string
+	tablesKept := layout != nil
+	if layout != nil {
+		values.WriteString(layout.head)
+	}
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key, node := fields.Content[i], fields.Content[i+1]
+
+		read, wasRead := layout.field(key.Value)
+		if wasRead && read.text != "" && sameYAMLNode(read.value, node) {
+			values.WriteString(read.lead + read.text)
+			continue
+		}
+
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return "", err
+		}
+		if value == nil {
+			return "", fmt.Errorf("%s: TOML has no null value", key.Value)
+		}
+		data, err := toml.Marshal(map[string]any{key.Value: toTOMLValue(value)})
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key.Value, err)
+		}
+		text := strings.TrimLeft(string(data), "\n")
+		if layout == nil || !layout.literal {
+			if text, err = toTOMLBasicStrings(text); err != nil {
+				return "", fmt.Errorf("%s: %w", key.Value, err)
+			}
+		}
+
+		if isTOMLTable(value) {
+			tableKeys = append(tableKeys, key.Value)
+			tablesKept = tablesKept && wasRead && read.text == "" && sameYAMLNode(read.value, node)
+			if tables.Len() > 0 {
+				tables.WriteString("\n")
+			}
+			writeTOMLComment(&tables, key.HeadComment)
+			tables.WriteString(text)
+			continue
+		}
+
+		if node.LineComment != "" {
+			text = strings.TrimSuffix(text, "\n") + " " + node.LineComment + "\n"
+		}
+		if wasRead {
+			// The comments before the field are in its lead
+			values.WriteString(read.lead)
+		} else if layout == nil {
+			writeTOMLComment(&values, key.HeadComment)
+		}
+		values.WriteString(text)
+	}
+
+	if tablesKept && slices.Equal(tableKeys, layout.tables()) {
+		values.WriteString(layout.tail)
+		return values.String(), nil
+	}
+	if values.Len() > 0 && tables.Len() > 0 {
+		values.WriteString("\n")
+	}
+	values.WriteString(tables.String())
+	writeTOMLComment(&values, fields.FootComment)
+	return values.String(), nil
+}
+
+// toTOMLBasicStrings rewrites the literal strings in text, which go-toml
+// writes in single quotes, as basic strings in double quotes.
+func toTOMLBasicStrings(text string) (string, error) {
+	parser := unstable.Parser{}
+	parser.Reset([]byte(text))
+
+	var b strings.Builder
+	end := 0
+	var visit func(node *unstable.Node)
+	visit = func(node *unstable.Node) {
+		switch node.Kind {
+		case unstable.KeyValue:
+			visit(node.Value())
+		case unstable.Array, unstable.InlineTable:
+			children := node.Children()
+			for children.Next() {
+				visit(children.Node())
+			}
+		case unstable.String:
+			start := int(parser.Range(node.Data).Offset) - 1
+			if text[start] != '\'' {
+				return
+			}
+			b.WriteString(text[end:start])
+			b.WriteString(tomlBasicString(string(node.Data)))
+			end = start + len(node.Data) + 2
+		}
+	}
+
+	for parser.NextExpression() {
+		visit(parser.Expression())
+	}
+	if err := parser.Error(); err != nil {
+		return "", err
+	}
+	b.WriteString(text[end:])
+	return b.String(), nil
+}
+
+// tomlBasicString quotes s as a TOML basic string.
+func tomlBasicString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tables returns the keys of the TOML tables as read.
+func (l *frontmatterLayout) tables() []string {
+	var keys []string
+	for _, key := range l.keys {
+		if l.fields[key].text == "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// isTOMLTable reports whether value is written as a table or an array of
+// tables rather than after its key.
+func isTOMLTable(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return true
+	case []any:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]any); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func writeTOMLComment(b *strings.Builder, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		b.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFrontmatter_TOML(t *testing.T) {
+	content := "+++\n# shown in lists\ntitle = \"Hello\" # the title\ncreated = 2024-01-02\ntags = [\"a\", \"b\"]\n\n[meta]\nz = 1\na = \"x\"\n\n[[links]]\nurl = \"https://example.com\"\n+++\n# Hello\n"
+
+	f, body, err := ReadFrontmatter(content)
+	require.NoError(t, err)
+	assert.Equal(t, FrontmatterTOML, f.Format)
+	assert.Equal(t, "# Hello\n", body)
+	assert.Equal(t, []string{"title", "created", "tags", "meta", "links"}, f.Keys())
+
+	metadata, err := f.Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"title":   "Hello",
+		"created": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"tags":    []any{"a", "b"},
+		"meta":    map[string]any{"a": "x", "z": 1},
+		"links":   []any{map[string]any{"url": "https://example.com"}},
+	}, metadata)
+
+	require.NoError(t, f.Set("title", "Hello: again"))
+	require.NoError(t, f.Set("status", "done"))
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\n# shown in lists\ntitle = \"Hello: again\" # the title\ncreated = 2024-01-02\ntags = [\"a\", \"b\"]\nstatus = \"done\"\n\n[meta]\nz = 1\na = \"x\"\n\n[[links]]\nurl = \"https://example.com\"\n+++\n# Hello\n", result)
+
+	reread, _, err := ReadFrontmatter(result)
+	require.NoError(t, err)
+	rereadMetadata, err := reread.Map()
+	require.NoError(t, err)
+	metadata["title"] = "Hello: again"
+	metadata["status"] = "done"
+	assert.Equal(t, metadata, rereadMetadata)
+}
+
+func TestFrontmatter_TOMLKeepsLayout(t *testing.T) {
+	content := "+++\ntitle = \"Hi\"\ntags = [\"a\"]   # topics\nstatus = \"draft\"\n\n# the end\n+++\nbody\n"
+
+	f, body, err := ReadFrontmatter(content)
+	require.NoError(t, err)
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, content, result)
+
+	require.NoError(t, f.Set("status", "done"))
+	require.NoError(t, f.Set("owner", "ana"))
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hi\"\ntags = [\"a\"]   # topics\nstatus = \"done\"\nowner = \"ana\"\n\n# the end\n+++\nbody\n", result)
+}
+
+func TestFrontmatter_TOMLTablesKeepLayout(t *testing.T) {
+	content := "+++\ntitle = \"Hi\"\n\n[meta]\nz = 1\na = \"x\"\n+++\nbody\n"
+
+	f, body, err := ReadFrontmatter(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Set("title", "Hello"))
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hello\"\n\n[meta]\nz = 1\na = \"x\"\n+++\nbody\n", result, "unchanged tables are kept")
+
+	require.NoError(t, f.Set("meta", map[string]any{"a": "y"}))
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hello\"\n\n[meta]\na = \"y\"\n+++\nbody\n", result)
+
+	f, body, err = ReadFrontmatter("+++\nmeta.a = 1\ntitle = \"Hi\"\n+++\nbody\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Set("title", "Hello"))
+	result, err = f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hello\"\n\n[meta]\na = 1\n+++\nbody\n", result, "dotted keys are written as tables")
+}
+
+func TestReadFrontmatter_TOMLErrors(t *testing.T) {
+	_, _, err := ReadFrontmatter("+++\ntitle = \n+++\nbody")
+	assert.ErrorContains(t, err, "invalid frontmatter")
+
+	f, body, err := ReadFrontmatter("+++\ntitle = \"Hello\"\n+++\nbody")
+	require.NoError(t, err)
+	require.NoError(t, f.Set("reviewer", nil))
+	_, err = f.Render(body)
+	assert.ErrorContains(t, err, "TOML has no null value")
+}
+
+func TestFrontmatter_TOMLBasicStrings(t *testing.T) {
+	f, body, err := ReadFrontmatter("+++\ntitle = \"Hi\"\n+++\nbody\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Set("note", `say "hi" to C:\x`))
+	require.NoError(t, f.Set("meta", map[string]any{"tags": []any{"a b", "it's"}}))
+	result, err := f.Render(body)
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hi\"\nnote = \"say \\\"hi\\\" to C:\\\\x\"\n\n[meta]\ntags = [\"a b\", \"it's\"]\n+++\nbody\n", result)
+
+	reread, _, err := ReadFrontmatter(result)
+	require.NoError(t, err)
+	note, _, err := reread.Get("note")
+	require.NoError(t, err)
+	assert.Equal(t, `say "hi" to C:\x`, note)
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word in a piece of text along with its byte offsets.
+type Token struct {
+	// Word is the lowercased word as written
+	Word string
+	// Term is the stemmed form of Word
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits text into words and returns their stemmed terms.
+// Words are runs of letters and digits; everything else separates them.
+// Chinese, Japanese and Korean are written without spaces, so each of their
+// characters is a word of its own.
+func Tokenize(text string) []Token {
+	var tokens []Token
+
+	start := -1
+	for i, r := range text {
+		if isCJK(r) {
+			if start >= 0 {
+				tokens = append(tokens, newToken(text, start, i))
+				start = -1
+			}
+			tokens = append(tokens, newToken(text, i, i+utf8.RuneLen(r)))
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+
+	return tokens
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func newToken(text string, start, end int) Token {
+	word := strings.ToLower(text[start:end])
+	return Token{
+		Word:  word,
+		Term:  Stem(word),
+		Start: start,
+		End:   end,
+	}
+}
+
+// minPrefixLength is the shortest search word that also matches as a prefix.
+// Shorter words would match far too much to be useful.
+const minPrefixLength = 3
+
+// SearchTerm is a single word of a full-text query.
+type SearchTerm struct {
+	Word string
+	Stem string
+}
+
+// NewSearchTerm creates a search term from a lowercased word.
+func NewSearchTerm(word string) SearchTerm {
+	return SearchTerm{Word: word, Stem: Stem(word)}
+}
+
+// Matches reports whether token matches the term, either by stem
+// ("meetings" matches "meeting") or by prefix ("java" matches "javascript").
+func (t SearchTerm) Matches(token Token) bool {
+	if token.Term == t.Stem {
+		return true
+	}
+	return len(t.Word) >= minPrefixLength && strings.HasPrefix(token.Word, t.Word)
+}
+
+// searchTerms tokenizes text into search terms.
+func searchTerms(text string) []SearchTerm {
+	tokens := Tokenize(text)
+	terms := make([]SearchTerm, len(tokens))
+	for i, token := range tokens {
+		terms[i] = NewSearchTerm(token.Word)
+	}
+	return terms
+}
+
+// FullTextQuery is a parsed full-text search query.
+type FullTextQuery struct {
+	// Terms are words that may appear anywhere in a document
+	Terms []SearchTerm
+	// Phrases are runs of words that must appear consecutively
+	Phrases [][]SearchTerm
+}
+
+// ParseFullTextQuery parses a search string. Words in double quotes are
+// treated as a phrase; everything else is an individual term, except that a
+// run of CJK characters is a phrase of its characters.
+// An unterminated quote runs to the end of the query.
+func ParseFullTextQuery(query string) FullTextQuery {
+	var q FullTextQuery
+
+	for query != "" {
+		before, after, found := strings.Cut(query, `"`)
+		q.addTerms(before)
+		if !found {
+			break
+		}
+
+		phrase, rest, _ := strings.Cut(after, `"`)
+		q.addPhrase(searchTerms(phrase))
+		query = rest
+	}
+
+	return q
+}
+
+// addTerms adds the words of unquoted text, keeping runs of CJK characters
+// together as phrases.
+func (q *FullTextQuery) addTerms(text string) {
+	tokens := Tokenize(text)
+	for i := 0; i < len(tokens); {
+		end := i + 1
+		for end < len(tokens) && cjkToken(text, tokens[end-1]) && cjkToken(text, tokens[end]) &&
+			tokens[end].Start == tokens[end-1].End {
+			end++
+		}
+
+		terms := make([]SearchTerm, 0, end-i)
+		for _, token := range tokens[i:end] {
+			terms = append(terms, NewSearchTerm(token.Word))
+		}
+		q.addPhrase(terms)
+		i = end
+	}
+}
+
+// addPhrase adds terms as a phrase, or as a term when there's only one.
+func (q *FullTextQuery) addPhrase(terms []SearchTerm) {
+	switch len(terms) {
+	case 0:
+	case 1:
+		q.Terms = append(q.Terms, terms[0])
+	default:
+		q.Phrases = append(q.Phrases, terms)
+	}
+}
+
+// cjkToken reports whether token is a single CJK character of text.
+func cjkToken(text string, token Token) bool {
+	r, size := utf8.DecodeRuneInString(text[token.Start:])
+	return size == token.End-token.Start && isCJK(r)
+}
+
+// Empty returns true if the query has nothing to match.
+func (q FullTextQuery) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0
+}
+
+// AllTerms returns every distinct term in the query, including those inside phrases.
+func (q FullTextQuery) AllTerms() []SearchTerm {
+	seen := make(map[SearchTerm]bool)
+	var terms []SearchTerm
+
+	add := func(term SearchTerm) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, term := range q.Terms {
+		add(term)
+	}
+	for _, phrase := range q.Phrases {
+		for _, term := range phrase {
+			add(term)
+		}
+	}
+
+	return terms
+}
+
+// matchesAny reports whether token matches any of terms.
+func matchesAny(token Token, terms []SearchTerm) bool {
+	for _, term := range terms {
+		if term.Matches(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snippet returns a short excerpt of text around the first match of any of
+// terms, with matches wrapped in markdown bold. Returns "" when nothing matches.
+func Snippet(text string, terms []SearchTerm, maxLen int) string {
+	tokens := Tokenize(text)
+	first := -1
+	for i, token := range tokens {
+		if matchesAny(token, terms) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// Centre the window on the first match, keeping to word boundaries
+	start := tokens[first].Start - maxLen/3
+	if start <= 0 {
+		start = 0
+	} else {
+		for i := 0; i <= first; i++ {
+			if tokens[i].Start >= start {
+				start = tokens[i].Start
+				break
+			}
+		}
+	}
+	end := start + maxLen
+	if end > len(text) {
+		end = len(text)
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	// Adjacent matches, such as the characters of a CJK word, share one
+	// bold run
+	pos := start
+	bold := false
+	for _, token := range tokens {
+		if token.Start < start {
+			continue
+		}
+		if token.End > end {
+			end = token.Start
+			break
+		}
+		if !matchesAny(token, terms) {
+			continue
+		}
+		if !bold || token.Start != pos {
+			if bold {
+				b.WriteString("**")
+			}
+			b.WriteString(text[pos:token.Start])
+			b.WriteString("**")
+		}
+		b.WriteString(text[token.Start:token.End])
+		pos = token.End
+		bold = true
+	}
+	if bold {
+		b.WriteString("**")
+	}
+	b.WriteString(text[pos:end])
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("Planning: the Releases!")
+
+	assert.Equal(t, []Token{
+		{Word: "planning", Term: "plan", Start: 0, End: 8},
+		{Word: "the", Term: "the", Start: 10, End: 13},
+		{Word: "releases", Term: "releas", Start: 14, End: 22},
+	}, tokens)
+}
+
+func TestTokenize_Unicode(t *testing.T) {
+	var words []string
+	for _, token := range Tokenize("Naïve café, 日本語") {
+		words = append(words, token.Word)
+	}
+	assert.Equal(t, []string{"naïve", "café", "日", "本", "語"}, words)
+}
+
+func TestTokenize_CJK(t *testing.T) {
+	tokens := Tokenize("这是test文档")
+
+	assert.Equal(t, []Token{
+		{Word: "这", Term: "这", Start: 0, End: 3},
+		{Word: "是", Term: "是", Start: 3, End: 6},
+		{Word: "test", Term: "test", Start: 6, End: 10},
+		{Word: "文", Term: "文", Start: 10, End: 13},
+		{Word: "档", Term: "档", Start: 13, End: 16},
+	}, tokens)
+}
+
+func TestSearchTerm_Matches(t *testing.T) {
+	tokens := Tokenize("meetings javascript go")
+
+	assert.True(t, NewSearchTerm("meeting").Matches(tokens[0]), "stem match")
+	assert.True(t, NewSearchTerm("java").Matches(tokens[1]), "prefix match")
+	assert.False(t, NewSearchTerm("ja").Matches(tokens[1]), "short words don't prefix match")
+	assert.True(t, NewSearchTerm("go").Matches(tokens[2]), "short words still match exactly")
+	assert.False(t, NewSearchTerm("script").Matches(tokens[1]))
+}
+
+func terms(words ...string) []SearchTerm {
+	result := make([]SearchTerm, len(words))
+	for i, word := range words {
+		result[i] = NewSearchTerm(word)
+	}
+	return result
+}
+
+func TestParseFullTextQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected FullTextQuery
+	}{
+		{
+			name:     "terms",
+			query:    "meeting notes",
+			expected: FullTextQuery{Terms: terms("meeting", "notes")},
+		},
+		{
+			name:     "cjk run is a phrase",
+			query:    "测试 文 notes",
+			expected: FullTextQuery{Terms: terms("文", "notes"), Phrases: [][]SearchTerm{terms("测", "试")}},
+		},
+		{
+			name:     "phrase",
+			query:    `"release plan" draft`,
+			expected: FullTextQuery{Terms: terms("draft"), Phrases: [][]SearchTerm{terms("release", "plan")}},
+		},
+		{
+			name:     "single word phrase is a term",
+			query:    `"release"`,
+			expected: FullTextQuery{Terms: terms("release")},
+		},
+		{
+			name:     "unterminated phrase",
+			query:    `todo "next week`,
+			expected: FullTextQuery{Terms: terms("todo"), Phrases: [][]SearchTerm{terms("next", "week")}},
+		},
+		{
+			name:     "punctuation only",
+			query:    "?!",
+			expected: FullTextQuery{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseFullTextQuery(tt.query))
+		})
+	}
+}
+
+func TestFullTextQuery_AllTerms(t *testing.T) {
+	q := ParseFullTextQuery(`draft "release plan" draft`)
+	assert.Equal(t, terms("draft", "release", "plan"), q.AllTerms())
+	assert.False(t, q.Empty())
+	assert.True(t, ParseFullTextQuery("").Empty())
+}
+
+func TestSnippet(t *testing.T) {
+	t.Run("highlights matches", func(t *testing.T) {
+		snippet := Snippet("We are planning the release.\n\nMore text.", terms("release"), 100)
+		assert.Equal(t, "We are planning the **release**. More text.", snippet)
+	})
+
+	t.Run("windows long text", func(t *testing.T) {
+		text := "alpha beta gamma delta epsilon zeta eta theta iota kappa lambda mu target nu xi omicron pi rho sigma tau upsilon phi chi psi omega"
+		snippet := Snippet(text, terms("target"), 40)
+		assert.Contains(t, snippet, "**target**")
+		assert.True(t, len(snippet) < len(text))
+		assert.Equal(t, "…", snippet[:len("…")])
+	})
+
+	t.Run("joins adjacent matches", func(t *testing.T) {
+		assert.Equal(t, "这是**测试**文档, **测**", Snippet("这是测试文档, 测", terms("测", "试"), 100))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		assert.Equal(t, "", Snippet("nothing here", terms("missing"), 100))
+	})
+}
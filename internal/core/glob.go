@@ -0,0 +1,87 @@
+package core
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether name matches a slash-separated glob pattern.
+// In addition to the path.Match syntax, a "**" segment matches zero or
+// more directories, so "**/*.md" matches both "a.md" and "x/y/a.md".
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(splitGlob(pattern), splitGlob(name))
+}
+
+// GlobBase returns the leading portion of a glob pattern that contains no
+// wildcard characters. It is the directory a matcher needs to walk.
+func GlobBase(pattern string) string {
+	segments := strings.Split(pattern, "/")
+
+	var base []string
+	for _, seg := range segments[:len(segments)-1] {
+		if HasGlobMeta(seg) {
+			break
+		}
+		base = append(base, seg)
+	}
+
+	if len(base) == 0 {
+		if strings.HasPrefix(pattern, "/") {
+			return "/"
+		}
+		return "."
+	}
+
+	joined := strings.Join(base, "/")
+	if joined == "" {
+		return "/"
+	}
+	return joined
+}
+
+// HasGlobMeta reports whether s contains any glob wildcard characters.
+func HasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func splitGlob(s string) []string {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "/")
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive ** segments
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
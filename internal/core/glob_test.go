@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{name: "simple match", pattern: "*.md", path: "note.md", expected: true},
+		{name: "simple no match", pattern: "*.md", path: "note.txt", expected: false},
+		{name: "star does not cross directories", pattern: "*.md", path: "a/note.md", expected: false},
+		{name: "doublestar matches root", pattern: "**/*.md", path: "note.md", expected: true},
+		{name: "doublestar matches nested", pattern: "**/*.md", path: "a/b/c/note.md", expected: true},
+		{name: "doublestar in middle", pattern: "projects/**/todo.md", path: "projects/x/y/todo.md", expected: true},
+		{name: "doublestar in middle zero dirs", pattern: "projects/**/todo.md", path: "projects/todo.md", expected: true},
+		{name: "trailing doublestar", pattern: "projects/**", path: "projects/a/b.md", expected: true},
+		{name: "prefix mismatch", pattern: "projects/*.md", path: "archive/a.md", expected: false},
+		{name: "absolute paths", pattern: "/tmp/nb/**/*.md", path: "/tmp/nb/notes/a.md", expected: true},
+		{name: "question mark", pattern: "note?.md", path: "note1.md", expected: true},
+		{name: "character class", pattern: "note[0-9].md", path: "notea.md", expected: false},
+		{name: "invalid pattern", pattern: "note[.md", path: "note[.md", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchGlob(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "**/*.md", expected: "."},
+		{pattern: "*.md", expected: "."},
+		{pattern: "notes/*.md", expected: "notes"},
+		{pattern: "/tmp/nb/**/*.md", expected: "/tmp/nb"},
+		{pattern: "/tmp/nb/note.md", expected: "/tmp/nb"},
+		{pattern: "/*.md", expected: "/"},
+		{pattern: "a/b*/c/*.md", expected: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GlobBase(tt.pattern))
+		})
+	}
+}
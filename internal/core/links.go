@@ -0,0 +1,249 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LinkKind is the syntax of a link to another document.
+type LinkKind int
+
+const (
+	// LinkInline is a markdown link or image, e.g. [text](path.md).
+	LinkInline LinkKind = iota
+	// LinkReference is a reference definition, e.g. [id]: path.md.
+	LinkReference
+	// LinkWiki is a wikilink or embed, e.g. [[path|alias]].
+	LinkWiki
+)
+
+// LinkRef is a link found by ScanLinks.
+type LinkRef struct {
+	Kind LinkKind
+	// Target is the destination without any #fragment: the URL of markdown
+	// links and the page of wikilinks (without alias).
+	Target string
+	// Fragment is the part after # including the #, if any
+	Fragment string
+	// Line is the 1-based line of the link
+	Line int
+}
+
+var referenceDefinition = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:[ \t]*`)
+
+// ScanLinks returns the markdown links, reference definitions and wikilinks of
+// content in document order. Frontmatter, code blocks and code spans are
+// skipped.
+func ScanLinks(content string) []LinkRef {
+	var links []LinkRef
+	RewriteLinks(content, func(link LinkRef) (string, bool) {
+		links = append(links, link)
+		return "", false
+	})
+	return links
+}
+
+// RewriteLinks calls rewrite for every link ScanLinks would return, and
+// replaces the link's Target with the returned string when rewrite returns
+// true. Fragments, titles, aliases and all other text are kept as they are.
+func RewriteLinks(content string, rewrite func(LinkRef) (string, bool)) string {
+	return rewriteProse(content, func(text string, line int) string {
+		return rewriteLine(text, line, rewrite)
+	})
+}
+
+// rewriteProse passes every line of content outside frontmatter and fenced
+// code blocks through rewrite, with its 1-based line number.
+func rewriteProse(content string, rewrite func(text string, line int) string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+
+	body := content
+	line := 1
+	if _, rest, ok := SplitFrontmatter(content); ok {
+		frontmatter := content[:len(content)-len(rest)]
+		b.WriteString(frontmatter)
+		line += strings.Count(frontmatter, "\n")
+		body = rest
+	}
+
+	var fence string
+	for _, text := range strings.SplitAfter(body, "\n") {
+		if text == "" {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(text, " ")
+		indent := len(text) - len(trimmed)
+		if fence != "" {
+			closing := strings.TrimRight(trimmed, " \t\r\n")
+			if indent < 4 && strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+				fence = ""
+			}
+			b.WriteString(text)
+		} else if marker := fenceMarker(trimmed); indent < 4 && marker != "" {
+			fence = marker
+			b.WriteString(text)
+		} else {
+			b.WriteString(rewrite(text, line))
+		}
+		line++
+	}
+	return b.String()
+}
+
+// fenceMarker returns the opening fence of a fenced code block starting line.
+func fenceMarker(line string) string {
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			if c == '`' && strings.ContainsRune(line[n:], '`') {
+				return ""
+			}
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// rewriteLine rewrites the links on one line outside code blocks.
+func rewriteLine(text string, line int, rewrite func(LinkRef) (string, bool)) string {
+	var b strings.Builder
+	i := 0
+
+	if loc := referenceDefinition.FindStringIndex(text); loc != nil {
+		b.WriteString(text[:loc[1]])
+		i = loc[1]
+		start, end := i, i
+		if strings.HasPrefix(text[i:], "<") {
+			start = i + 1
+			end = start + strings.IndexAny(text[start:]+">", ">\n")
+		} else {
+			end = i + strings.IndexAny(text[i:]+" ", " \t\r\n")
+		}
+		i = writeTarget(&b, text, i, start, end, LinkReference, line, rewrite)
+	}
+
+	for i < len(text) {
+		switch {
+		case text[i] == '\\' && i+1 < len(text):
+			b.WriteString(text[i : i+2])
+			i += 2
+		case text[i] == '`':
+			end := codeSpanEnd(text, i)
+			b.WriteString(text[i:end])
+			i = end
+		case strings.HasPrefix(text[i:], "[["):
+			end := strings.Index(text[i+2:], "]]")
+			if end < 0 {
+				b.WriteString(text[i:])
+				i = len(text)
+				continue
+			}
+			start := i + 2
+			end += start
+			page := start + strings.IndexAny(text[start:end]+"|", "|")
+			b.WriteString("[[")
+			i = writeTarget(&b, text, start, start, page, LinkWiki, line, rewrite)
+		case strings.HasPrefix(text[i:], "]("):
+			b.WriteString("](")
+			start := i + 2
+			for start < len(text) && (text[start] == ' ' || text[start] == '\t') {
+				start++
+			}
+			b.WriteString(text[i+2 : start])
+			var end int
+			if strings.HasPrefix(text[start:], "<") {
+				b.WriteByte('<')
+				start++
+				end = start + strings.IndexAny(text[start:]+">", ">\n")
+			} else {
+				end = destinationEnd(text, start)
+			}
+			i = writeTarget(&b, text, start, start, end, LinkInline, line, rewrite)
+		default:
+			b.WriteByte(text[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// writeTarget writes text[from:end] to b, passing the target in
+// text[start:end] through rewrite, and returns end.
+func writeTarget(b *strings.Builder, text string, from, start, end int, kind LinkKind, line int, rewrite func(LinkRef) (string, bool)) int {
+	b.WriteString(text[from:start])
+
+	target, fragment := text[start:end], ""
+	if hash := strings.IndexByte(target, '#'); hash >= 0 {
+		target, fragment = target[:hash], target[hash:]
+	}
+	// Wikilinks may pad the page with spaces, e.g. [[ page | alias ]]
+	var lead, trail string
+	if kind == LinkWiki {
+		left := strings.TrimLeft(target, " ")
+		page := strings.TrimRight(left, " ")
+		lead, trail = target[:len(target)-len(left)], left[len(page):]
+		target = page
+	}
+
+	if replacement, ok := rewrite(LinkRef{Kind: kind, Target: target, Fragment: fragment, Line: line}); ok {
+		target = replacement
+	}
+	b.WriteString(lead)
+	b.WriteString(target)
+	b.WriteString(trail)
+	b.WriteString(fragment)
+	return end
+}
+
+// destinationEnd returns the end of an unbracketed link destination starting
+// at start: the first space or unbalanced closing parenthesis.
+func destinationEnd(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ' ', '\t', '\r', '\n':
+			return i
+		}
+	}
+	return len(text)
+}
+
+// codeSpanEnd returns the end of the code span opening at start, or the end
+// of the backtick run when the span isn't closed on this line.
+func codeSpanEnd(text string, start int) int {
+	n := start
+	for n < len(text) && text[n] == '`' {
+		n++
+	}
+	run := text[start:n]
+	for i := n; i < len(text); {
+		j := strings.Index(text[i:], run)
+		if j < 0 {
+			break
+		}
+		j += i
+		k := j + len(run)
+		if k == len(text) || text[k] != '`' {
+			return k
+		}
+		for k < len(text) && text[k] == '`' {
+			k++
+		}
+		i = k
+	}
+	return n
+}
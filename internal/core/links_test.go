@@ -0,0 +1,74 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanLinks(t *testing.T) {
+	content := strings.Join([]string{
+		"---",
+		"related: \"[[not-a-link]]\"",
+		"---",
+		"See [the plan](projects/plan.md#goals \"Plan\") and [[daily-log|the log]].",
+		"![diagram](<images/my diagram.png>) and ![[embedded#Intro]]",
+		"`[code](skipped.md)` and ``[[also skipped]]``",
+		"```md",
+		"[fenced](skipped.md)",
+		"```",
+		"[ref]: refs/target.md 'Title'",
+		"[anchor](#local) [web](https://example.com/a_(b))",
+		"",
+	}, "\n")
+
+	links := ScanLinks(content)
+	assert.Equal(t, []LinkRef{
+		{Kind: LinkInline, Target: "projects/plan.md", Fragment: "#goals", Line: 4},
+		{Kind: LinkWiki, Target: "daily-log", Line: 4},
+		{Kind: LinkInline, Target: "images/my diagram.png", Line: 5},
+		{Kind: LinkWiki, Target: "embedded", Fragment: "#Intro", Line: 5},
+		{Kind: LinkReference, Target: "refs/target.md", Line: 10},
+		{Kind: LinkInline, Target: "", Fragment: "#local", Line: 11},
+		{Kind: LinkInline, Target: "https://example.com/a_(b)", Line: 11},
+	}, links)
+}
+
+func TestRewriteLinks(t *testing.T) {
+	content := strings.Join([]string{
+		"See [plan](plan.md#goals) and [[ plan | the plan ]].",
+		"[again]( plan.md \"title\") and `[plan](plan.md)`",
+		"~~~",
+		"[[plan]]",
+		"~~~",
+		"[p]: <plan.md>",
+		"[other](other.md) [[plan#Heading]]",
+	}, "\n")
+
+	result := RewriteLinks(content, func(link LinkRef) (string, bool) {
+		switch link.Target {
+		case "plan.md":
+			return "archive/plan.md", true
+		case "plan":
+			return "archive/plan", true
+		}
+		return "", false
+	})
+
+	assert.Equal(t, strings.Join([]string{
+		"See [plan](archive/plan.md#goals) and [[ archive/plan | the plan ]].",
+		"[again]( archive/plan.md \"title\") and `[plan](plan.md)`",
+		"~~~",
+		"[[plan]]",
+		"~~~",
+		"[p]: <archive/plan.md>",
+		"[other](other.md) [[archive/plan#Heading]]",
+	}, "\n"), result)
+}
+
+func TestRewriteLinks_Unchanged(t *testing.T) {
+	content := "---\ntitle: x\n---\n\n# Title\n\nNo [links](a.md) changed, [[b]] either.\n[unclosed](c.md\n[[unclosed\n"
+	result := RewriteLinks(content, func(LinkRef) (string, bool) { return "", false })
+	assert.Equal(t, content, result)
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// MarkdownLink is a link found in a markdown document.
+type MarkdownLink struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+	// Line is the 1-based line the link's block starts on
+	Line int `json:"line"`
+}
+
+// MarkdownHeading is an ATX or setext heading.
+type MarkdownHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"`
+}
+
+// MarkdownCodeBlock is a fenced or indented code block.
+type MarkdownCodeBlock struct {
+	// Language is the first word of a fence's info string, if any
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	Line     int    `json:"line"`
+}
+
+// MarkdownTask is a GitHub-style task list item, e.g. "- [x] Ship it".
+type MarkdownTask struct {
+	Done bool   `json:"done"`
+	Text string `json:"text"`
+	Line int    `json:"line"`
+}
+
+// MarkdownElements holds the structural elements of a markdown document.
+type MarkdownElements struct {
+	Links      []MarkdownLink
+	Headings   []MarkdownHeading
+	CodeBlocks []MarkdownCodeBlock
+	Tasks      []MarkdownTask
+}
+
+var markdownParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// ExtractMarkdown parses content as GitHub-flavoured markdown and returns its
+// links, headings, code blocks and tasks in document order. Content should not
+// include frontmatter.
+func ExtractMarkdown(content string) MarkdownElements {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var elements MarkdownElements
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Link:
+			elements.Links = append(elements.Links, MarkdownLink{
+				Text: nodeText(n, source),
+				URL:  string(n.Destination),
+				Line: lineOf(n, source),
+			})
+		case *ast.AutoLink:
+			elements.Links = append(elements.Links, MarkdownLink{
+				Text: string(n.Label(source)),
+				URL:  string(n.URL(source)),
+				Line: lineOf(n, source),
+			})
+		case *ast.Heading:
+			elements.Headings = append(elements.Headings, MarkdownHeading{
+				Level: n.Level,
+				Text:  nodeText(n, source),
+				Line:  lineOf(n, source),
+			})
+		case *ast.FencedCodeBlock:
+			language := ""
+			// The block's lines start after the opening fence
+			line := lineOf(n, source) - 1
+			if n.Info != nil {
+				language, _, _ = strings.Cut(string(n.Info.Segment.Value(source)), " ")
+				line = bytes.Count(source[:n.Info.Segment.Start], []byte("\n")) + 1
+			}
+			elements.CodeBlocks = append(elements.CodeBlocks, MarkdownCodeBlock{
+				Language: language,
+				Code:     blockLines(n, source),
+				Line:     line,
+			})
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock:
+			elements.CodeBlocks = append(elements.CodeBlocks, MarkdownCodeBlock{
+				Code: blockLines(n, source),
+				Line: lineOf(n, source),
+			})
+			return ast.WalkSkipChildren, nil
+		case *extast.TaskCheckBox:
+			elements.Tasks = append(elements.Tasks, MarkdownTask{
+				Done: n.IsChecked,
+				Text: strings.TrimSpace(nodeText(n.Parent(), source)),
+				Line: lineOf(n, source),
+			})
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return elements
+}
+
+// nodeText concatenates the text inside node, without markup.
+func nodeText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		case *ast.AutoLink:
+			b.Write(t.Label(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// blockLines returns the raw lines of a code block.
+func blockLines(node ast.Node, source []byte) string {
+	var b bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		b.Write(segment.Value(source))
+	}
+	return b.String()
+}
+
+// lineOf returns the 1-based line of the block containing node.
+func lineOf(node ast.Node, source []byte) int {
+	for n := node; n != nil; n = n.Parent() {
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			return bytes.Count(source[:n.Lines().At(0).Start], []byte("\n")) + 1
+		}
+	}
+	return 0
+}
+
+// ExtractSection returns the part of content under the first heading whose
+// text is heading, ignoring case and any leading #s, up to the next heading of
+// the same or a higher level. The heading itself is included.
+func ExtractSection(content, heading string) (string, bool) {
+	heading = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(heading), "#"))
+	headings := ExtractMarkdown(content).Headings
+	lines := strings.SplitAfter(content, "\n")
+
+	for i, h := range headings {
+		if !strings.EqualFold(strings.TrimSpace(h.Text), heading) {
+			continue
+		}
+
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.Level <= h.Level {
+				end = next.Line - 1
+				break
+			}
+		}
+
+		section := strings.Join(lines[h.Line-1:end], "")
+		return strings.TrimRight(section, "\n") + "\n", true
+	}
+
+	return "", false
+}
+
+// HeadingAnchor returns the GitHub-style anchor of a heading: lowercase, with
+// spaces turned into hyphens and punctuation other than - and _ removed.
+func HeadingAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// HeadingAnchors returns the anchors of the headings in content. Repeated
+// anchors get -1, -2, ... suffixes, as on GitHub.
+func HeadingAnchors(content string) []string {
+	headings := ExtractMarkdown(content).Headings
+	anchors := make([]string, 0, len(headings))
+	seen := make(map[string]int, len(headings))
+	for _, h := range headings {
+		anchor := HeadingAnchor(h.Text)
+		if n := seen[anchor]; n > 0 {
+			seen[anchor]++
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			seen[anchor] = 1
+		}
+		anchors = append(anchors, anchor)
+	}
+	return anchors
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const extractSample = `# Release *plan*
+
+See [the spec](specs/release.md) and <https://example.com>.
+
+## Tasks
+
+- [x] Write notes
+- [ ] Ship **it**
+- plain item
+
+` + "```go\nfmt.Println(\"hi\")\n```" + `
+
+    indented code
+
+Setext heading
+--------------
+`
+
+func TestExtractMarkdown_Links(t *testing.T) {
+	elements := ExtractMarkdown(extractSample)
+
+	assert.Equal(t, []MarkdownLink{
+		{Text: "the spec", URL: "specs/release.md", Line: 3},
+		{Text: "https://example.com", URL: "https://example.com", Line: 3},
+	}, elements.Links)
+}
+
+func TestExtractMarkdown_Headings(t *testing.T) {
+	elements := ExtractMarkdown(extractSample)
+
+	assert.Equal(t, []MarkdownHeading{
+		{Level: 1, Text: "Release plan", Line: 1},
+		{Level: 2, Text: "Tasks", Line: 5},
+		{Level: 2, Text: "Setext heading", Line: 17},
+	}, elements.Headings)
+}
+
+func TestExtractMarkdown_CodeBlocks(t *testing.T) {
+	elements := ExtractMarkdown(extractSample)
+
+	assert.Equal(t, []MarkdownCodeBlock{
+		{Language: "go", Code: "fmt.Println(\"hi\")\n", Line: 11},
+		{Language: "", Code: "indented code\n", Line: 15},
+	}, elements.CodeBlocks)
+}
+
+func TestExtractMarkdown_Tasks(t *testing.T) {
+	elements := ExtractMarkdown(extractSample)
+
+	assert.Equal(t, []MarkdownTask{
+		{Done: true, Text: "Write notes", Line: 7},
+		{Done: false, Text: "Ship it", Line: 8},
+	}, elements.Tasks)
+}
+
+func TestExtractMarkdown_Empty(t *testing.T) {
+	assert.Equal(t, MarkdownElements{}, ExtractMarkdown(""))
+}
+
+func TestExtractSection(t *testing.T) {
+	content := "# Plan\n\nIntro\n\n## Tasks\n\n- one\n\n### Details\n\nMore\n\n## Notes\n\nLast\n"
+
+	tests := []struct {
+		heading  string
+		expected string
+		found    bool
+	}{
+		{"Tasks", "## Tasks\n\n- one\n\n### Details\n\nMore\n", true},
+		{"## tasks", "## Tasks\n\n- one\n\n### Details\n\nMore\n", true},
+		{"Details", "### Details\n\nMore\n", true},
+		{"Notes", "## Notes\n\nLast\n", true},
+		{"Plan", content, true},
+		{"Missing", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			section, found := ExtractSection(content, tt.heading)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, section)
+		})
+	}
+}
+
+func TestExtractSection_IgnoresHeadingsInCode(t *testing.T) {
+	content := "## Setup\n\n```sh\n# Install\nmake\n```\n\n## Usage\n"
+
+	section, found := ExtractSection(content, "Setup")
+	assert.True(t, found)
+	assert.Equal(t, "## Setup\n\n```sh\n# Install\nmake\n```\n", section)
+
+	_, found = ExtractSection(content, "Install")
+	assert.False(t, found)
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := map[string]string{
+		"Open Questions":        "open-questions",
+		"  What's next?  ":      "whats-next",
+		"API v2.0 (draft)":      "api-v20-draft",
+		"snake_case & kebab-it": "snake_case--kebab-it",
+		"Café Notes":            "café-notes",
+		"open-questions":        "open-questions",
+	}
+
+	for text, expected := range tests {
+		t.Run(text, func(t *testing.T) {
+			assert.Equal(t, expected, HeadingAnchor(text))
+		})
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	content := "# Plan\n\n## Notes\n\n## Notes\n\n```\n# Not a heading\n```\n\n## Notes\n"
+	assert.Equal(t, []string{"plan", "notes", "notes-1", "notes-2"}, HeadingAnchors(content))
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -37,20 +38,26 @@ func (e ValidationErrors) Error() string {
 	return strings.Join(lines, "\n")
 }
 
-// PrettyPrint formats validation errors in a hierarchical format grouped by path.
+// PrettyPrint formats validation errors in a hierarchical format grouped by
+// path, in the order the paths first appear.
 func (e ValidationErrors) PrettyPrint() string {
 	grouped := make(map[string][]string)
+	var paths []string
 
 	for _, err := range e {
 		path := err.Path
 		if path == "" {
 			path = "(root)"
 		}
+		if _, ok := grouped[path]; !ok {
+			paths = append(paths, path)
+		}
 		grouped[path] = append(grouped[path], err.Message)
 	}
 
 	var lines []string
-	for path, messages := range grouped {
+	for _, path := range paths {
+		messages := grouped[path]
 		lines = append(lines, fmt.Sprintf("- %s", path))
 		for _, msg := range messages {
 			lines = append(lines, fmt.Sprintf("  - %s", msg))
@@ -159,3 +166,165 @@ func ValidateNoteName(name string) error {
 
 	return nil
 }
+
+// Frontmatter schemas
+
+// FieldType is the type a frontmatter field must have.
+type FieldType string
+
+// Field types. A field without a type may hold any value.
+const (
+	FieldString  FieldType = "string"
+	FieldNumber  FieldType = "number"
+	FieldBoolean FieldType = "boolean"
+	FieldDate    FieldType = "date"
+	FieldList    FieldType = "list"
+	FieldEnum    FieldType = "enum"
+)
+
+// FieldTypes lists every field type.
+var FieldTypes = []FieldType{FieldString, FieldNumber, FieldBoolean, FieldDate, FieldList, FieldEnum}
+
+// FieldSchema describes a frontmatter field.
+type FieldSchema struct {
+	Type     FieldType `json:"type,omitempty"`
+	Required bool      `json:"required,omitempty"`
+	// Default is the value new notes get when they don't set the field
+	Default any `json:"default,omitempty"`
+	// Pattern is a regular expression string values, and the strings in
+	// lists, must match
+	Pattern string `json:"pattern,omitempty"`
+	// Values are the allowed values of an enum, or of the items of a list
+	Values []string `json:"values,omitempty"`
+}
+
+// FrontmatterSchema describes the frontmatter fields of notes, by key.
+// Fields not in the schema are allowed.
+type FrontmatterSchema map[string]FieldSchema
+
+// Merge returns the schema with the fields of other added, replacing fields
+// with the same key.
+func (s FrontmatterSchema) Merge(other FrontmatterSchema) FrontmatterSchema {
+	if len(other) == 0 {
+		return s
+	}
+	merged := make(FrontmatterSchema, len(s)+len(other))
+	for key, field := range s {
+		merged[key] = field
+	}
+	for key, field := range other {
+		merged[key] = field
+	}
+	return merged
+}
+
+// Defaults returns the default value of every field that has one.
+func (s FrontmatterSchema) Defaults() map[string]any {
+	defaults := make(map[string]any)
+	for key, field := range s {
+		if field.Default != nil {
+			defaults[key] = field.Default
+		}
+	}
+	return defaults
+}
+
+// Validate checks metadata against the schema. Errors are ordered by key.
+func (s FrontmatterSchema) Validate(metadata map[string]any) ValidationErrors {
+	v := NewValidator()
+
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s[key].validate(v.WithPath(key), metadata[key])
+	}
+	return v.Errors()
+}
+
+// validate checks a field's value, nil when the field isn't set.
+func (f FieldSchema) validate(v *Validator, value any) {
+	if value == nil {
+		if f.Required {
+			v.AddError("is required")
+		}
+		return
+	}
+
+	var pattern *regexp.Regexp
+	if f.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(f.Pattern); err != nil {
+			v.AddError(fmt.Sprintf("schema has an invalid pattern %q: %v", f.Pattern, err))
+			return
+		}
+	}
+
+	switch f.Type {
+	case "":
+	case FieldString:
+		if _, ok := value.(string); !ok {
+			v.AddError("must be a string")
+			return
+		}
+	case FieldNumber:
+		switch value.(type) {
+		case int, int64, uint64, float64:
+		default:
+			v.AddError("must be a number")
+			return
+		}
+	case FieldBoolean:
+		if _, ok := value.(bool); !ok {
+			v.AddError("must be true or false")
+			return
+		}
+	case FieldDate:
+		if !isFrontmatterDate(value) {
+			v.AddError("must be a date, e.g. 2006-01-02 or 2006-01-02T15:04:05Z")
+			return
+		}
+	case FieldEnum:
+		if !f.allows(value) {
+			v.AddError(fmt.Sprintf("must be one of: %s", strings.Join(f.Values, ", ")))
+			return
+		}
+	case FieldList:
+		items, ok := value.([]any)
+		if !ok {
+			v.AddError("must be a list")
+			return
+		}
+		for i, item := range items {
+			// Items are addressed as tags[1], like ValidateFrontmatter does
+			itemValidator := &Validator{errors: v.errors, path: fmt.Sprintf("%s[%d]", v.path, i)}
+			if len(f.Values) > 0 && !f.allows(item) {
+				itemValidator.AddError(fmt.Sprintf("must be one of: %s", strings.Join(f.Values, ", ")))
+			} else if s, isString := item.(string); pattern != nil && isString && !pattern.MatchString(s) {
+				itemValidator.AddError(fmt.Sprintf("must match %s", f.Pattern))
+			}
+		}
+		return
+	default:
+		v.AddError(fmt.Sprintf("schema has an unknown type %q", f.Type))
+		return
+	}
+
+	if s, isString := value.(string); pattern != nil && isString && !pattern.MatchString(s) {
+		v.AddError(fmt.Sprintf("must match %s", f.Pattern))
+	}
+}
+
+// allows reports whether value is one of the field's Values.
+func (f FieldSchema) allows(value any) bool {
+	s := fmt.Sprint(value)
+	for _, allowed := range f.Values {
+		if s == allowed {
+			return true
+		}
+	}
+	return false
+}
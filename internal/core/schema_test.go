@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateNotebookName(t *testing.T) {
@@ -177,3 +178,85 @@ func TestValidatePath(t *testing.T) {
 		})
 	}
 }
+
+func TestFrontmatterSchema_Validate(t *testing.T) {
+	schema := FrontmatterSchema{
+		"title":    {Type: FieldString, Required: true},
+		"status":   {Type: FieldEnum, Values: []string{"draft", "accepted", "superseded"}, Default: "draft"},
+		"date":     {Type: FieldDate, Required: true},
+		"tags":     {Type: FieldList, Pattern: `^[a-z-]+$`},
+		"owners":   {Type: FieldList, Values: []string{"ana", "bo"}},
+		"priority": {Type: FieldNumber},
+		"public":   {Type: FieldBoolean},
+		"ticket":   {Type: FieldString, Pattern: `^[A-Z]+-\d+$`},
+		"anything": {},
+	}
+
+	valid := map[string]any{
+		"title":    "Use Go",
+		"status":   "accepted",
+		"date":     "2025-01-02",
+		"tags":     []any{"adr", "lang-choice"},
+		"owners":   []any{"bo"},
+		"priority": 2,
+		"public":   false,
+		"ticket":   "OPS-12",
+		"anything": []any{1, "two"},
+	}
+	assert.Empty(t, schema.Validate(valid))
+
+	invalid := map[string]any{
+		"status":   "rejected",
+		"date":     "soon",
+		"tags":     []any{"ADR", "ok"},
+		"owners":   "ana",
+		"priority": "high",
+		"public":   "yes",
+		"ticket":   "ops 12",
+	}
+	assert.Equal(t, ValidationErrors{
+		{Path: "date", Message: "must be a date, e.g. 2006-01-02 or 2006-01-02T15:04:05Z"},
+		{Path: "owners", Message: "must be a list"},
+		{Path: "priority", Message: "must be a number"},
+		{Path: "public", Message: "must be true or false"},
+		{Path: "status", Message: "must be one of: draft, accepted, superseded"},
+		{Path: "tags[0]", Message: "must match ^[a-z-]+$"},
+		{Path: "ticket", Message: "must match ^[A-Z]+-\\d+$"},
+		{Path: "title", Message: "is required"},
+	}, schema.Validate(invalid))
+}
+
+func TestFrontmatterSchema_ValidateBadSchema(t *testing.T) {
+	schema := FrontmatterSchema{
+		"a": {Type: "colour"},
+		"b": {Type: FieldString, Pattern: "("},
+	}
+
+	errs := schema.Validate(map[string]any{"a": "red", "b": "x"})
+	require.Len(t, errs, 2)
+	assert.Equal(t, `schema has an unknown type "colour"`, errs[0].Message)
+	assert.Contains(t, errs[1].Message, `schema has an invalid pattern "("`)
+}
+
+func TestFrontmatterSchema_MergeAndDefaults(t *testing.T) {
+	base := FrontmatterSchema{
+		"title":  {Type: FieldString, Required: true},
+		"status": {Type: FieldEnum, Values: []string{"open"}, Default: "open"},
+	}
+	merged := base.Merge(FrontmatterSchema{"status": {Type: FieldString, Default: "new"}, "due": {Type: FieldDate}})
+
+	assert.Len(t, merged, 3)
+	assert.Equal(t, FieldString, merged["status"].Type)
+	assert.Equal(t, FieldEnum, base["status"].Type)
+	assert.Equal(t, map[string]any{"status": "new"}, merged.Defaults())
+	assert.Equal(t, base, base.Merge(nil))
+}
+
+func TestValidationErrors_PrettyPrintOrder(t *testing.T) {
+	errs := ValidationErrors{
+		{Path: "title", Message: "is required"},
+		{Path: "date", Message: "must be a date"},
+		{Path: "title", Message: "must be a string"},
+	}
+	assert.Equal(t, "- title\n  - is required\n  - must be a string\n- date\n  - must be a date", errs.PrettyPrint())
+}
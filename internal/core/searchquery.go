@@ -0,0 +1,164 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FieldOp is the comparison a field filter applies.
+type FieldOp string
+
+// Field filter comparisons.
+const (
+	FieldOpMatch        FieldOp = ":"
+	FieldOpGreater      FieldOp = ">"
+	FieldOpGreaterEqual FieldOp = ">="
+	FieldOpLess         FieldOp = "<"
+	FieldOpLessEqual    FieldOp = "<="
+)
+
+// FieldFilter restricts search results by a note field, e.g. "tag:work"
+// or "-status:done".
+type FieldFilter struct {
+	// Field is the lowercased field name
+	Field  string
+	Op     FieldOp
+	Value  string
+	Negate bool
+}
+
+// SearchQuery is a parsed search string: field filters plus free text.
+type SearchQuery struct {
+	// Filters must all hold for a note to match
+	Filters []FieldFilter
+	// Exclude lists words and phrases ("-draft", -"on hold") a note must not contain
+	Exclude [][]SearchTerm
+	// Text is the free text of the query with filters and exclusions removed
+	Text string
+	// FullText is Text parsed for ranking
+	FullText FullTextQuery
+}
+
+// ParseSearchQuery parses a search string such as
+//
+//	tag:work title:"release plan" -status:done created:>2025-01-01 path:projects/* deploy
+//
+// Words of the form field:value become filters, optionally negated with a
+// leading "-" and using >, >=, < or <= after the colon to compare. Other
+// words prefixed with "-" are excluded, and everything else is free text.
+func ParseSearchQuery(query string) (SearchQuery, error) {
+	var q SearchQuery
+	var text []string
+
+	for _, word := range splitQuery(query) {
+		negate := len(word) > 1 && word[0] == '-'
+		body := word
+		if negate {
+			body = word[1:]
+		}
+
+		if filter, ok, err := parseFieldFilter(body); err != nil {
+			return SearchQuery{}, err
+		} else if ok {
+			filter.Negate = negate
+			q.Filters = append(q.Filters, filter)
+			continue
+		}
+
+		if negate {
+			if terms := searchTerms(unquote(body)); len(terms) > 0 {
+				q.Exclude = append(q.Exclude, terms)
+				continue
+			}
+		}
+
+		text = append(text, word)
+	}
+
+	q.Text = strings.Join(text, " ")
+	q.FullText = ParseFullTextQuery(q.Text)
+
+	return q, nil
+}
+
+// splitQuery splits a query on whitespace, keeping double-quoted sections
+// together. An unterminated quote runs to the end of the query.
+func splitQuery(query string) []string {
+	var words []string
+	var b strings.Builder
+	quoted := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if b.Len() > 0 {
+				words = append(words, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		words = append(words, b.String())
+	}
+
+	return words
+}
+
+// parseFieldFilter parses "field:value". Returns false if word isn't a filter.
+func parseFieldFilter(word string) (FieldFilter, bool, error) {
+	field, rest, found := strings.Cut(word, ":")
+	if !found || !isFieldName(field) {
+		return FieldFilter{}, false, nil
+	}
+	// A trailing colon ("Note:") or a URL ("http://...") is just text
+	if rest == "" || strings.HasPrefix(rest, "//") {
+		return FieldFilter{}, false, nil
+	}
+
+	op := FieldOpMatch
+	for _, candidate := range []FieldOp{FieldOpGreaterEqual, FieldOpLessEqual, FieldOpGreater, FieldOpLess} {
+		if strings.HasPrefix(rest, string(candidate)) {
+			op = candidate
+			rest = rest[len(candidate):]
+			break
+		}
+	}
+
+	value := unquote(rest)
+	if value == "" {
+		return FieldFilter{}, false, fmt.Errorf("missing value for field %q", field)
+	}
+
+	return FieldFilter{
+		Field: strings.ToLower(field),
+		Op:    op,
+		Value: value,
+	}, true, nil
+}
+
+// isFieldName reports whether s looks like a frontmatter key.
+func isFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unquote removes the double quotes grouping a value.
+func unquote(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, `"`, ""))
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		filters []FieldFilter
+		exclude [][]SearchTerm
+		text    string
+	}{
+		{
+			name:  "plain text",
+			query: "release plan",
+			text:  "release plan",
+		},
+		{
+			name:    "field filter",
+			query:   "tag:work",
+			filters: []FieldFilter{{Field: "tag", Op: FieldOpMatch, Value: "work"}},
+		},
+		{
+			name:    "quoted value",
+			query:   `title:"release plan"`,
+			filters: []FieldFilter{{Field: "title", Op: FieldOpMatch, Value: "release plan"}},
+		},
+		{
+			name:    "negated filter",
+			query:   "-status:done",
+			filters: []FieldFilter{{Field: "status", Op: FieldOpMatch, Value: "done", Negate: true}},
+		},
+		{
+			name:  "comparisons",
+			query: "created:>2025-01-01 created:<=2025-06-30 priority:>=2 size:<10",
+			filters: []FieldFilter{
+				{Field: "created", Op: FieldOpGreater, Value: "2025-01-01"},
+				{Field: "created", Op: FieldOpLessEqual, Value: "2025-06-30"},
+				{Field: "priority", Op: FieldOpGreaterEqual, Value: "2"},
+				{Field: "size", Op: FieldOpLess, Value: "10"},
+			},
+		},
+		{
+			name:    "field names are case-insensitive",
+			query:   "Status:Open",
+			filters: []FieldFilter{{Field: "status", Op: FieldOpMatch, Value: "Open"}},
+		},
+		{
+			name:  "filters mixed with text",
+			query: `tag:work title:"release plan" -status:done created:>2025-01-01 path:projects/* deploy "go live"`,
+			filters: []FieldFilter{
+				{Field: "tag", Op: FieldOpMatch, Value: "work"},
+				{Field: "title", Op: FieldOpMatch, Value: "release plan"},
+				{Field: "status", Op: FieldOpMatch, Value: "done", Negate: true},
+				{Field: "created", Op: FieldOpGreater, Value: "2025-01-01"},
+				{Field: "path", Op: FieldOpMatch, Value: "projects/*"},
+			},
+			text: `deploy "go live"`,
+		},
+		{
+			name:    "excluded words and phrases",
+			query:   `meeting -draft -"on hold"`,
+			exclude: [][]SearchTerm{terms("draft"), terms("on", "hold")},
+			text:    "meeting",
+		},
+		{
+			name:  "lone dash is text",
+			query: "2 - 1",
+			text:  "2 - 1",
+		},
+		{
+			name:  "trailing colon is text",
+			query: "Note: buy milk",
+			text:  "Note: buy milk",
+		},
+		{
+			name:  "urls are text",
+			query: "https://example.com",
+			text:  "https://example.com",
+		},
+		{
+			name:  "non field prefix is text",
+			query: "C++:thing",
+			text:  "C++:thing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseSearchQuery(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.filters, q.Filters)
+			assert.Equal(t, tt.exclude, q.Exclude)
+			assert.Equal(t, tt.text, q.Text)
+			assert.Equal(t, ParseFullTextQuery(tt.text), q.FullText)
+		})
+	}
+}
+
+func TestParseSearchQuery_MissingValue(t *testing.T) {
+	for _, query := range []string{"created:>", "status:<=", `title:""`} {
+		t.Run(query, func(t *testing.T) {
+			_, err := ParseSearchQuery(query)
+			assert.ErrorContains(t, err, "missing value")
+		})
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	assert.Equal(t, []string{"a", `title:"x y"`, `"b c"`}, splitQuery(` a  title:"x y"	"b c" `))
+	assert.Equal(t, []string{`"unterminated quote`}, splitQuery(`"unterminated quote`))
+	assert.Empty(t, splitQuery("   "))
+}
@@ -0,0 +1,227 @@
+package core
+
+import "strings"
+
+// Stem reduces an English word to its stem using the Porter algorithm,
+// so "meetings", "meeting" and "meet" all map to "meet".
+// The word must be lowercase; words containing non a-z letters are returned as-is.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+// consonant reports whether b[i] is a consonant. Y is a consonant at the
+// start of a word or after a vowel.
+func (s *stemmer) consonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.consonant(i-1)
+	}
+	return true
+}
+
+// measure counts the VC sequences in b[:end].
+func (s *stemmer) measure(end int) int {
+	n := 0
+	i := 0
+	for i < end && s.consonant(i) {
+		i++
+	}
+	for i < end {
+		for i < end && !s.consonant(i) {
+			i++
+		}
+		if i >= end {
+			break
+		}
+		for i < end && s.consonant(i) {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
+// hasVowel reports whether b[:end] contains a vowel.
+func (s *stemmer) hasVowel(end int) bool {
+	for i := 0; i < end; i++ {
+		if !s.consonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleConsonant reports whether b[:end] ends in a double consonant.
+func (s *stemmer) doubleConsonant(end int) bool {
+	return end >= 2 && s.b[end-1] == s.b[end-2] && s.consonant(end-1)
+}
+
+// cvc reports whether b[:end] ends consonant-vowel-consonant where the
+// final consonant is not w, x or y.
+func (s *stemmer) cvc(end int) bool {
+	if end < 3 || !s.consonant(end-1) || s.consonant(end-2) || !s.consonant(end-3) {
+		return false
+	}
+	switch s.b[end-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func (s *stemmer) hasSuffix(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+// replace swaps suffix for replacement when the remaining stem has a measure
+// greater than minMeasure. Returns true if suffix matched, even if not replaced.
+func (s *stemmer) replace(suffix, replacement string, minMeasure int) bool {
+	if !s.hasSuffix(suffix) {
+		return false
+	}
+	stem := len(s.b) - len(suffix)
+	if s.measure(stem) > minMeasure {
+		s.b = append(s.b[:stem], replacement...)
+	}
+	return true
+}
+
+func (s *stemmer) step1a() {
+	switch {
+	case s.hasSuffix("sses"):
+		s.b = s.b[:len(s.b)-2]
+	case s.hasSuffix("ies"):
+		s.b = s.b[:len(s.b)-2]
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.b = s.b[:len(s.b)-1]
+	}
+}
+
+func (s *stemmer) step1b() {
+	if s.hasSuffix("eed") {
+		if s.measure(len(s.b)-3) > 0 {
+			s.b = s.b[:len(s.b)-1]
+		}
+		return
+	}
+
+	var stem int
+	switch {
+	case s.hasSuffix("ed") && s.hasVowel(len(s.b)-2):
+		stem = len(s.b) - 2
+	case s.hasSuffix("ing") && s.hasVowel(len(s.b)-3):
+		stem = len(s.b) - 3
+	default:
+		return
+	}
+	s.b = s.b[:stem]
+
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleConsonant(len(s.b)):
+		switch s.b[len(s.b)-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:len(s.b)-1]
+		}
+	case s.measure(len(s.b)) == 1 && s.cvc(len(s.b)):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.hasSuffix("y") && s.hasVowel(len(s.b)-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+var step2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+func (s *stemmer) step2() {
+	for _, rule := range step2Rules {
+		if s.replace(rule[0], rule[1], 0) {
+			return
+		}
+	}
+}
+
+var step3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func (s *stemmer) step3() {
+	for _, rule := range step3Rules {
+		if s.replace(rule[0], rule[1], 0) {
+			return
+		}
+	}
+}
+
+// step4Suffixes is ordered so longer suffixes are tried before their tails.
+var step4Suffixes = []string{
+	"ement", "ance", "ence", "able", "ible", "ment", "ant", "ent", "ism",
+	"ate", "iti", "ous", "ive", "ize", "ion", "al", "er", "ic", "ou",
+}
+
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes {
+		if !s.hasSuffix(suffix) {
+			continue
+		}
+		stem := len(s.b) - len(suffix)
+		if suffix == "ion" && (stem == 0 || (s.b[stem-1] != 's' && s.b[stem-1] != 't')) {
+			return
+		}
+		if s.measure(stem) > 1 {
+			s.b = s.b[:stem]
+		}
+		return
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.hasSuffix("e") {
+		stem := len(s.b) - 1
+		m := s.measure(stem)
+		if m > 1 || (m == 1 && !s.cvc(stem)) {
+			s.b = s.b[:stem]
+		}
+	}
+
+	if s.hasSuffix("ll") && s.measure(len(s.b)) > 1 {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{word: "caresses", expected: "caress"},
+		{word: "ponies", expected: "poni"},
+		{word: "cats", expected: "cat"},
+		{word: "feed", expected: "feed"},
+		{word: "agreed", expected: "agre"},
+		{word: "plastered", expected: "plaster"},
+		{word: "motoring", expected: "motor"},
+		{word: "sing", expected: "sing"},
+		{word: "hopping", expected: "hop"},
+		{word: "falling", expected: "fall"},
+		{word: "filing", expected: "file"},
+		{word: "happy", expected: "happi"},
+		{word: "relational", expected: "relat"},
+		{word: "generalizations", expected: "gener"},
+		{word: "meetings", expected: "meet"},
+		{word: "meeting", expected: "meet"},
+		{word: "running", expected: "run"},
+		{word: "go", expected: "go"},
+		{word: "2025", expected: "2025"},
+		{word: "café", expected: "café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Stem(tt.word))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -70,26 +71,25 @@ func Dedent(text string) string {
 	return strings.Join(result, "\n")
 }
 
-// ObjectToFrontmatter converts a map to YAML-style frontmatter.
-func ObjectToFrontmatter(obj map[string]any) string {
-	var lines []string
+// ObjectToFrontmatter converts a map to YAML frontmatter fields, without the
+// --- delimiters, sorted by key.
+func ObjectToFrontmatter(obj map[string]any) (string, error) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for key, value := range obj {
-		switch v := value.(type) {
-		case []string:
-			lines = append(lines, fmt.Sprintf("%s:", key))
-			for _, item := range v {
-				lines = append(lines, fmt.Sprintf("  - %s", item))
-			}
-		case []any:
-			lines = append(lines, fmt.Sprintf("%s:", key))
-			for _, item := range v {
-				lines = append(lines, fmt.Sprintf("  - %v", item))
-			}
-		default:
-			lines = append(lines, fmt.Sprintf("%s: %v", key, v))
+	f := NewFrontmatter()
+	for _, key := range keys {
+		if err := f.Set(key, obj[key]); err != nil {
+			return "", err
 		}
 	}
 
-	return strings.Join(lines, "\n")
+	text, err := writeYAMLFrontmatter(f.doc, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to write frontmatter: %w", err)
+	}
+	return text, nil
 }
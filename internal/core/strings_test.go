@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSlugify(t *testing.T) {
@@ -98,344 +99,81 @@ func TestObjectToFrontmatter(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    map[string]any
-		contains []string
-	}{
-		{
-			name: "simple values",
-			input: map[string]any{
-				"title": "Hello",
-				"count": 42,
-			},
-			contains: []string{"title: Hello", "count: 42"},
-		},
-		{
-			name: "array values",
-			input: map[string]any{
-				"tags": []string{"one", "two"},
-			},
-			contains: []string{"tags:", "  - one", "  - two"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			for _, expected := range tt.contains {
-				assert.Contains(t, result, expected)
-			}
-		})
-	}
-}
-
-// === ObjectToFrontmatter Edge Case Tests ===
-
-func TestObjectToFrontmatter_NestedObjects(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    map[string]any
-		contains []string
-		note     string
-	}{
-		{
-			name: "nested map becomes string representation",
-			input: map[string]any{
-				"title": "Test",
-				"metadata": map[string]any{
-					"author": "John",
-					"date":   "2024-01-01",
-				},
-			},
-			contains: []string{"title: Test", "metadata: map[author:John date:2024-01-01]"},
-			note:     "Nested maps are converted to string representation",
-		},
-		{
-			name: "deeply nested objects",
-			input: map[string]any{
-				"config": map[string]any{
-					"database": map[string]any{
-						"host": "localhost",
-						"port": 5432,
-					},
-				},
-			},
-			contains: []string{"config: map[database:map[host:localhost port:5432]]"},
-			note:     "Deep nesting becomes flattened string",
-		},
-		{
-			name: "mixed types in nested object",
-			input: map[string]any{
-				"settings": map[string]any{
-					"enabled": true,
-					"count":   10,
-					"name":    "test",
-				},
-			},
-			contains: []string{"settings: map["},
-			note:     "Mixed types in nested objects work",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			for _, expected := range tt.contains {
-				assert.Contains(t, result, expected, "Test: %s", tt.note)
-			}
-		})
-	}
-}
-
-func TestObjectToFrontmatter_ArrayValues(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    map[string]any
-		contains []string
-		note     string
+		expected string
 	}{
 		{
-			name: "mixed type array",
-			input: map[string]any{
-				"items": []any{"string", 42, true, nil},
-			},
-			contains: []string{"items:", "  - string", "  - 42", "  - true", "  - <nil>"},
-			note:     "Mixed types in any array are converted appropriately",
-		},
-		{
-			name: "empty string array",
-			input: map[string]any{
-				"tags": []string{},
-			},
-			contains: []string{"tags:"},
-			note:     "Empty arrays create header only",
-		},
-		{
-			name: "empty any array",
-			input: map[string]any{
-				"items": []any{},
-			},
-			contains: []string{"items:"},
-			note:     "Empty any arrays work the same",
+			name:     "simple values in key order",
+			input:    map[string]any{"title": "Hello", "count": 42, "draft": false},
+			expected: "count: 42\ndraft: false\ntitle: Hello\n",
 		},
 		{
-			name: "array with special characters",
-			input: map[string]any{
-				"commands": []string{"git commit", "echo \"hello\"", "rm -rf /"},
-			},
-			contains: []string{"commands:", "  - git commit", "  - echo \"hello\"", "  - rm -rf /"},
-			note:     "Special characters in array items are preserved",
+			name:     "array values",
+			input:    map[string]any{"tags": []string{"one", "two"}, "empty": []any{}},
+			expected: "empty: []\ntags:\n  - one\n  - two\n",
 		},
 		{
-			name: "array with unicode",
-			input: map[string]any{
-				"names": []string{"José", "François", "München"},
-			},
-			contains: []string{"names:", "  - José", "  - François", "  - München"},
-			note:     "Unicode in arrays works correctly",
-		},
-		{
-			name: "nested arrays in any array",
-			input: map[string]any{
-				"matrix": []any{[]string{"a", "b"}, []int{1, 2}},
-			},
-			contains: []string{"matrix:", "  - [a b]", "  - [1 2]"},
-			note:     "Nested arrays become string representations",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			for _, expected := range tt.contains {
-				assert.Contains(t, result, expected, "Test: %s", tt.note)
-			}
-		})
-	}
-}
-
-func TestObjectToFrontmatter_SpecialValues(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    map[string]any
-		contains []string
-		note     string
-	}{
-		{
-			name: "nil values",
+			name: "nested maps",
 			input: map[string]any{
-				"title":       "Test",
-				"description": nil,
-				"count":       0,
-				"enabled":     false,
+				"metadata": map[string]any{"author": "John", "date": "2024-01-01"},
 			},
-			contains: []string{"title: Test", "description: <nil>", "count: 0", "enabled: false"},
-			note:     "Nil and zero values are handled correctly",
+			expected: "metadata:\n  author: John\n  date: \"2024-01-01\"\n",
 		},
 		{
-			name: "boolean values",
-			input: map[string]any{
-				"published": true,
-				"draft":     false,
-			},
-			contains: []string{"published: true", "draft: false"},
-			note:     "Boolean values work correctly",
+			name:     "strings needing quotes",
+			input:    map[string]any{"colon": "key: value", "hash": "#tag", "bool": "true"},
+			expected: "bool: \"true\"\ncolon: 'key: value'\nhash: '#tag'\n",
 		},
 		{
-			name: "numeric types",
-			input: map[string]any{
-				"int":     42,
-				"int64":   int64(1000000000),
-				"float32": float32(3.14),
-				"float64": 2.71828,
-			},
-			contains: []string{"int: 42", "int64: 1000000000", "float32: 3.14", "float64: 2.71828"},
-			note:     "Different numeric types work",
+			name:     "multiline strings",
+			input:    map[string]any{"summary": "line1\nline2"},
+			expected: "summary: |-\n  line1\n  line2\n",
 		},
 		{
-			name: "empty string",
-			input: map[string]any{
-				"title": "",
-				"body":  "content",
-			},
-			contains: []string{"title: ", "body: content"},
-			note:     "Empty strings are preserved",
+			name:     "nil",
+			input:    map[string]any{"description": nil},
+			expected: "description: null\n",
 		},
 		{
-			name: "whitespace strings",
-			input: map[string]any{
-				"spaces": "   ",
-				"tabs":   "\t\t",
-				"mixed":  " \t \n ",
-			},
-			contains: []string{"spaces:    ", "tabs: \t\t", "mixed:  \t \n "},
-			note:     "Whitespace-only strings are preserved",
+			name:     "empty map",
+			input:    map[string]any{},
+			expected: "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			for _, expected := range tt.contains {
-				assert.Contains(t, result, expected, "Test: %s", tt.note)
-			}
+			result, err := ObjectToFrontmatter(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
-func TestObjectToFrontmatter_UnicodeAndEscaping(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    map[string]any
-		contains []string
-		note     string
-	}{
-		{
-			name: "unicode characters",
-			input: map[string]any{
-				"title":  "Café Notes",
-				"author": "José María González",
-				"city":   "München",
-				"symbol": "£€¥₹",
-			},
-			contains: []string{"title: Café Notes", "author: José María González", "city: München", "symbol: £€¥₹"},
-			note:     "Unicode characters are preserved correctly",
-		},
-		{
-			name: "special characters that might need escaping",
-			input: map[string]any{
-				"quote":     "She said \"hello\"",
-				"colon":     "key: value format",
-				"newline":   "line1\nline2",
-				"backslash": "path\\to\\file",
-			},
-			contains: []string{
-				"quote: She said \"hello\"",
-				"colon: key: value format",
-				"newline: line1\nline2",
-				"backslash: path\\to\\file",
-			},
-			note: "Special characters are included as-is (YAML escaping handled by consumer)",
-		},
-		{
-			name: "emoji and symbols",
-			input: map[string]any{
-				"status": "✅ Complete",
-				"mood":   "😊 Happy",
-				"math":   "x² + y² = z²",
-			},
-			contains: []string{"status: ✅ Complete", "mood: 😊 Happy", "math: x² + y² = z²"},
-			note:     "Emoji and mathematical symbols work",
-		},
-		{
-			name: "very long string",
-			input: map[string]any{
-				"long": strings.Repeat("Lorem ipsum ", 100),
-			},
-			contains: []string{"long: " + strings.Repeat("Lorem ipsum ", 10)}, // Check beginning
-			note:     "Very long strings are handled",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			for _, expected := range tt.contains {
-				assert.Contains(t, result, expected, "Test: %s", tt.note)
-			}
-		})
-	}
+func TestObjectToFrontmatter_RoundTrip(t *testing.T) {
+	input := map[string]any{
+		"title":          "Café: Notes",
+		"quote":          "She said \"hello\"",
+		"newline":        " line1\nline2 ",
+		"path":           "C:\\notes\\file",
+		"spaces":         "   ",
+		"emoji":          "✅ Complete",
+		"number":         2.71828,
+		"settings":       map[string]any{"enabled": true, "database": map[string]any{"host": "localhost", "port": 5432}},
+		"matrix":         []any{[]any{"a", "b"}, []any{1, 2}},
+		"key with space": "value",
+		"key:with:colon": "value",
+		"long":           strings.Repeat("Lorem ipsum ", 100),
+	}
+
+	result, err := ObjectToFrontmatter(input)
+	require.NoError(t, err)
+
+	parsed, _, err := ParseFrontmatter("---\n" + result + "---\n")
+	require.NoError(t, err)
+	assert.Equal(t, input, parsed)
 }
 
-func TestObjectToFrontmatter_ErrorConditions(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    map[string]any
-		contains []string
-		note     string
-	}{
-		{
-			name: "empty map",
-			input: map[string]any{},
-			contains: []string{},
-			note:     "Empty map should produce empty result",
-		},
-		{
-			name: "complex unsupported types",
-			input: map[string]any{
-				"channel": make(chan int),
-				"func":    func() {},
-			},
-			contains: []string{"channel:", "func:"},
-			note:     "Complex types get converted to string representation",
-		},
-		{
-			name: "keys with special characters",
-			input: map[string]any{
-				"normal-key":    "value1",
-				"key with space": "value2",
-				"key:with:colon": "value3",
-				"key\"quote":     "value4",
-			},
-			contains: []string{
-				"normal-key: value1",
-				"key with space: value2", 
-				"key:with:colon: value3",
-				"key\"quote: value4",
-			},
-			note: "Keys with special characters are preserved as-is",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ObjectToFrontmatter(tt.input)
-			
-			if len(tt.contains) == 0 {
-				assert.Empty(t, result, "Test: %s", tt.note)
-			} else {
-				for _, expected := range tt.contains {
-					assert.Contains(t, result, expected, "Test: %s", tt.note)
-				}
-			}
-		})
-	}
+func TestObjectToFrontmatter_UnsupportedTypes(t *testing.T) {
+	_, err := ObjectToFrontmatter(map[string]any{"channel": make(chan int)})
+	assert.Error(t, err)
 }
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TagsField is the frontmatter field holding a note's tags.
+const TagsField = "tags"
+
+// NormalizeTag returns tag the way opennotes compares tags: without a leading
+// #, in lower case, with spaces as dashes and without empty levels, so
+// "#Project/ Alpha Beta/" is "project/alpha-beta". Returns "" when nothing is
+// left.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	var levels []string
+	for _, level := range strings.Split(tag, "/") {
+		if level = strings.Join(strings.Fields(strings.ToLower(level)), "-"); level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return strings.Join(levels, "/")
+}
+
+// ValidTag reports whether tag, normalised, can be written as a #hashtag:
+// letters, digits, _, - and / with at least one letter.
+func ValidTag(tag string) bool {
+	letter := false
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return false
+		}
+		letter = letter || unicode.IsLetter(r)
+	}
+	return letter
+}
+
+// TagLevels returns tag and the tags above it in its hierarchy, outermost
+// first: "project/alpha/api" gives project, project/alpha and
+// project/alpha/api.
+func TagLevels(tag string) []string {
+	var levels []string
+	for i, r := range tag {
+		if r == '/' {
+			levels = append(levels, tag[:i])
+		}
+	}
+	return append(levels, tag)
+}
+
+// HasTagPrefix reports whether tag is parent or a tag under it.
+func HasTagPrefix(tag, parent string) bool {
+	return tag == parent || strings.HasPrefix(tag, parent+"/")
+}
+
+// FieldTags returns the normalised tags of a frontmatter tags value: a list
+// of tags, or a string of tags separated by commas or spaces.
+func FieldTags(value any) []string {
+	var tags []string
+	add := func(tag string) {
+		if tag = NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		for _, tag := range strings.FieldsFunc(v, isTagSeparator) {
+			add(tag)
+		}
+	case []string:
+		for _, tag := range v {
+			add(tag)
+		}
+	case []any:
+		for _, item := range v {
+			switch item.(type) {
+			case nil, []any, map[string]any:
+			default:
+				add(fmt.Sprint(item))
+			}
+		}
+	}
+	return tags
+}
+
+// NoteTags returns the tags of a note: its frontmatter tags and the
+// #hashtags in content, normalised, without duplicates and sorted.
+func NoteTags(metadata map[string]any, content string) []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, tag := range append(FieldTags(metadata[TagsField]), ScanHashtags(content)...) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// ScanHashtags returns the normalised #hashtags of content in document
+// order. A hashtag starts a line or follows a space, and is made of
+// letters, digits, _, - and / with at least one letter, so headings, #123
+// and link #fragments aren't tags. Frontmatter, code blocks and code spans
+// are skipped.
+func ScanHashtags(content string) []string {
+	var tags []string
+	RewriteHashtags(content, func(tag string) (string, bool) {
+		tags = append(tags, tag)
+		return "", false
+	})
+	return tags
+}
+
+// RewriteHashtags calls rewrite with the normalised tag of every hashtag
+// ScanHashtags would return, and replaces the hashtag with the returned tag
+// when rewrite returns true.
+func RewriteHashtags(content string, rewrite func(tag string) (string, bool)) string {
+	return rewriteProse(content, func(text string, _ int) string {
+		return rewriteHashtagLine(text, rewrite)
+	})
+}
+
+// rewriteHashtagLine rewrites the hashtags on one line outside code blocks.
+func rewriteHashtagLine(text string, rewrite func(string) (string, bool)) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '\\' && i+1 < len(text):
+			b.WriteString(text[i : i+2])
+			i += 2
+		case text[i] == '`':
+			end := codeSpanEnd(text, i)
+			b.WriteString(text[i:end])
+			i = end
+		case text[i] == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			end := hashtagEnd(text, i+1)
+			tag := text[i+1 : end]
+			if !ValidTag(tag) {
+				b.WriteByte('#')
+				i++
+				continue
+			}
+			if replacement, ok := rewrite(NormalizeTag(tag)); ok {
+				tag = replacement
+			}
+			b.WriteString("#" + tag)
+			i = end
+		default:
+			b.WriteByte(text[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// hashtagEnd returns the end of the hashtag starting at start, leaving out
+// trailing slashes.
+func hashtagEnd(text string, start int) int {
+	end := start
+	for i, r := range text[start:] {
+		if !isHashtagRune(r) {
+			break
+		}
+		end = start + i + len(string(r))
+	}
+	for end > start && text[end-1] == '/' {
+		end--
+	}
+	return end
+}
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/'
+}
+
+func isTagSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
+}
+
+// RewriteTags calls rewrite with every tag of content, in its frontmatter
+// tags field and its #hashtags, and replaces the tag when rewrite returns
+// true. A tags list that ends up with a tag twice keeps the first. Returns
+// the new content and the number of tags replaced; content without
+// replacements is returned as it was.
+func RewriteTags(content string, rewrite func(tag string) (string, bool)) (string, int, error) {
+	frontmatter, body, err := ReadFrontmatter(content)
+	if err != nil {
+		return "", 0, err
+	}
+
+	count := 0
+	fieldChanged := false
+	if i := frontmatter.index(TagsField); i >= 0 {
+		n := rewriteTagsNode(frontmatter.fields.Content[i+1], rewrite)
+		count += n
+		fieldChanged = n > 0
+	}
+
+	newBody := RewriteHashtags(body, func(tag string) (string, bool) {
+		replacement, ok := rewrite(tag)
+		if ok && replacement != tag {
+			count++
+			return replacement, true
+		}
+		return "", false
+	})
+
+	if count == 0 {
+		return content, 0, nil
+	}
+	if !fieldChanged {
+		return content[:len(content)-len(body)] + newBody, count, nil
+	}
+	result, err := frontmatter.Render(newBody)
+	if err != nil {
+		return "", 0, err
+	}
+	return result, count, nil
+}
+
+// rewriteTagsNode rewrites the tags of a frontmatter tags value in place and
+// returns the number replaced. Lists keep their style and comments, and
+// strings their separators.
+func rewriteTagsNode(node *yaml.Node, rewrite func(string) (string, bool)) int {
+	replace := func(text string) (string, bool) {
+		tag := NormalizeTag(text)
+		if tag == "" {
+			return "", false
+		}
+		replacement, ok := rewrite(tag)
+		return replacement, ok && replacement != tag
+	}
+
+	count := 0
+	switch node.Kind {
+	case yaml.SequenceNode:
+		seen := make(map[string]bool)
+		kept := node.Content[:0]
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				kept = append(kept, item)
+				continue
+			}
+			if replacement, ok := replace(item.Value); ok {
+				item.Value, item.Tag = replacement, "!!str"
+				count++
+			}
+			tag := NormalizeTag(item.Value)
+			if tag != "" && seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			kept = append(kept, item)
+		}
+		node.Content = kept
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return 0
+		}
+		var b strings.Builder
+		rest := node.Value
+		for rest != "" {
+			start := strings.IndexFunc(rest, func(r rune) bool { return !isTagSeparator(r) })
+			if start < 0 {
+				b.WriteString(rest)
+				break
+			}
+			end := strings.IndexFunc(rest[start:], isTagSeparator)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += start
+			}
+			b.WriteString(rest[:start])
+			if replacement, ok := replace(rest[start:end]); ok {
+				b.WriteString(replacement)
+				count++
+			} else {
+				b.WriteString(rest[start:end])
+			}
+			rest = rest[end:]
+		}
+		node.Value = b.String()
+	}
+	return count
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := map[string]string{
+		"work":                   "work",
+		"#Work":                  "work",
+		"  Project/Alpha ":       "project/alpha",
+		"#Project/ Alpha Beta/":  "project/alpha-beta",
+		"project//alpha":         "project/alpha",
+		"#":                      "",
+		"":                       "",
+		"Ünïcode/Überschrift":    "ünïcode/überschrift",
+		"multi  word   tag":      "multi-word-tag",
+		"/leading/and/trailing/": "leading/and/trailing",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, NormalizeTag(input), input)
+	}
+}
+
+func TestValidTag(t *testing.T) {
+	assert.True(t, ValidTag("work"))
+	assert.True(t, ValidTag("project/alpha-2"))
+	assert.True(t, ValidTag("2025_q1"))
+	assert.False(t, ValidTag("123"))
+	assert.False(t, ValidTag("two words"))
+	assert.False(t, ValidTag("a.b"))
+	assert.False(t, ValidTag(""))
+}
+
+func TestTagLevels(t *testing.T) {
+	assert.Equal(t, []string{"work"}, TagLevels("work"))
+	assert.Equal(t, []string{"project", "project/alpha", "project/alpha/api"}, TagLevels("project/alpha/api"))
+
+	assert.True(t, HasTagPrefix("project/alpha", "project"))
+	assert.True(t, HasTagPrefix("project", "project"))
+	assert.False(t, HasTagPrefix("projects", "project"))
+}
+
+func TestFieldTags(t *testing.T) {
+	assert.Equal(t, []string{"work", "project/alpha"}, FieldTags([]any{"Work", "#project/alpha", "", nil, []any{"nested"}}))
+	assert.Equal(t, []string{"2025"}, FieldTags([]any{2025}))
+	assert.Equal(t, []string{"work", "home", "later"}, FieldTags("work, home later"))
+	assert.Equal(t, []string{"a"}, FieldTags([]string{"A"}))
+	assert.Empty(t, FieldTags(nil))
+	assert.Empty(t, FieldTags(map[string]any{"a": 1}))
+}
+
+func TestScanHashtags(t *testing.T) {
+	content := strings.Join([]string{
+		"---",
+		"title: '#not-a-tag'",
+		"---",
+		"# Heading",
+		"#Work on #project/alpha/, see issue #123 and [top](#release).",
+		"Email a#b, escaped \\#nope, `#code` and (#paren).",
+		"```",
+		"#fenced",
+		"```",
+		"\t#tabbed #über",
+	}, "\n")
+
+	assert.Equal(t, []string{"work", "project/alpha", "tabbed", "über"}, ScanHashtags(content))
+}
+
+func TestNoteTags(t *testing.T) {
+	tags := NoteTags(map[string]any{"tags": []any{"Work", "project/alpha"}}, "About #work and #home.\n")
+	assert.Equal(t, []string{"home", "project/alpha", "work"}, tags)
+
+	assert.Equal(t, []string{}, NoteTags(nil, "No tags.\n"))
+}
+
+func TestRewriteTags(t *testing.T) {
+	rename := func(tag string) (string, bool) {
+		if HasTagPrefix(tag, "project") {
+			return "work" + strings.TrimPrefix(tag, "project"), true
+		}
+		return "", false
+	}
+
+	t.Run("list and hashtags", func(t *testing.T) {
+		content := "---\ntitle: Plan\ntags: [Project/Alpha, work/alpha, home] # topics\n---\n# Plan\n\nFor #project and #project/beta, not #projects.\n"
+		result, count, err := RewriteTags(content, rename)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, "---\ntitle: Plan\ntags: [work/alpha, home] # topics\n---\n# Plan\n\nFor #work and #work/beta, not #projects.\n", result)
+	})
+
+	t.Run("block list", func(t *testing.T) {
+		result, count, err := RewriteTags("---\ntags:\n  - project # main\n  - other\n---\n", rename)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, "---\ntags:\n  - work # main\n  - other\n---\n", result)
+	})
+
+	t.Run("string", func(t *testing.T) {
+		result, count, err := RewriteTags("+++\ntags = \"project,  home project/x\"\n+++\nbody\n", rename)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, "+++\ntags = \"work,  home work/x\"\n+++\nbody\n", result)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		result, count, err := RewriteTags("{\n  \"title\": \"Plan\",\n  \"tags\": [\"project\", \"home\"]\n}\nbody\n", rename)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, "{\n  \"title\": \"Plan\",\n  \"tags\": [\"work\", \"home\"]\n}\nbody\n", result)
+	})
+
+	t.Run("only hashtags", func(t *testing.T) {
+		content := "---\n# keep: this\ntitle:   spaced\n---\n#project\n"
+		result, count, err := RewriteTags(content, rename)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, "---\n# keep: this\ntitle:   spaced\n---\n#work\n", result, "frontmatter is kept as written")
+	})
+
+	t.Run("no tags", func(t *testing.T) {
+		content := "---\ntags: [home]\n---\n#other\n"
+		result, count, err := RewriteTags(content, rename)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Equal(t, content, result)
+	})
+
+	t.Run("invalid frontmatter", func(t *testing.T) {
+		_, _, err := RewriteTags("---\ntags: [unclosed\n---\n", rename)
+		assert.Error(t, err)
+	})
+}
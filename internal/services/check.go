@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// Kinds of CheckProblem.
+const (
+	ProblemMissingNote       = "missing-note"
+	ProblemMissingHeading    = "missing-heading"
+	ProblemMissingAttachment = "missing-attachment"
+	ProblemOrphan            = "orphan"
+)
+
+// ProblemKinds lists every kind of problem Check reports.
+var ProblemKinds = []string{ProblemMissingNote, ProblemMissingHeading, ProblemMissingAttachment, ProblemOrphan}
+
+// CheckProblem is a problem Check found in a note.
+type CheckProblem struct {
+	Kind string `json:"kind"`
+	// Note is the relative path of the note with the problem
+	Note string `json:"note"`
+	Line int    `json:"line,omitempty"`
+	// Link is the offending link as written, e.g. [[page#heading]]
+	Link    string `json:"link,omitempty"`
+	Message string `json:"message"`
+}
+
+// CheckReport is the result of checking a notebook.
+type CheckReport struct {
+	Notes    int            `json:"notes"`
+	Problems []CheckProblem `json:"problems"`
+}
+
+// Without returns the report without problems of the given kinds.
+func (r *CheckReport) Without(kinds ...string) *CheckReport {
+	filtered := &CheckReport{Notes: r.Notes, Problems: []CheckProblem{}}
+	for _, problem := range r.Problems {
+		skip := false
+		for _, kind := range kinds {
+			if problem.Kind == kind {
+				skip = true
+			}
+		}
+		if !skip {
+			filtered.Problems = append(filtered.Problems, problem)
+		}
+	}
+	return filtered
+}
+
+// checkedNote is a note's links and heading anchors.
+type checkedNote struct {
+	relative string
+	links    []core.LinkRef
+	anchors  map[string]bool
+}
+
+// Check looks for links to notes that don't exist, links to headings that
+// don't exist (#fragments), references to missing attachments and orphan
+// notes that have no links to or from other notes. Problems are ordered by
+// note and line.
+func (s *NoteService) Check(ctx context.Context) (*CheckReport, error) {
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	relatives := make([]string, len(notes))
+	checked := make(map[string]*checkedNote, len(notes))
+	for i, note := range notes {
+		relatives[i] = note.File.Relative
+
+		data, err := os.ReadFile(note.File.Filepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note: %w", err)
+		}
+		_, body, _ := core.SplitFrontmatter(string(data))
+
+		anchors := make(map[string]bool)
+		for _, anchor := range core.HeadingAnchors(body) {
+			anchors[anchor] = true
+		}
+		checked[note.File.Relative] = &checkedNote{
+			relative: note.File.Relative,
+			links:    core.ScanLinks(string(data)),
+			anchors:  anchors,
+		}
+	}
+
+	resolver := newNoteResolver(s.notebookPath, relatives)
+	attachments, err := attachmentNames(s.notebookPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CheckReport{Notes: len(notes), Problems: []CheckProblem{}}
+	linked := make(map[string]bool, len(notes))
+	for _, rel := range relatives {
+		note := checked[rel]
+		for _, link := range note.links {
+			problem := CheckProblem{Note: rel, Line: link.Line, Link: writtenLink(link)}
+
+			kind, isNote := noteLinkKind(link)
+			switch {
+			case link.Target == "" && link.Kind != core.LinkWiki:
+				// A #fragment within the note itself
+				if !hasAnchor(note, link.Fragment) {
+					problem.Kind = ProblemMissingHeading
+					problem.Message = fmt.Sprintf("no heading %s in this note", link.Fragment)
+				}
+			case isNote:
+				target := resolver.resolveLink(rel, kind, link.Target)
+				switch {
+				case target == "":
+					problem.Kind = ProblemMissingNote
+					problem.Message = fmt.Sprintf("no note %s", link.Target)
+				case !hasAnchor(checked[target], link.Fragment):
+					problem.Kind = ProblemMissingHeading
+					problem.Message = fmt.Sprintf("no heading %s in %s", link.Fragment, target)
+				}
+				if target != "" && target != rel {
+					linked[rel] = true
+					linked[target] = true
+				}
+			case link.Kind == core.LinkWiki:
+				if !wikiAttachmentExists(s.notebookPath, rel, link.Target, attachments) {
+					problem.Kind = ProblemMissingAttachment
+					problem.Message = fmt.Sprintf("no file %s", link.Target)
+				}
+			default:
+				file, ok := resolver.resolveFile(filepath.Join(s.notebookPath, filepath.FromSlash(rel)), link.Target)
+				if !ok {
+					continue
+				}
+				if _, err := os.Stat(file); err != nil {
+					problem.Kind = ProblemMissingAttachment
+					problem.Message = fmt.Sprintf("no file %s", link.Target)
+				}
+			}
+
+			if problem.Kind != "" {
+				report.Problems = append(report.Problems, problem)
+			}
+		}
+	}
+
+	for _, rel := range relatives {
+		if !linked[rel] {
+			report.Problems = append(report.Problems, CheckProblem{
+				Kind:    ProblemOrphan,
+				Note:    rel,
+				Message: "no links to or from other notes",
+			})
+		}
+	}
+
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		a, b := report.Problems[i], report.Problems[j]
+		if a.Note != b.Note {
+			return a.Note < b.Note
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+// hasAnchor reports whether fragment names a heading in note. Fragments may
+// be anchors (#open-questions) or, in wikilinks, heading text (#Open
+// Questions). Empty fragments and block references (#^id) always match.
+func hasAnchor(note *checkedNote, fragment string) bool {
+	fragment = strings.TrimPrefix(fragment, "#")
+	if fragment == "" || strings.HasPrefix(fragment, "^") {
+		return true
+	}
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	// Wikilinks can name nested headings, e.g. [[page#Plan#Risks]]
+	if i := strings.LastIndex(fragment, "#"); i >= 0 {
+		fragment = fragment[i+1:]
+	}
+	return note.anchors[strings.ToLower(fragment)] || note.anchors[core.HeadingAnchor(fragment)]
+}
+
+// writtenLink returns link roughly as it appears in the note.
+func writtenLink(link core.LinkRef) string {
+	if link.Kind == core.LinkWiki {
+		return "[[" + link.Target + link.Fragment + "]]"
+	}
+	return "(" + link.Target + link.Fragment + ")"
+}
+
+// attachmentNames returns the lowercased names of the files under root that
+// aren't notes.
+func attachmentNames(root string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() && !strings.HasSuffix(d.Name(), ".md") {
+			names[strings.ToLower(d.Name())] = true
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to scan notebook: %w", err)
+	}
+	return names, nil
+}
+
+// wikiAttachmentExists reports whether a wikilink page naming an attachment
+// resolves to a file: by path from the root or the note's directory, or else
+// by filename anywhere in the notebook.
+func wikiAttachmentExists(root, source, page string, names map[string]bool) bool {
+	for _, dir := range []string{root, filepath.Join(root, filepath.FromSlash(path.Dir(source)))} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(page))); err == nil {
+			return true
+		}
+	}
+	return !strings.Contains(page, "/") && names[strings.ToLower(page)]
+}
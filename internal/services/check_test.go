@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestNoteService_Check(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "guide.md", `---
+title: Guide
+---
+# Guide
+
+See [setup](setup.md#install), [missing](setup.md#uninstall) and [below](#next-steps).
+Also [[setup#Install]], [[setup#Nowhere]], [[setup#^block]] and [top](#nope).
+
+![diagram](images/diagram.png) and ![[chart.png]] and ![[gone.png]].
+[broken](old-page.md), [[Ghost]] and [site](https://example.com/missing.md).
+
+## Next Steps
+`)
+	testutil.WriteNote(t, root, "setup.md", "# Setup\n\n## Install\n\nBack to [[guide]].\n")
+	testutil.WriteNote(t, root, "assets/chart.png", "png")
+	testutil.WriteNote(t, root, "images/diagram.png", "png")
+	testutil.WriteNote(t, root, "lonely.md", "# Lonely\n\nLinks to [[lonely#Lonely]] itself.\n")
+
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+
+	report, err := notes.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Notes)
+
+	assert.Equal(t, []CheckProblem{
+		{Kind: ProblemMissingHeading, Note: "guide.md", Line: 6, Link: "(setup.md#uninstall)", Message: "no heading #uninstall in setup.md"},
+		{Kind: ProblemMissingHeading, Note: "guide.md", Line: 7, Link: "[[setup#Nowhere]]", Message: "no heading #Nowhere in setup.md"},
+		{Kind: ProblemMissingHeading, Note: "guide.md", Line: 7, Link: "(#nope)", Message: "no heading #nope in this note"},
+		{Kind: ProblemMissingAttachment, Note: "guide.md", Line: 9, Link: "[[gone.png]]", Message: "no file gone.png"},
+		{Kind: ProblemMissingNote, Note: "guide.md", Line: 10, Link: "(old-page.md)", Message: "no note old-page.md"},
+		{Kind: ProblemMissingNote, Note: "guide.md", Line: 10, Link: "[[Ghost]]", Message: "no note Ghost"},
+		{Kind: ProblemOrphan, Note: "lonely.md", Message: "no links to or from other notes"},
+	}, report.Problems)
+}
+
+func TestNoteService_Check_Clean(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "a.md", "# A\n\n## Plan\n\nSee [b](b.md#b) and ![logo](logo.svg).\n")
+	testutil.WriteNote(t, root, "b.md", "# B\n\nBack to [[a#Plan]].\n")
+	testutil.WriteNote(t, root, "logo.svg", "<svg/>")
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+
+	report, err := notes.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Notes)
+	assert.Empty(t, report.Problems)
+}
+
+func TestCheckReport_Without(t *testing.T) {
+	report := &CheckReport{Notes: 2, Problems: []CheckProblem{
+		{Kind: ProblemOrphan, Note: "a.md"},
+		{Kind: ProblemMissingNote, Note: "b.md"},
+		{Kind: ProblemMissingHeading, Note: "b.md"},
+	}}
+
+	filtered := report.Without(ProblemOrphan, ProblemMissingHeading)
+	assert.Equal(t, 2, filtered.Notes)
+	assert.Equal(t, []CheckProblem{{Kind: ProblemMissingNote, Note: "b.md"}}, filtered.Problems)
+	assert.Len(t, report.Problems, 3)
+}
+
+func TestHasAnchor(t *testing.T) {
+	note := &checkedNote{anchors: map[string]bool{"open-questions": true, "risks": true}}
+
+	tests := []struct {
+		fragment string
+		expected bool
+	}{
+		{"", true},
+		{"#open-questions", true},
+		{"#Open Questions", true},
+		{"#Open%20Questions", true},
+		{"#Plan#Risks", true},
+		{"#^block-id", true},
+		{"#answers", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fragment, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hasAnchor(note, tt.fragment))
+		})
+	}
+}
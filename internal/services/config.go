@@ -24,6 +24,21 @@ type Config struct {
 	Notebooks []string `koanf:"notebooks" json:"notebooks"`
 	// NotebookPath is the current notebook path (from env, flag, or stored)
 	NotebookPath string `koanf:"notebookpath" json:"notebookpath,omitempty"`
+	// MarkdownExtension is a local markdown extension file to load instead of
+	// installing from the community repository, or "builtin" for the Go reader
+	MarkdownExtension string `koanf:"markdownextension" json:"markdownextension,omitempty"`
+	// IndexDir is where persistent note indexes are stored (defaults to the user cache dir)
+	IndexDir string `koanf:"indexdir" json:"indexdir,omitempty"`
+	// SQLMemoryLimit caps the memory of --sql queries, e.g. "512MB"
+	SQLMemoryLimit string `koanf:"sqlmemorylimit" json:"sqlmemorylimit,omitempty"`
+	// SQLThreads is the number of threads --sql queries may use
+	SQLThreads int `koanf:"sqlthreads" json:"sqlthreads,omitempty"`
+	// SQLMaxRows is the most rows a --sql query returns
+	SQLMaxRows int `koanf:"sqlmaxrows" json:"sqlmaxrows,omitempty"`
+	// SQLHistoryFile is where the SQL REPL keeps its history (defaults to the user cache dir)
+	SQLHistoryFile string `koanf:"sqlhistoryfile" json:"sqlhistoryfile,omitempty"`
+	// Editor is the command notes are edited with (defaults to $VISUAL, then $EDITOR)
+	Editor string `koanf:"editor" json:"editor,omitempty"`
 }
 
 // ConfigService manages configuration loading and persistence.
@@ -59,8 +74,15 @@ func NewConfigServiceWithPath(configPath string) (*ConfigService, error) {
 	// 1. Load defaults
 	defaultNotebooksDir := filepath.Join(filepath.Dir(configPath), "notebooks")
 	defaults := map[string]interface{}{
-		"notebooks":    []string{defaultNotebooksDir},
-		"notebookpath": "",
+		"notebooks":         []string{defaultNotebooksDir},
+		"notebookpath":      "",
+		"markdownextension": "",
+		"indexdir":          "",
+		"sqlmemorylimit":    "",
+		"sqlthreads":        0,
+		"sqlmaxrows":        0,
+		"sqlhistoryfile":    "",
+		"editor":            "",
 	}
 
 	if err := k.Load(confmap.Provider(defaults, "."), nil); err != nil {
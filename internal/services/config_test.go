@@ -98,6 +98,48 @@ func TestNewConfigService_EnvVarPriorityOverFile(t *testing.T) {
 	assert.Equal(t, []string{"/path/to/notebooks"}, svc.Store.Notebooks)
 }
 
+func TestNewConfigService_MarkdownExtensionEnvVar(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "opennotes", "config.json")
+
+	t.Setenv("OPENNOTES_MARKDOWN_EXTENSION", BuiltinMarkdownReader)
+
+	svc, err := NewConfigServiceWithPath(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, BuiltinMarkdownReader, svc.Store.MarkdownExtension)
+}
+
+func TestNewConfigService_SQLLimitEnvVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "opennotes", "config.json")
+
+	t.Setenv("OPENNOTES_SQL_MEMORY_LIMIT", "1GB")
+	t.Setenv("OPENNOTES_SQL_THREADS", "4")
+	t.Setenv("OPENNOTES_SQL_MAX_ROWS", "500")
+	t.Setenv("OPENNOTES_SQL_HISTORY_FILE", "/tmp/history")
+
+	svc, err := NewConfigServiceWithPath(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1GB", svc.Store.SQLMemoryLimit)
+	assert.Equal(t, 4, svc.Store.SQLThreads)
+	assert.Equal(t, 500, svc.Store.SQLMaxRows)
+	assert.Equal(t, "/tmp/history", svc.Store.SQLHistoryFile)
+}
+
+func TestNewConfigService_EditorEnvVar(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "opennotes", "config.json")
+
+	t.Setenv("OPENNOTES_EDITOR", "code --wait")
+
+	svc, err := NewConfigServiceWithPath(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "code --wait", svc.Store.Editor)
+}
+
 func TestConfigService_Write_CreatesDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "nested", "opennotes", "config.json")
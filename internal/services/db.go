@@ -12,20 +12,90 @@ import (
 	_ "github.com/duckdb/duckdb-go/v2"
 )
 
+// DbOptions configures how DbService prepares its connections.
+type DbOptions struct {
+	// MarkdownExtension is a local path to a markdown extension binary, or
+	// BuiltinMarkdownReader to skip the extension. Empty uses the community build.
+	MarkdownExtension string
+	// IndexDir is where persistent note indexes are stored. Empty uses DefaultIndexDir.
+	IndexDir string
+	// SQLMemoryLimit caps the memory of user SQL queries, e.g. "512MB".
+	// Empty uses DefaultSQLMemoryLimit.
+	SQLMemoryLimit string
+	// SQLThreads is the number of threads user SQL queries may use.
+	// Zero uses DefaultSQLThreads.
+	SQLThreads int
+	// SQLMaxRows is the most rows a user SQL query returns.
+	// Zero uses DefaultSQLMaxRows.
+	SQLMaxRows int
+}
+
+// attachment identifies a database attached to a connection under an alias.
+type attachment struct {
+	db    *sql.DB
+	alias string
+}
+
+// indexOwners tracks which DbService has each index file attached for writing.
+// DuckDB's file lock only guards against other processes, so two in-process
+// instances writing the same file must be prevented here.
+var indexOwners = struct {
+	sync.Mutex
+	files map[string]*DbService
+}{files: make(map[string]*DbService)}
+
+// claimIndexFile reserves path for writing by d.
+// Returns false if another DbService in this process already holds it.
+func (d *DbService) claimIndexFile(path string) bool {
+	indexOwners.Lock()
+	defer indexOwners.Unlock()
+
+	if owner, ok := indexOwners.files[path]; ok && owner != d {
+		return false
+	}
+	indexOwners.files[path] = d
+	return true
+}
+
+// releaseIndexFiles releases every index file claimed by d.
+func (d *DbService) releaseIndexFiles() {
+	indexOwners.Lock()
+	defer indexOwners.Unlock()
+
+	for path, owner := range indexOwners.files {
+		if owner == d {
+			delete(indexOwners.files, path)
+		}
+	}
+}
+
 // DbService manages DuckDB database connections.
 type DbService struct {
 	db       *sql.DB
-	readOnly *sql.DB
+	readOnly map[string]*sql.DB
 	once     sync.Once
-	roOnce   sync.Once
+	roMu     sync.Mutex
 	mu       sync.Mutex
+	indexMu  sync.Mutex
+	attached map[attachment]string
+	indexes  map[string]*NoteIndex
+	options  DbOptions
 	log      zerolog.Logger
 }
 
 // NewDbService creates a new database service.
 func NewDbService() *DbService {
+	return NewDbServiceWithOptions(DbOptions{})
+}
+
+// NewDbServiceWithOptions creates a database service with custom options.
+func NewDbServiceWithOptions(options DbOptions) *DbService {
 	return &DbService{
-		log: Log("DbService"),
+		readOnly: make(map[string]*sql.DB),
+		attached: make(map[attachment]string),
+		indexes:  make(map[string]*NoteIndex),
+		options:  options,
+		log:      Log("DbService"),
 	}
 }
 
@@ -45,16 +115,8 @@ func (d *DbService) GetDB(ctx context.Context) (*sql.DB, error) {
 		}
 		d.db = db
 
-		// Install and load markdown extension
-		d.log.Debug().Msg("installing markdown extension")
-		if _, err := db.ExecContext(ctx, "INSTALL markdown FROM community"); err != nil {
-			initErr = fmt.Errorf("failed to install markdown extension: %w", err)
-			return
-		}
-
-		d.log.Debug().Msg("loading markdown extension")
-		if _, err := db.ExecContext(ctx, "LOAD markdown"); err != nil {
-			initErr = fmt.Errorf("failed to load markdown extension: %w", err)
+		if err := d.loadMarkdown(ctx, db, false, ""); err != nil {
+			initErr = err
 			return
 		}
 
@@ -68,54 +130,161 @@ func (d *DbService) GetDB(ctx context.Context) (*sql.DB, error) {
 	return d.db, nil
 }
 
-// GetReadOnlyDB returns a separate read-only database connection.
-// This is used for executing user-provided SQL queries safely.
-// The connection is lazily initialized on first call and reused thereafter.
-func (d *DbService) GetReadOnlyDB(ctx context.Context) (*sql.DB, error) {
-	var initErr error
+// GetReadOnlyDB returns the sandboxed database used for user SQL queries
+// against the notebook at root. It can only read files under root and the
+// notebook's index; see openSandbox. An empty root allows no file access.
+// Each root's database is lazily initialized on first call and reused thereafter.
+func (d *DbService) GetReadOnlyDB(ctx context.Context, root string) (*sql.DB, error) {
+	d.roMu.Lock()
+	defer d.roMu.Unlock()
 
-	d.roOnce.Do(func() {
-		d.log.Debug().Msg("initializing read-only database connection")
+	if db, ok := d.readOnly[root]; ok {
+		return db, nil
+	}
 
-		// Open separate in-memory database
-		db, err := sql.Open("duckdb", "")
-		if err != nil {
-			initErr = fmt.Errorf("failed to open read-only database: %w", err)
-			return
+	d.log.Debug().Str("root", root).Msg("initializing read-only database connection")
+
+	db, err := d.openSandbox(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	d.readOnly[root] = db
+	d.log.Debug().Str("root", root).Msg("read-only database initialized")
+	return db, nil
+}
+
+// loadMarkdown makes read_markdown available on db.
+// Sources are tried in order: the configured local extension, an already
+// installed extension, the community repository, then the built-in Go reader.
+// Only the last step works without network access or a cached extension.
+// When confined the built-in reader only reads files under root.
+// Either way the md_extract_* and helper functions are registered too, see
+// registerSQLFunctions.
+func (d *DbService) loadMarkdown(ctx context.Context, db *sql.DB, confined bool, root string) error {
+	if err := d.loadMarkdownReader(ctx, db, confined, root); err != nil {
+		return err
+	}
+
+	if err := registerSQLFunctions(ctx, db); err != nil {
+		return fmt.Errorf("failed to register SQL functions: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DbService) loadMarkdownReader(ctx context.Context, db *sql.DB, confined bool, root string) error {
+	if d.options.MarkdownExtension != BuiltinMarkdownReader {
+		err := d.loadMarkdownExtension(ctx, db, d.options.MarkdownExtension)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
 		}
+		d.log.Debug().Err(err).Msg("markdown extension unavailable, using built-in reader")
+	}
 
-		// Install and load markdown extension
-		d.log.Debug().Msg("installing markdown extension on read-only connection")
-		if _, err := db.ExecContext(ctx, "INSTALL markdown FROM community"); err != nil {
-			initErr = fmt.Errorf("failed to install markdown extension on read-only connection: %w", err)
-			if closeErr := db.Close(); closeErr != nil {
-				d.log.Warn().Err(closeErr).Msg("failed to close db after install error")
-			}
-			return
+	d.log.Debug().Msg("registering built-in markdown reader")
+	if err := registerMarkdownReader(ctx, db, confined, root); err != nil {
+		return fmt.Errorf("failed to register built-in markdown reader: %w", err)
+	}
+
+	return nil
+}
+
+// loadMarkdownExtension loads the DuckDB markdown extension.
+// When path is set the extension is loaded from that file only.
+func (d *DbService) loadMarkdownExtension(ctx context.Context, db *sql.DB, path string) error {
+	if path != "" {
+		d.log.Debug().Str("path", path).Msg("loading markdown extension from file")
+		if _, err := db.ExecContext(ctx, "LOAD "+quoteSQLString(path)); err != nil {
+			return fmt.Errorf("failed to load markdown extension from %s: %w", path, err)
 		}
+		return nil
+	}
 
-		d.log.Debug().Msg("loading markdown extension on read-only connection")
-		if _, err := db.ExecContext(ctx, "LOAD markdown"); err != nil {
-			initErr = fmt.Errorf("failed to load markdown extension on read-only connection: %w", err)
-			if closeErr := db.Close(); closeErr != nil {
-				d.log.Warn().Err(closeErr).Msg("failed to close db after load error")
-			}
-			return
+	// Prefer a previously installed copy so no network access is needed
+	d.log.Debug().Msg("loading markdown extension")
+	if _, err := db.ExecContext(ctx, "LOAD markdown"); err == nil {
+		return nil
+	}
+
+	d.log.Debug().Msg("installing markdown extension")
+	if _, err := db.ExecContext(ctx, "INSTALL markdown FROM community"); err != nil {
+		return fmt.Errorf("failed to install markdown extension: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "LOAD markdown"); err != nil {
+		return fmt.Errorf("failed to load markdown extension: %w", err)
+	}
+
+	return nil
+}
+
+// NoteIndex returns the persistent note index for a notebook root.
+// Indexes are shared by every NoteService using this DbService.
+func (d *DbService) NoteIndex(root string) *NoteIndex {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if index, ok := d.indexes[root]; ok {
+		return index
+	}
+
+	index := NewNoteIndex(d, root, IndexPath(d.options.IndexDir, root))
+	d.indexes[root] = index
+	return index
+}
+
+// attach attaches the database file at path to db under alias.
+// It is a no-op when the same file is already attached under that alias.
+func (d *DbService) attach(ctx context.Context, db *sql.DB, alias, path string, readOnly bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := attachment{db: db, alias: alias}
+	if current, ok := d.attached[key]; ok {
+		if current == path {
+			return nil
 		}
+		if _, err := db.ExecContext(ctx, "DETACH "+alias); err != nil {
+			return fmt.Errorf("failed to detach %s: %w", alias, err)
+		}
+		delete(d.attached, key)
+	}
 
-		d.readOnly = db
-		d.log.Debug().Msg("read-only database initialized")
-	})
+	stmt := fmt.Sprintf("ATTACH %s AS %s", quoteSQLString(path), alias)
+	if readOnly {
+		stmt += " (READ_ONLY)"
+	}
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to attach %s: %w", path, err)
+	}
 
-	if initErr != nil {
-		return nil, initErr
+	d.attached[key] = path
+	return nil
+}
+
+// detach removes a database previously attached with attach.
+func (d *DbService) detach(ctx context.Context, db *sql.DB, alias string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := attachment{db: db, alias: alias}
+	if _, ok := d.attached[key]; !ok {
+		return nil
 	}
 
-	return d.readOnly, nil
+	delete(d.attached, key)
+	if _, err := db.ExecContext(ctx, "DETACH "+alias); err != nil {
+		return fmt.Errorf("failed to detach %s: %w", alias, err)
+	}
+	return nil
 }
 
 // Query executes a query and returns results as maps.
-func (d *DbService) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+func (d *DbService) Query(ctx context.Context, query string, args ...interface{}) (*ResultSet, error) {
 	db, err := d.GetDB(ctx)
 	if err != nil {
 		return nil, err
@@ -131,43 +300,7 @@ func (d *DbService) Query(ctx context.Context, query string, args ...interface{}
 		}
 	}()
 
-	return rowsToMaps(rows)
-}
-
-// rowsToMaps converts sql.Rows to a slice of maps.
-func rowsToMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-
-	var results []map[string]interface{}
-
-	for rows.Next() {
-		// Create slice of interface{} to hold values
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, err
-		}
-
-		// Create map for this row
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			row[col] = values[i]
-		}
-		results = append(results, row)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return results, nil
+	return NewResultSet(rows)
 }
 
 // Close closes both database connections.
@@ -184,12 +317,18 @@ func (d *DbService) Close() error {
 		}
 	}
 
-	if d.readOnly != nil {
-		d.log.Debug().Msg("closing read-only database")
-		if err := d.readOnly.Close(); err != nil {
+	d.roMu.Lock()
+	for root, db := range d.readOnly {
+		d.log.Debug().Str("root", root).Msg("closing read-only database")
+		if err := db.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	d.readOnly = make(map[string]*sql.DB)
+	d.roMu.Unlock()
+
+	d.attached = make(map[attachment]string)
+	d.releaseIndexFiles()
 
 	if len(errs) > 0 {
 		return fmt.Errorf("failed to close database(s): %v", errs)
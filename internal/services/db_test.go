@@ -39,8 +39,8 @@ func TestDbService_GetDB_LoadsMarkdownExtension(t *testing.T) {
 	db, err := svc.GetDB(ctx)
 	require.NoError(t, err)
 
-	// Verify markdown extension is loaded by checking for the function
-	rows, err := db.QueryContext(ctx, "SELECT extension_name FROM duckdb_extensions() WHERE extension_name = 'markdown' AND loaded = true")
+	// Verify read_markdown is available, from the extension or the built-in reader
+	rows, err := db.QueryContext(ctx, "SELECT function_name FROM duckdb_functions() WHERE function_name = 'read_markdown'")
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		if err := rows.Close(); err != nil {
@@ -48,8 +48,8 @@ func TestDbService_GetDB_LoadsMarkdownExtension(t *testing.T) {
 		}
 	})
 
-	// Should find the markdown extension
-	assert.True(t, rows.Next(), "markdown extension should be loaded")
+	// Should find the read_markdown function
+	assert.True(t, rows.Next(), "read_markdown should be available")
 }
 
 func TestDbService_GetDB_LazyInit(t *testing.T) {
@@ -101,9 +101,9 @@ func TestDbService_Query_SimpleSQL(t *testing.T) {
 	results, err := svc.Query(ctx, "SELECT 1 as value, 'hello' as message")
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	assert.Equal(t, int32(1), results[0]["value"])
-	assert.Equal(t, "hello", results[0]["message"])
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, int32(1), results.Value(0, "value"))
+	assert.Equal(t, "hello", results.Value(0, "message"))
 }
 
 func TestDbService_Query_ResultMapping(t *testing.T) {
@@ -121,15 +121,15 @@ func TestDbService_Query_ResultMapping(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	require.Len(t, results, 3)
+	require.Len(t, results.Rows, 3)
 
 	// Verify column names and values
-	assert.Equal(t, int32(1), results[0]["id"])
-	assert.Equal(t, "a", results[0]["letter"])
-	assert.Equal(t, int32(2), results[1]["id"])
-	assert.Equal(t, "b", results[1]["letter"])
-	assert.Equal(t, int32(3), results[2]["id"])
-	assert.Equal(t, "c", results[2]["letter"])
+	assert.Equal(t, int32(1), results.Value(0, "id"))
+	assert.Equal(t, "a", results.Value(0, "letter"))
+	assert.Equal(t, int32(2), results.Value(1, "id"))
+	assert.Equal(t, "b", results.Value(1, "letter"))
+	assert.Equal(t, int32(3), results.Value(2, "id"))
+	assert.Equal(t, "c", results.Value(2, "letter"))
 }
 
 func TestDbService_Query_ReadMarkdown(t *testing.T) {
@@ -160,14 +160,14 @@ This is test content.
 	results, err := svc.Query(ctx, "SELECT * FROM read_markdown(?)", mdFile)
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
+	require.Len(t, results.Rows, 1)
 
 	// Verify markdown metadata was extracted (returns duckdb.Map)
-	metadata := results[0]["metadata"]
+	metadata := results.Value(0, "metadata")
 	assert.NotNil(t, metadata)
 
 	// Verify content is present
-	mdContent := results[0]["content"]
+	mdContent := results.Value(0, "content")
 	assert.NotNil(t, mdContent)
 	assert.Contains(t, mdContent, "# Test Note")
 }
@@ -183,7 +183,7 @@ func TestDbService_Query_EmptyResult(t *testing.T) {
 
 	results, err := svc.Query(ctx, "SELECT 1 WHERE 1=0")
 	require.NoError(t, err)
-	assert.Empty(t, results)
+	assert.Empty(t, results.Rows)
 }
 
 func TestDbService_Query_InvalidSQL(t *testing.T) {
@@ -280,10 +280,10 @@ func TestDbService_Query_WithArgs(t *testing.T) {
 	results, err := svc.Query(ctx, "SELECT ? as value, ? as name", 42, "test")
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
+	require.Len(t, results.Rows, 1)
 	// DuckDB returns int64 for integer parameters
-	assert.Equal(t, int64(42), results[0]["value"])
-	assert.Equal(t, "test", results[0]["name"])
+	assert.Equal(t, int64(42), results.Value(0, "value"))
+	assert.Equal(t, "test", results.Value(0, "name"))
 }
 
 // Tests for GetReadOnlyDB
@@ -297,7 +297,7 @@ func TestDbService_GetReadOnlyDB_ReturnsConnection(t *testing.T) {
 		}
 	})
 
-	db, err := svc.GetReadOnlyDB(ctx)
+	db, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 	assert.NotNil(t, db)
 }
@@ -311,11 +311,11 @@ func TestDbService_GetReadOnlyDB_LoadsMarkdownExtension(t *testing.T) {
 		}
 	})
 
-	db, err := svc.GetReadOnlyDB(ctx)
+	db, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
-	// Verify markdown extension is loaded
-	rows, err := db.QueryContext(ctx, "SELECT extension_name FROM duckdb_extensions() WHERE extension_name = 'markdown' AND loaded = true")
+	// Verify read_markdown is available, from the extension or the built-in reader
+	rows, err := db.QueryContext(ctx, "SELECT function_name FROM duckdb_functions() WHERE function_name = 'read_markdown'")
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		if err := rows.Close(); err != nil {
@@ -323,8 +323,8 @@ func TestDbService_GetReadOnlyDB_LoadsMarkdownExtension(t *testing.T) {
 		}
 	})
 
-	// Should find the markdown extension
-	assert.True(t, rows.Next(), "markdown extension should be loaded on read-only connection")
+	// Should find the read_markdown function
+	assert.True(t, rows.Next(), "read_markdown should be available on read-only connection")
 }
 
 func TestDbService_GetReadOnlyDB_LazyInit(t *testing.T) {
@@ -335,14 +335,14 @@ func TestDbService_GetReadOnlyDB_LazyInit(t *testing.T) {
 		}
 	})
 
-	// Before GetReadOnlyDB, readOnly should be nil
-	assert.Nil(t, svc.readOnly)
+	// Before GetReadOnlyDB, no read-only database should be open
+	assert.Empty(t, svc.readOnly)
 
-	// After GetReadOnlyDB, readOnly should be initialized
+	// After GetReadOnlyDB, the root's database should be initialized
 	ctx := context.Background()
-	_, err := svc.GetReadOnlyDB(ctx)
+	_, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
-	assert.NotNil(t, svc.readOnly)
+	assert.Len(t, svc.readOnly, 1)
 }
 
 func TestDbService_GetReadOnlyDB_ReturnsSameConnection(t *testing.T) {
@@ -354,10 +354,10 @@ func TestDbService_GetReadOnlyDB_ReturnsSameConnection(t *testing.T) {
 		}
 	})
 
-	db1, err := svc.GetReadOnlyDB(ctx)
+	db1, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
-	db2, err := svc.GetReadOnlyDB(ctx)
+	db2, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
 	// Should return the same connection
@@ -376,7 +376,7 @@ func TestDbService_GetReadOnlyDB_IsSeparateFromMainDB(t *testing.T) {
 	db, err := svc.GetDB(ctx)
 	require.NoError(t, err)
 
-	roDb, err := svc.GetReadOnlyDB(ctx)
+	roDb, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
 	// Should be different connections
@@ -392,7 +392,7 @@ func TestDbService_GetReadOnlyDB_ExecutesQuery(t *testing.T) {
 		}
 	})
 
-	db, err := svc.GetReadOnlyDB(ctx)
+	db, err := svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
 	// Should be able to execute a simple query
@@ -419,7 +419,7 @@ func TestDbService_Close_BothConnections(t *testing.T) {
 	_, err := svc.GetDB(ctx)
 	require.NoError(t, err)
 
-	_, err = svc.GetReadOnlyDB(ctx)
+	_, err = svc.GetReadOnlyDB(ctx, "")
 	require.NoError(t, err)
 
 	// Close should close both
@@ -446,7 +446,7 @@ func TestDbService_GetReadOnlyDB_ConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			db, err := svc.GetReadOnlyDB(ctx)
+			db, err := svc.GetReadOnlyDB(ctx, "")
 			if err != nil {
 				errs <- err
 				return
@@ -495,7 +495,7 @@ This should be readable from read-only connection.
 	require.NoError(t, err)
 
 	// Query using read-only connection
-	db, err := svc.GetReadOnlyDB(ctx)
+	db, err := svc.GetReadOnlyDB(ctx, tmpDir)
 	require.NoError(t, err)
 
 	rows, err := db.QueryContext(ctx, "SELECT * FROM read_markdown(?)", mdFile)
@@ -582,7 +582,7 @@ func TestDbService_GetReadOnlyDB_CancelledContextOnInit(t *testing.T) {
 	cancel() // Cancel immediately
 
 	// GetReadOnlyDB should return an error
-	db, err := svc.GetReadOnlyDB(ctx)
+	db, err := svc.GetReadOnlyDB(ctx, "")
 
 	// Either error should occur or db should be nil (timing dependent)
 	// Test is checking for graceful handling and no panic
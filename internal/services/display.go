@@ -3,9 +3,11 @@ package services
 import (
 	"bytes"
 	"fmt"
-	"sort"
+	"io"
+	"os"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/glamour"
 )
@@ -54,84 +56,80 @@ func (d *Display) RenderTemplate(tmpl *template.Template, ctx any) (string, erro
 	return rendered, nil
 }
 
-// RenderSQLResults renders SQL query results as an ASCII table.
-// Results is a slice of maps where each map represents a row.
-// Columns are extracted from the first result map and sorted alphabetically.
-func (d *Display) RenderSQLResults(results []map[string]interface{}) error {
+// RenderSQLResults renders SQL query results as an ASCII table on stdout.
+// Columns are shown in SELECT order and nested values are formatted with FormatColumnValue.
+func (d *Display) RenderSQLResults(results *ResultSet) error {
+	return d.RenderSQLResultsTo(os.Stdout, results)
+}
+
+// RenderSQLResultsTo renders SQL query results as an ASCII table to w.
+func (d *Display) RenderSQLResultsTo(w io.Writer, results *ResultSet) error {
 	// Handle empty results
-	if len(results) == 0 {
-		fmt.Println("No results")
-		return nil
+	if results == nil || results.Len() == 0 {
+		_, err := fmt.Fprintln(w, "No results")
+		return err
 	}
 
-	// Extract columns from first result
-	// Use a map to get unique columns, then sort
-	columnSet := make(map[string]bool)
-	for key := range results[0] {
-		columnSet[key] = true
-	}
+	columns := results.ColumnNames()
 
-	// Convert to slice and sort
-	columns := make([]string, 0, len(columnSet))
-	for col := range columnSet {
-		columns = append(columns, col)
+	// Format every cell up front so widths and output agree
+	cells := make([][]string, len(results.Rows))
+	for i, row := range results.Rows {
+		cells[i] = make([]string, len(columns))
+		for j, value := range row {
+			cells[i][j] = FormatColumnValue(value, results.Columns[j].Type)
+		}
 	}
-	sort.Strings(columns)
-
-	// Calculate column widths
-	widths := make(map[string]int)
 
-	// Initialize with column header widths
-	for _, col := range columns {
-		widths[col] = len(col)
+	// Calculate column widths, starting with the header widths
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = utf8.RuneCountInString(col)
 	}
-
-	// Update widths with data widths
-	for _, row := range results {
-		for _, col := range columns {
-			val := fmt.Sprintf("%v", row[col])
-			if len(val) > widths[col] {
-				widths[col] = len(val)
+	for _, row := range cells {
+		for i, val := range row {
+			if n := utf8.RuneCountInString(val); n > widths[i] {
+				widths[i] = n
 			}
 		}
 	}
 
-	// Print header row
-	for i, col := range columns {
-		if i > 0 {
-			fmt.Print("  ")
+	var b strings.Builder
+	printRow := func(values []string) {
+		for i, val := range values {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(val + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(val)))
 		}
-		fmt.Printf("%-*s", widths[col], col)
+		b.WriteString("\n")
 	}
-	fmt.Println()
+
+	// Print header row
+	printRow(columns)
 
 	// Print separator row
-	for i, col := range columns {
-		if i > 0 {
-			fmt.Print("  ")
-		}
-		fmt.Print(strings.Repeat("-", widths[col]))
+	separators := make([]string, len(columns))
+	for i := range columns {
+		separators[i] = strings.Repeat("-", widths[i])
 	}
-	fmt.Println()
+	printRow(separators)
 
 	// Print data rows
-	for _, row := range results {
-		for i, col := range columns {
-			if i > 0 {
-				fmt.Print("  ")
-			}
-			val := fmt.Sprintf("%v", row[col])
-			fmt.Printf("%-*s", widths[col], val)
-		}
-		fmt.Println()
+	for _, row := range cells {
+		printRow(row)
 	}
 
 	// Print summary
-	fmt.Printf("\n%d row", len(results))
-	if len(results) != 1 {
-		fmt.Print("s")
+	fmt.Fprintf(&b, "\n%d row", results.Len())
+	if results.Len() != 1 {
+		b.WriteString("s")
+	}
+	if results.Truncated {
+		b.WriteString(" (truncated at the row limit)")
 	}
-	fmt.Println()
+	b.WriteString("\n")
 
-	return nil
+	_, err := io.WriteString(w, b.String())
+	return err
 }
@@ -278,6 +278,15 @@ func findSubstring(s, substr string) bool {
 
 // Tests for RenderSQLResults
 
+// newResultSet builds a result set with VARCHAR columns.
+func newResultSet(columns []string, rows ...[]any) *ResultSet {
+	results := &ResultSet{Rows: rows}
+	for _, name := range columns {
+		results.Columns = append(results.Columns, Column{Name: name, Type: "VARCHAR"})
+	}
+	return results
+}
+
 // captureOutput captures stdout during function execution
 func captureOutput(f func()) string {
 	r, w, _ := os.Pipe()
@@ -300,7 +309,7 @@ func TestDisplay_RenderSQLResults_EmptyResults(t *testing.T) {
 	}
 
 	output := captureOutput(func() {
-		_ = display.RenderSQLResults([]map[string]interface{}{})
+		_ = display.RenderSQLResults(newResultSet(nil))
 	})
 
 	if !strings.Contains(output, "No results") {
@@ -314,13 +323,9 @@ func TestDisplay_RenderSQLResults_SingleRow(t *testing.T) {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
-	results := []map[string]interface{}{
-		{
-			"name":  "John",
-			"email": "john@example.com",
-			"age":   30,
-		},
-	}
+	results := newResultSet([]string{"name", "email", "age"},
+		[]any{"John", "john@example.com", 30},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -357,11 +362,11 @@ func TestDisplay_RenderSQLResults_MultipleRows(t *testing.T) {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
-	results := []map[string]interface{}{
-		{"id": 1, "name": "Alice"},
-		{"id": 2, "name": "Bob"},
-		{"id": 3, "name": "Charlie"},
-	}
+	results := newResultSet([]string{"id", "name"},
+		[]any{1, "Alice"},
+		[]any{2, "Bob"},
+		[]any{3, "Charlie"},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -398,10 +403,10 @@ func TestDisplay_RenderSQLResults_ColumnAlignment(t *testing.T) {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
-	results := []map[string]interface{}{
-		{"short": "a", "verylongname": "value1"},
-		{"short": "abcdef", "verylongname": "v2"},
-	}
+	results := newResultSet([]string{"short", "verylongname"},
+		[]any{"a", "value1"},
+		[]any{"abcdef", "v2"},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -437,14 +442,9 @@ func TestDisplay_RenderSQLResults_DifferentTypes(t *testing.T) {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
-	results := []map[string]interface{}{
-		{
-			"string_col": "text",
-			"int_col":    42,
-			"float_col":  3.14,
-			"bool_col":   true,
-		},
-	}
+	results := newResultSet([]string{"string_col", "int_col", "float_col", "bool_col"},
+		[]any{"text", 42, 3.14, true},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -465,16 +465,16 @@ func TestDisplay_RenderSQLResults_DifferentTypes(t *testing.T) {
 	}
 }
 
-func TestDisplay_RenderSQLResults_ColumnSorting(t *testing.T) {
+func TestDisplay_RenderSQLResults_ColumnOrder(t *testing.T) {
 	display, err := NewDisplay()
 	if err != nil {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
 	// Create results with columns in non-alphabetical order
-	results := []map[string]interface{}{
-		{"zebra": 1, "apple": 2, "middle": 3},
-	}
+	results := newResultSet([]string{"zebra", "apple", "middle"},
+		[]any{1, 2, 3},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -488,14 +488,14 @@ func TestDisplay_RenderSQLResults_ColumnSorting(t *testing.T) {
 	middlePos := strings.Index(headerLine, "middle")
 	zebraPos := strings.Index(headerLine, "zebra")
 
-	// Columns should be in alphabetical order
+	// Columns should stay in SELECT order
 	if applePos == -1 || middlePos == -1 || zebraPos == -1 {
 		t.Fatal("RenderSQLResults() missing expected columns")
 	}
 
-	if !(applePos < middlePos && middlePos < zebraPos) {
-		t.Errorf("RenderSQLResults() columns not sorted: apple@%d, middle@%d, zebra@%d",
-			applePos, middlePos, zebraPos)
+	if !(zebraPos < applePos && applePos < middlePos) {
+		t.Errorf("RenderSQLResults() columns reordered: zebra@%d, apple@%d, middle@%d",
+			zebraPos, applePos, middlePos)
 	}
 }
 
@@ -505,9 +505,9 @@ func TestDisplay_RenderSQLResults_NilValues(t *testing.T) {
 		t.Fatalf("NewDisplay() failed: %v", err)
 	}
 
-	results := []map[string]interface{}{
-		{"col1": "value", "col2": nil},
-	}
+	results := newResultSet([]string{"col1", "col2"},
+		[]any{"value", nil},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
@@ -520,6 +520,9 @@ func TestDisplay_RenderSQLResults_NilValues(t *testing.T) {
 	if !strings.Contains(output, "col2") {
 		t.Error("RenderSQLResults() missing column with nil value")
 	}
+	if !strings.Contains(output, "NULL") {
+		t.Error("RenderSQLResults() should show nil values as NULL")
+	}
 }
 
 func TestDisplay_RenderSQLResults_LargeValues(t *testing.T) {
@@ -529,9 +532,9 @@ func TestDisplay_RenderSQLResults_LargeValues(t *testing.T) {
 	}
 
 	longString := strings.Repeat("x", 100)
-	results := []map[string]interface{}{
-		{"short": "s", "long": longString},
-	}
+	results := newResultSet([]string{"short", "long"},
+		[]any{"s", longString},
+	)
 
 	output := captureOutput(func() {
 		_ = display.RenderSQLResults(results)
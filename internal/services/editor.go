@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// DefaultEditor is used when no editor is configured and neither $VISUAL
+// nor $EDITOR is set.
+const DefaultEditor = "vi"
+
+// UpdatedField is the frontmatter field set to the time a note was last edited.
+const UpdatedField = "updated"
+
+// EditorCommand returns the editor command line: the configured editor,
+// else $VISUAL, else $EDITOR, else DefaultEditor. Arguments are split on
+// whitespace, so "code --wait" works.
+func EditorCommand(configured string) []string {
+	for _, editor := range []string{configured, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(editor); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{DefaultEditor}
+}
+
+// EditFile opens path in editor, attached to the terminal, and waits for it
+// to exit. If the file changed, FinishEdit is applied to it with schema.
+// Reports whether the file changed.
+func EditFile(ctx context.Context, editor []string, path string, schema core.FrontmatterSchema) (bool, error) {
+	log := Log("Editor")
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note: %w", err)
+	}
+
+	args := append(append([]string{}, editor[1:]...), path)
+	cmd := exec.CommandContext(ctx, editor[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Debug().Strs("editor", editor).Str("path", path).Msg("opening editor")
+	if err := cmd.Run(); err != nil {
+		return false, fmt.Errorf("editor %s failed: %w", editor[0], err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note: %w", err)
+	}
+	if bytes.Equal(before, after) {
+		log.Debug().Str("path", path).Msg("note unchanged")
+		return false, nil
+	}
+
+	content, err := FinishEdit(string(after), schema, time.Now())
+	if err != nil {
+		return true, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, fmt.Errorf("failed to stat note: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		return true, fmt.Errorf("failed to update note: %w", err)
+	}
+	return true, nil
+}
+
+// FinishEdit validates the frontmatter of edited content against schema, as
+// ValidateNote does, and sets its updated field to now. Invalid frontmatter
+// is an error, and the content is left for the user to fix.
+func FinishEdit(content string, schema core.FrontmatterSchema, now time.Time) (string, error) {
+	frontmatter, body, err := core.ReadFrontmatter(content)
+	if err != nil {
+		return "", err
+	}
+	errs, err := ValidateNote(content, schema)
+	if err != nil {
+		return "", err
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("invalid frontmatter:\n%s", errs.PrettyPrint())
+	}
+
+	if err := frontmatter.Set(UpdatedField, now.Truncate(time.Second)); err != nil {
+		return "", err
+	}
+	return frontmatter.Render(body)
+}
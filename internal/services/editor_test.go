@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{DefaultEditor}, EditorCommand(""))
+
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, []string{"nano"}, EditorCommand(""))
+
+	t.Setenv("VISUAL", "code --wait")
+	assert.Equal(t, []string{"code", "--wait"}, EditorCommand(""))
+
+	assert.Equal(t, []string{"hx"}, EditorCommand(" hx "))
+}
+
+func TestFinishEdit(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	content, err := FinishEdit("---\ntitle: Hello\n---\n# Hello\n", nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Hello\nupdated: 2025-06-01T10:30:00Z\n---\n# Hello\n", content)
+
+	_, err = FinishEdit("---\ntitle: [unclosed\n---\n", nil, now)
+	assert.ErrorContains(t, err, "invalid frontmatter")
+
+	_, err = FinishEdit("---\ncreated: someday\n---\n", nil, now)
+	assert.ErrorContains(t, err, "created")
+
+	// The notebook schema applies as it does to notes add and meta set
+	schema := core.FrontmatterSchema{
+		"status": {Type: core.FieldEnum, Values: []string{"todo", "done"}, Required: true},
+	}
+	_, err = FinishEdit("---\nstatus: someday\n---\n", schema, now)
+	assert.ErrorContains(t, err, "status")
+	_, err = FinishEdit("---\ntitle: Hello\n---\n", schema, now)
+	assert.ErrorContains(t, err, "status")
+	_, err = FinishEdit("---\nstatus: done\n---\n", schema, now)
+	assert.NoError(t, err)
+}
+
+func TestEditFile_SetsUpdated(t *testing.T) {
+	path := testutil.WriteNote(t, t.TempDir(), "note.md", "---\ntitle: Hello\n---\n# Hello\n")
+	editor := []string{"sh", "-c", `echo "More text" >> "$1"`, "editor"}
+
+	changed, err := EditFile(context.Background(), editor, path, nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	metadata, body, err := core.ParseFrontmatter(string(data))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", metadata["title"])
+	assert.IsType(t, time.Time{}, metadata[UpdatedField])
+	assert.Equal(t, "# Hello\nMore text\n", body)
+}
+
+func TestEditFile_Unchanged(t *testing.T) {
+	original := "---\ntitle: Hello\n---\n# Hello\n"
+	path := testutil.WriteNote(t, t.TempDir(), "note.md", original)
+
+	changed, err := EditFile(context.Background(), []string{"true"}, path, nil)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+}
+
+func TestEditFile_InvalidFrontmatterKeepsEdit(t *testing.T) {
+	path := testutil.WriteNote(t, t.TempDir(), "note.md", "---\ntitle: Hello\n---\n# Hello\n")
+	editor := []string{"sh", "-c", `printf -- "---\ntitle: [oops\n---\n" > "$1"`, "editor"}
+
+	changed, err := EditFile(context.Background(), editor, path, nil)
+	assert.True(t, changed)
+	assert.ErrorContains(t, err, "invalid frontmatter")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: [oops\n---\n", string(data))
+}
+
+func TestEditFile_EditorFails(t *testing.T) {
+	path := testutil.WriteNote(t, t.TempDir(), "note.md", "# Hello\n")
+
+	_, err := EditFile(context.Background(), []string{"false"}, path, nil)
+	assert.ErrorContains(t, err, "editor false failed")
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// filterDateLayouts are the date formats understood by search filters,
+// most specific first.
+var filterDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// dateOnlyLayout is the layout of a filter value naming a whole day.
+const dateOnlyLayout = "2006-01-02"
+
+// filterNotes keeps the notes matching every filter in query and containing
+// none of its excluded words.
+func filterNotes(notes []Note, query core.SearchQuery) []Note {
+	if len(query.Filters) == 0 && len(query.Exclude) == 0 {
+		return notes
+	}
+
+	var matched []Note
+	for i := range notes {
+		if matchesFilters(&notes[i], query) {
+			matched = append(matched, notes[i])
+		}
+	}
+	return matched
+}
+
+func matchesFilters(note *Note, query core.SearchQuery) bool {
+	for _, filter := range query.Filters {
+		if matchesField(note, filter) == filter.Negate {
+			return false
+		}
+	}
+
+	if len(query.Exclude) > 0 {
+		doc := newSearchDocument(note)
+		for _, phrase := range query.Exclude {
+			if doc.containsPhrase(phrase) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// matchesField reports whether note satisfies filter, ignoring Negate.
+//
+// The path, title and content fields are built in; any other field is looked
+// up in the note's frontmatter. "tag" and "tags" match the note's tags.
+func matchesField(note *Note, filter core.FieldFilter) bool {
+	switch filter.Field {
+	case "path":
+		if filter.Op == core.FieldOpMatch {
+			return matchPath(filter.Value, note.File.Relative)
+		}
+		return compareValue(note.File.Relative, filter)
+	case "title":
+		if filter.Op == core.FieldOpMatch {
+			return containsFold(note.DisplayName(), filter.Value)
+		}
+		return compareValue(note.DisplayName(), filter)
+	case "content":
+		return containsFold(note.Content, filter.Value)
+	}
+
+	if filter.Field == "tag" || filter.Field == core.TagsField {
+		if matchesTag(note.Tags, filter) {
+			return true
+		}
+	}
+
+	value, ok := metadataField(note.Metadata, filter.Field)
+	if !ok {
+		return false
+	}
+
+	// Lists match if any element does
+	if values, isList := value.([]any); isList {
+		for _, v := range values {
+			if compareValue(MetadataValueString(v), filter) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return compareValue(MetadataValueString(value), filter)
+}
+
+// matchesTag reports whether one of tags, or a tag above it, matches filter.
+// So "project" matches notes tagged project/alpha.
+func matchesTag(tags []string, filter core.FieldFilter) bool {
+	value := filter
+	value.Value = core.NormalizeTag(filter.Value)
+	for _, tag := range tags {
+		for _, level := range core.TagLevels(tag) {
+			if compareValue(level, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// metadataField looks up a frontmatter key, ignoring case.
+func metadataField(metadata map[string]any, field string) (any, bool) {
+	if value, ok := metadata[field]; ok {
+		return value, true
+	}
+	for key, value := range metadata {
+		if strings.EqualFold(key, field) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// matchPath reports whether relative, or one of its parent directories,
+// matches pattern. So "projects" and "projects/*" both match notes anywhere
+// under projects/.
+func matchPath(pattern, relative string) bool {
+	pattern = strings.Trim(pattern, "/")
+	segments := strings.Split(relative, "/")
+	for i := len(segments); i > 0; i-- {
+		if core.MatchGlob(pattern, strings.Join(segments[:i], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValue applies filter's comparison to a field value.
+// Dates and numbers compare by value, everything else case-insensitively.
+// A date-only filter value ("2025-01-01") compares against the whole day.
+func compareValue(value string, filter core.FieldFilter) bool {
+	cmp, ok := compareDates(value, filter.Value)
+	if !ok {
+		cmp, ok = compareNumbers(value, filter.Value)
+	}
+	if !ok {
+		if filter.Op == core.FieldOpMatch {
+			return matchFold(filter.Value, value)
+		}
+		cmp = strings.Compare(strings.ToLower(value), strings.ToLower(filter.Value))
+	}
+
+	switch filter.Op {
+	case core.FieldOpGreater:
+		return cmp > 0
+	case core.FieldOpGreaterEqual:
+		return cmp >= 0
+	case core.FieldOpLess:
+		return cmp < 0
+	case core.FieldOpLessEqual:
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+func compareDates(value, target string) (int, bool) {
+	v, ok := parseFilterDate(value)
+	if !ok {
+		return 0, false
+	}
+	t, ok := parseFilterDate(target)
+	if !ok {
+		return 0, false
+	}
+
+	if _, err := time.Parse(dateOnlyLayout, target); err == nil {
+		y, m, d := v.Date()
+		v = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return v.Compare(t), true
+}
+
+func parseFilterDate(s string) (time.Time, bool) {
+	for _, layout := range filterDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func compareNumbers(value, target string) (int, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	t, err := strconv.ParseFloat(target, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch {
+	case v < t:
+		return -1, true
+	case v > t:
+		return 1, true
+	}
+	return 0, true
+}
+
+// matchFold reports whether value equals pattern ignoring case.
+// Patterns containing glob characters ("in-*") are matched as globs.
+func matchFold(pattern, value string) bool {
+	if core.HasGlobMeta(pattern) {
+		ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+		return err == nil && ok
+	}
+	return strings.EqualFold(pattern, value)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
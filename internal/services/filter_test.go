@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func filterNotesFor(t *testing.T, notes []Note, query string) []string {
+	t.Helper()
+	parsed, err := core.ParseSearchQuery(query)
+	require.NoError(t, err)
+	return relatives(filterNotes(notes, parsed))
+}
+
+func TestFilterNotes(t *testing.T) {
+	plan := newSearchNote("projects/launch/plan.md", "Release Plan", "Ship it. Draft for review.")
+	plan.Metadata["tags"] = []any{"work", "launch"}
+	plan.Metadata["status"] = "open"
+	plan.Metadata["created"] = "2025-03-10"
+	plan.Metadata["priority"] = float64(2)
+	plan.Tags = []string{"launch", "project/alpha", "work"}
+
+	retro := newSearchNote("projects/retro.md", "Retro", "What went well.")
+	retro.Metadata["tags"] = []any{"work"}
+	retro.Metadata["status"] = "done"
+	retro.Metadata["created"] = "2024-12-01T09:30:00Z"
+	retro.Metadata["priority"] = float64(10)
+	retro.Tags = []string{"project/beta", "work"}
+
+	journal := newSearchNote("journal/2025-01-01.md", "", "Personal things, on hold.")
+	journal.Metadata["tag"] = "Personal"
+	journal.Metadata["created"] = "2025-01-01T18:00:00Z"
+
+	notes := []Note{plan, retro, journal}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "tag in list", query: "tag:work", expected: []string{"projects/launch/plan.md", "projects/retro.md"}},
+		{name: "tag ignores case", query: "tag:personal", expected: []string{"journal/2025-01-01.md"}},
+		{name: "tags field name", query: "tags:launch", expected: []string{"projects/launch/plan.md"}},
+		{name: "tag hierarchy", query: "tag:project", expected: []string{"projects/launch/plan.md", "projects/retro.md"}},
+		{name: "nested tag", query: "tag:#Project/Alpha", expected: []string{"projects/launch/plan.md"}},
+		{name: "tag glob", query: "tag:project/b*", expected: []string{"projects/retro.md"}},
+		{name: "tag prefix isn't a parent", query: "tag:proj", expected: []string{}},
+		{name: "negated field", query: "-status:done", expected: []string{"projects/launch/plan.md", "journal/2025-01-01.md"}},
+		{name: "title substring", query: `title:"release plan"`, expected: []string{"projects/launch/plan.md"}},
+		{name: "title from filename", query: "title:2025", expected: []string{"journal/2025-01-01.md"}},
+		{name: "date after", query: "created:>2025-01-01", expected: []string{"projects/launch/plan.md"}},
+		{name: "date on or after", query: "created:>=2025-01-01", expected: []string{"projects/launch/plan.md", "journal/2025-01-01.md"}},
+		{name: "date before", query: "created:<2025-01-01", expected: []string{"projects/retro.md"}},
+		{name: "date equals whole day", query: "created:2025-01-01", expected: []string{"journal/2025-01-01.md"}},
+		{name: "numbers compare by value", query: "priority:>5", expected: []string{"projects/retro.md"}},
+		{name: "path glob", query: "path:projects/*", expected: []string{"projects/launch/plan.md", "projects/retro.md"}},
+		{name: "path directory", query: "path:projects/launch", expected: []string{"projects/launch/plan.md"}},
+		{name: "path file glob", query: "path:*.md", expected: []string{}},
+		{name: "path recursive glob", query: "path:**/plan.md", expected: []string{"projects/launch/plan.md"}},
+		{name: "value glob", query: "status:d*", expected: []string{"projects/retro.md"}},
+		{name: "field present", query: "status:*", expected: []string{"projects/launch/plan.md", "projects/retro.md"}},
+		{name: "missing field", query: "owner:me", expected: []string{}},
+		{name: "content", query: `content:"went well"`, expected: []string{"projects/retro.md"}},
+		{name: "excluded word", query: "-draft", expected: []string{"projects/retro.md", "journal/2025-01-01.md"}},
+		{name: "excluded phrase", query: `-"on hold"`, expected: []string{"projects/launch/plan.md", "projects/retro.md"}},
+		{name: "combined", query: "tag:work -status:done created:>2025-01-01 path:projects/*", expected: []string{"projects/launch/plan.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, filterNotesFor(t, notes, tt.query))
+		})
+	}
+}
+
+func TestNoteService_SearchNotes_Filters(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "projects/plan.md", "---\ntags: [work]\nstatus: open\ncreated: 2025-02-01\n---\n# Plan\n\nDeploy the release.\n")
+	testutil.WriteNote(t, root, "projects/done.md", "---\ntags: [work]\nstatus: done\ncreated: 2025-02-01\n---\n# Done\n\nDeploy finished.\n")
+	testutil.WriteNote(t, root, "home.md", "---\ntags: [home]\n---\n# Home\n\nDeploy the shelves.\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "tag:work -status:done created:>2025-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects/plan.md"}, relatives(notes))
+	assert.Empty(t, notes[0].Snippet, "filter-only searches aren't ranked")
+
+	notes, err = NewNoteService(nil, svc, root).SearchNotes(ctx, "path:projects deploy")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"projects/plan.md", "projects/done.md"}, relatives(notes))
+	assert.Contains(t, notes[0].Snippet, "**Deploy**")
+}
+
+func TestNoteService_SearchNotes_InvalidQuery(t *testing.T) {
+	svc := newTestDbService(t, DbOptions{})
+
+	_, err := NewNoteService(nil, svc, t.TempDir()).SearchNotes(context.Background(), "created:>")
+	assert.ErrorContains(t, err, "invalid search query")
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// scalarFunc is a Go scalar function registered with DuckDB.
+type scalarFunc struct {
+	config duckdb.ScalarFuncConfig
+	row    duckdb.RowExecutorFn
+}
+
+func (f *scalarFunc) Config() duckdb.ScalarFuncConfig {
+	return f.config
+}
+
+func (f *scalarFunc) Executor() duckdb.ScalarFuncExecutor {
+	return duckdb.ScalarFuncExecutor{RowExecutor: f.row}
+}
+
+// structField is a named STRUCT member for structListType.
+type structField struct {
+	name string
+	typ  duckdb.Type
+}
+
+// structListType builds a LIST(STRUCT(...)) type.
+func structListType(fields ...structField) (duckdb.TypeInfo, error) {
+	entries := make([]duckdb.StructEntry, len(fields))
+	for i, field := range fields {
+		info, err := duckdb.NewTypeInfo(field.typ)
+		if err != nil {
+			return nil, err
+		}
+		entries[i], err = duckdb.NewStructEntry(info, field.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	structInfo, err := duckdb.NewStructInfo(entries[0], entries[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	return duckdb.NewListInfo(structInfo)
+}
+
+// markdownExtractors are Go versions of the markdown extension's md_extract_*
+// functions, plus md_extract_tasks which the extension lacks. Each takes the
+// markdown content and returns a LIST of STRUCTs.
+var markdownExtractors = []struct {
+	name    string
+	fields  []structField
+	extract func(core.MarkdownElements) []any
+}{
+	{
+		name: "md_extract_links",
+		fields: []structField{
+			{"text", duckdb.TYPE_VARCHAR}, {"url", duckdb.TYPE_VARCHAR}, {"line_number", duckdb.TYPE_BIGINT},
+		},
+		extract: func(e core.MarkdownElements) []any {
+			items := make([]any, len(e.Links))
+			for i, link := range e.Links {
+				items[i] = map[string]any{"text": link.Text, "url": link.URL, "line_number": int64(link.Line)}
+			}
+			return items
+		},
+	},
+	{
+		name: "md_extract_headers",
+		fields: []structField{
+			{"level", duckdb.TYPE_INTEGER}, {"text", duckdb.TYPE_VARCHAR}, {"line_number", duckdb.TYPE_BIGINT},
+		},
+		extract: func(e core.MarkdownElements) []any {
+			items := make([]any, len(e.Headings))
+			for i, heading := range e.Headings {
+				items[i] = map[string]any{"level": int32(heading.Level), "text": heading.Text, "line_number": int64(heading.Line)}
+			}
+			return items
+		},
+	},
+	{
+		name: "md_extract_code_blocks",
+		fields: []structField{
+			{"language", duckdb.TYPE_VARCHAR}, {"code", duckdb.TYPE_VARCHAR}, {"line_number", duckdb.TYPE_BIGINT},
+		},
+		extract: func(e core.MarkdownElements) []any {
+			items := make([]any, len(e.CodeBlocks))
+			for i, block := range e.CodeBlocks {
+				items[i] = map[string]any{"language": block.Language, "code": block.Code, "line_number": int64(block.Line)}
+			}
+			return items
+		},
+	},
+	{
+		name: "md_extract_tasks",
+		fields: []structField{
+			{"done", duckdb.TYPE_BOOLEAN}, {"text", duckdb.TYPE_VARCHAR}, {"line_number", duckdb.TYPE_BIGINT},
+		},
+		extract: func(e core.MarkdownElements) []any {
+			items := make([]any, len(e.Tasks))
+			for i, task := range e.Tasks {
+				items[i] = map[string]any{"done": task.Done, "text": task.Text, "line_number": int64(task.Line)}
+			}
+			return items
+		},
+	},
+}
+
+// registerSQLFunctions registers the helper functions the notebook views use,
+// and Go fallbacks for any md_extract_* function the markdown extension
+// didn't provide. Functions that already exist are left alone.
+func registerSQLFunctions(ctx context.Context, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log := Log("MarkdownReader")
+			log.Warn().Err(err).Msg("failed to close registration connection")
+		}
+	}()
+
+	varchar, err := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	if err != nil {
+		return err
+	}
+	boolean, err := duckdb.NewTypeInfo(duckdb.TYPE_BOOLEAN)
+	if err != nil {
+		return err
+	}
+
+	tags, err := duckdb.NewListInfo(varchar)
+	if err != nil {
+		return err
+	}
+
+	functions := map[string]*scalarFunc{
+		// opennotes_slugify(text) matches the filename fallback of Note.DisplayName
+		"opennotes_slugify": {
+			config: duckdb.ScalarFuncConfig{
+				InputTypeInfos: []duckdb.TypeInfo{varchar},
+				ResultTypeInfo: varchar,
+			},
+			row: func(values []driver.Value) (any, error) {
+				text, _ := values[0].(string)
+				return core.Slugify(text), nil
+			},
+		},
+		// opennotes_match_glob(pattern, path) uses the same glob rules as note filters
+		"opennotes_match_glob": {
+			config: duckdb.ScalarFuncConfig{
+				InputTypeInfos: []duckdb.TypeInfo{varchar, varchar},
+				ResultTypeInfo: boolean,
+			},
+			row: func(values []driver.Value) (any, error) {
+				pattern, _ := values[0].(string)
+				path, _ := values[1].(string)
+				return core.MatchGlob(pattern, path), nil
+			},
+		},
+		// opennotes_note_tags(tags, content) matches Note.Tags, from the
+		// metadata column's tags value and the note's content
+		"opennotes_note_tags": {
+			config: duckdb.ScalarFuncConfig{
+				InputTypeInfos:      []duckdb.TypeInfo{varchar, varchar},
+				ResultTypeInfo:      tags,
+				SpecialNullHandling: true,
+			},
+			row: func(values []driver.Value) (any, error) {
+				field, _ := values[0].(string)
+				content, _ := values[1].(string)
+
+				// Lists are JSON encoded in the metadata column
+				var value any = field
+				var list []any
+				if strings.HasPrefix(field, "[") && json.Unmarshal([]byte(field), &list) == nil {
+					value = list
+				}
+
+				result := []any{}
+				for _, tag := range core.NoteTags(map[string]any{core.TagsField: value}, content) {
+					result = append(result, tag)
+				}
+				return result, nil
+			},
+		},
+	}
+
+	for _, extractor := range markdownExtractors {
+		listType, err := structListType(extractor.fields...)
+		if err != nil {
+			return err
+		}
+		extract := extractor.extract
+		functions[extractor.name] = &scalarFunc{
+			config: duckdb.ScalarFuncConfig{
+				InputTypeInfos: []duckdb.TypeInfo{varchar},
+				ResultTypeInfo: listType,
+			},
+			row: func(values []driver.Value) (any, error) {
+				content, _ := values[0].(string)
+				return extract(core.ExtractMarkdown(content)), nil
+			},
+		}
+	}
+
+	for name, fn := range functions {
+		// Keep the extension's own md_extract_* functions
+		exists, err := functionExists(ctx, conn, name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := duckdb.RegisterScalarUDF(conn, name, fn); err != nil {
+			return fmt.Errorf("failed to register %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// functionExists reports whether a function called name is already defined.
+func functionExists(ctx context.Context, conn *sql.Conn, name string) (bool, error) {
+	var count int
+	row := conn.QueryRowContext(ctx, "SELECT count(*) FROM duckdb_functions() WHERE function_name = ?", name)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterSQLFunctions(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	require.NoError(t, registerSQLFunctions(ctx, db))
+
+	tests := []struct {
+		query    string
+		expected any
+	}{
+		{"SELECT opennotes_slugify('Meeting Notes')", "meeting-notes"},
+		{"SELECT opennotes_match_glob('**/*.md', 'a/b.md')", true},
+		{"SELECT opennotes_match_glob('*.md', 'a/b.md')", false},
+		{"SELECT to_json(md_extract_links('[a](b.md)'))::VARCHAR", `[{"text":"a","url":"b.md","line_number":1}]`},
+		{"SELECT to_json(md_extract_headers('## Hi'))::VARCHAR", `[{"level":2,"text":"Hi","line_number":1}]`},
+		{"SELECT to_json(md_extract_code_blocks('    x'))::VARCHAR", `[{"language":"","code":"x\n","line_number":1}]`},
+		{"SELECT to_json(md_extract_tasks('- [x] done'))::VARCHAR", `[{"done":true,"text":"done","line_number":1}]`},
+		{"SELECT len(md_extract_links('no links'))", int64(0)},
+		{`SELECT to_json(opennotes_note_tags('["Work","project/alpha"]', 'About #home'))::VARCHAR`, `["home","project/alpha","work"]`},
+		{"SELECT to_json(opennotes_note_tags('work, home', NULL))::VARCHAR", `["home","work"]`},
+		{"SELECT len(opennotes_note_tags(NULL, 'No tags'))", int64(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got any
+			require.NoError(t, db.QueryRowContext(ctx, tt.query).Scan(&got))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRegisterSQLFunctions_Twice(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	require.NoError(t, registerSQLFunctions(ctx, db))
+	assert.NoError(t, registerSQLFunctions(ctx, db))
+}
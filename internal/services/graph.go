@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// GraphFormat is how a link graph is written.
+type GraphFormat string
+
+// Graph formats.
+const (
+	GraphDOT     GraphFormat = "dot"
+	GraphMermaid GraphFormat = "mermaid"
+	GraphML      GraphFormat = "graphml"
+	GraphJSON    GraphFormat = "json"
+)
+
+// GraphFormats lists every supported graph format.
+var GraphFormats = []GraphFormat{GraphDOT, GraphMermaid, GraphML, GraphJSON}
+
+// ParseGraphFormat validates a graph format name. An empty name means GraphDOT.
+func ParseGraphFormat(name string) (GraphFormat, error) {
+	if name == "" {
+		return GraphDOT, nil
+	}
+	for _, format := range GraphFormats {
+		if string(format) == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(GraphFormats))
+	for i, format := range GraphFormats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown graph format %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// GraphOptions selects the notes in a link graph. Zero values select every note.
+type GraphOptions struct {
+	// Group keeps notes in the named notebook group
+	Group string
+	// Tag keeps notes with a matching tag (globs allowed), as the tag: search filter
+	Tag string
+	// Where is a SQL predicate on the notes view, e.g. "relative LIKE 'adr/%'"
+	Where string
+	// From is the relative path of a note to centre the graph on
+	From string
+	// Depth is how many links away from From notes are kept
+	Depth int
+}
+
+// GraphNode is a note in a link graph.
+type GraphNode struct {
+	// ID is the note's relative path
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Group string `json:"group,omitempty"`
+}
+
+// GraphEdge is one or more links from one note to another.
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Links is the number of links from Source to Target
+	Links int `json:"links"`
+}
+
+// Graph is the notes of a notebook and the links between them.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Graph returns the link graph of the notes selected by opts. Links to
+// missing notes, links from a note to itself and links to notes that aren't
+// selected are left out. Nodes are ordered by path and edges by source and
+// target.
+func (s *NoteService) Graph(ctx context.Context, opts GraphOptions) (*Graph, error) {
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var group *NotebookGroup
+	if opts.Group != "" {
+		if group, err = findGroup(s.groups, opts.Group); err != nil {
+			return nil, err
+		}
+	}
+
+	var where map[string]bool
+	if strings.TrimSpace(opts.Where) != "" {
+		relatives, err := s.SelectNotes(ctx, opts.Where)
+		if err != nil {
+			return nil, err
+		}
+		where = make(map[string]bool, len(relatives))
+		for _, relative := range relatives {
+			where[relative] = true
+		}
+	}
+
+	var tag core.FieldFilter
+	if opts.Tag != "" {
+		tag = core.FieldFilter{Field: "tag", Op: core.FieldOpMatch, Value: opts.Tag}
+	}
+
+	selected := make(map[string]GraphNode, len(notes))
+	for i := range notes {
+		note := &notes[i]
+		keep := (group == nil || group.Matches(note.File.Relative)) &&
+			(where == nil || where[note.File.Relative]) &&
+			(opts.Tag == "" || matchesField(note, tag))
+		if !keep && note.File.Relative != opts.From {
+			continue
+		}
+		selected[note.File.Relative] = GraphNode{ID: note.File.Relative, Title: note.DisplayName(), Group: nodeGroup(note, group)}
+	}
+	if opts.From != "" {
+		if _, ok := selected[opts.From]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoteNotFound, opts.From)
+		}
+	}
+
+	links, err := s.queryLinks(ctx, "target IS NOT NULL AND source <> target ORDER BY source, target")
+	if err != nil {
+		return nil, err
+	}
+
+	var edges []GraphEdge
+	for _, link := range links {
+		_, source := selected[link.Source]
+		_, target := selected[link.Target]
+		if !source || !target {
+			continue
+		}
+		if last := len(edges) - 1; last >= 0 && edges[last].Source == link.Source && edges[last].Target == link.Target {
+			edges[last].Links++
+			continue
+		}
+		edges = append(edges, GraphEdge{Source: link.Source, Target: link.Target, Links: 1})
+	}
+
+	if opts.From != "" {
+		near := neighbourhood(opts.From, opts.Depth, edges)
+		for id := range selected {
+			if !near[id] {
+				delete(selected, id)
+			}
+		}
+		kept := edges[:0]
+		for _, edge := range edges {
+			if near[edge.Source] && near[edge.Target] {
+				kept = append(kept, edge)
+			}
+		}
+		edges = kept
+	}
+
+	graph := &Graph{Nodes: make([]GraphNode, 0, len(selected)), Edges: []GraphEdge{}}
+	for _, node := range selected {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	graph.Edges = append(graph.Edges, edges...)
+	return graph, nil
+}
+
+// nodeGroup returns the group a note is shown in: the group the graph is
+// filtered on, else the first of the note's groups.
+func nodeGroup(note *Note, filter *NotebookGroup) string {
+	if filter != nil && filter.Matches(note.File.Relative) {
+		return filter.Name
+	}
+	if len(note.Groups) > 0 {
+		return note.Groups[0]
+	}
+	return ""
+}
+
+// neighbourhood returns the notes at most depth links from start, following
+// links in either direction.
+func neighbourhood(start string, depth int, edges []GraphEdge) map[string]bool {
+	adjacent := make(map[string][]string)
+	for _, edge := range edges {
+		adjacent[edge.Source] = append(adjacent[edge.Source], edge.Target)
+		adjacent[edge.Target] = append(adjacent[edge.Target], edge.Source)
+	}
+
+	near := map[string]bool{start: true}
+	frontier := []string{start}
+	for i := 0; i < depth && len(frontier) > 0; i++ {
+		var next []string
+		for _, id := range frontier {
+			for _, other := range adjacent[id] {
+				if !near[other] {
+					near[other] = true
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	return near
+}
+
+// WriteGraph writes graph in format.
+func WriteGraph(w io.Writer, format GraphFormat, graph *Graph) error {
+	switch format {
+	case GraphDOT:
+		return writeDOT(w, graph)
+	case GraphMermaid:
+		return writeMermaid(w, graph)
+	case GraphML:
+		return writeGraphML(w, graph)
+	case GraphJSON:
+		return WriteOutput(w, OutputJSON, graph)
+	}
+	return fmt.Errorf("unknown graph format %q", format)
+}
+
+// nodesByGroup returns the groups of graph's nodes in order of first
+// appearance, with ungrouped nodes under "".
+func nodesByGroup(graph *Graph) ([]string, map[string][]GraphNode) {
+	var names []string
+	groups := make(map[string][]GraphNode)
+	for _, node := range graph.Nodes {
+		if _, ok := groups[node.Group]; !ok {
+			names = append(names, node.Group)
+		}
+		groups[node.Group] = append(groups[node.Group], node)
+	}
+	return names, groups
+}
+
+// writeDOT writes graph for Graphviz, with each group as a cluster.
+func writeDOT(w io.Writer, graph *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph notebook {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	names, groups := nodesByGroup(graph)
+	for i, name := range names {
+		indent := "\t"
+		if name != "" {
+			fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, dotQuote(name))
+			indent = "\t\t"
+		}
+		for _, node := range groups[name] {
+			fmt.Fprintf(&b, "%s%s [label=%s];\n", indent, dotQuote(node.ID), dotQuote(node.Title))
+		}
+		if name != "" {
+			b.WriteString("\t}\n")
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s", dotQuote(edge.Source), dotQuote(edge.Target))
+		if edge.Links > 1 {
+			fmt.Fprintf(&b, " [penwidth=%d]", min(edge.Links, 5))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeMermaid writes graph as a Mermaid flowchart, with each group as a
+// subgraph. Nodes get generated IDs since Mermaid IDs can't hold paths.
+func writeMermaid(w io.Writer, graph *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i+1)
+	}
+
+	names, groups := nodesByGroup(graph)
+	for i, name := range names {
+		indent := "    "
+		if name != "" {
+			fmt.Fprintf(&b, "    subgraph g%d[%s]\n", i+1, mermaidQuote(name))
+			indent = "        "
+		}
+		for _, node := range groups[name] {
+			fmt.Fprintf(&b, "%s%s[%s]\n", indent, ids[node.ID], mermaidQuote(node.Title))
+		}
+		if name != "" {
+			b.WriteString("    end\n")
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "    %s --> %s\n", ids[edge.Source], ids[edge.Target])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidQuote returns s as a Mermaid quoted label.
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// writeGraphML writes graph as GraphML, with the title and group of each
+// note and the link count of each edge as data.
+func writeGraphML(w io.Writer, graph *Graph) error {
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "title", For: "node", Name: "title", Type: "string"},
+			{ID: "group", For: "node", Name: "group", Type: "string"},
+			{ID: "links", For: "edge", Name: "links", Type: "int"},
+		},
+		Graph: graphMLGraph{ID: "notebook", EdgeDefault: "directed"},
+	}
+	for _, node := range graph.Nodes {
+		data := []graphMLData{{Key: "title", Value: node.Title}}
+		if node.Group != "" {
+			data = append(data, graphMLData{Key: "group", Value: node.Group})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: node.ID, Data: data})
+	}
+	for _, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.Source,
+			Target: edge.Target,
+			Data:   []graphMLData{{Key: "links", Value: fmt.Sprint(edge.Links)}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write graphml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+// writeGraphNotebook writes a notebook of linked notes and returns its root.
+func writeGraphNotebook(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "adr/001-use-go.md", "---\ntitle: Use Go\ntags: [adr, lang]\n---\nSee [[002-use-duckdb]] and [[002-use-duckdb#Status]].\n")
+	testutil.WriteNote(t, root, "adr/002-use-duckdb.md", "---\ntitle: Use DuckDB\ntags: [adr]\n---\nSupersedes [old](../notes/sqlite.md). Back to [[001-use-go]].\n")
+	testutil.WriteNote(t, root, "notes/sqlite.md", "---\ntitle: \"SQLite \\\"notes\\\"\"\n---\nLinks to [[missing]] and [[sqlite]] itself. See [[index]].\n")
+	testutil.WriteNote(t, root, "index.md", "# Index\n\n[[001-use-go]]\n")
+	testutil.WriteNote(t, root, "lonely.md", "Nothing here.\n")
+	return root
+}
+
+func TestNoteService_Graph(t *testing.T) {
+	root := writeGraphNotebook(t)
+
+	// The --sql row limit doesn't apply to the graph's notes
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{SQLMaxRows: 2}), root)
+	notes.groups = []NotebookGroup{{Name: "Decisions", Globs: []string{"adr/**"}}}
+
+	graph, err := notes.Graph(context.Background(), GraphOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []GraphNode{
+		{ID: "adr/001-use-go.md", Title: "Use Go", Group: "Decisions"},
+		{ID: "adr/002-use-duckdb.md", Title: "Use DuckDB", Group: "Decisions"},
+		{ID: "index.md", Title: "index"},
+		{ID: "lonely.md", Title: "lonely"},
+		{ID: "notes/sqlite.md", Title: `SQLite "notes"`},
+	}, graph.Nodes)
+	assert.Equal(t, []GraphEdge{
+		{Source: "adr/001-use-go.md", Target: "adr/002-use-duckdb.md", Links: 2},
+		{Source: "adr/002-use-duckdb.md", Target: "adr/001-use-go.md", Links: 1},
+		{Source: "adr/002-use-duckdb.md", Target: "notes/sqlite.md", Links: 1},
+		{Source: "index.md", Target: "adr/001-use-go.md", Links: 1},
+		{Source: "notes/sqlite.md", Target: "index.md", Links: 1},
+	}, graph.Edges)
+}
+
+func TestNoteService_Graph_Filters(t *testing.T) {
+	root := writeGraphNotebook(t)
+
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+	// A catch-all group listed first mustn't hide notes from later groups
+	notes.groups = []NotebookGroup{
+		{Name: "Default", Globs: []string{"**/*.md"}},
+		{Name: "Decisions", Globs: []string{"adr/**"}},
+	}
+
+	ctx := context.Background()
+
+	ids := func(graph *Graph) []string {
+		var ids []string
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		opts     GraphOptions
+		expected []string
+		edges    int
+	}{
+		{"group", GraphOptions{Group: "Decisions"}, []string{"adr/001-use-go.md", "adr/002-use-duckdb.md"}, 2},
+		{"group ignores case", GraphOptions{Group: "decisions"}, []string{"adr/001-use-go.md", "adr/002-use-duckdb.md"}, 2},
+		{"group and where", GraphOptions{Group: "Default", Where: "relative NOT LIKE 'adr/%'"}, []string{"index.md", "lonely.md", "notes/sqlite.md"}, 1},
+		{"tag", GraphOptions{Tag: "lang"}, []string{"adr/001-use-go.md"}, 0},
+		{"tag glob", GraphOptions{Tag: "ad*"}, []string{"adr/001-use-go.md", "adr/002-use-duckdb.md"}, 2},
+		{"where", GraphOptions{Where: "relative NOT LIKE 'adr/%'"}, []string{"index.md", "lonely.md", "notes/sqlite.md"}, 1},
+		{"from depth 1", GraphOptions{From: "index.md", Depth: 1}, []string{"adr/001-use-go.md", "index.md", "notes/sqlite.md"}, 2},
+		{"from depth 0", GraphOptions{From: "index.md"}, []string{"index.md"}, 0},
+		{"from outside filter", GraphOptions{From: "index.md", Depth: 1, Group: "Decisions"}, []string{"adr/001-use-go.md", "index.md"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := notes.Graph(ctx, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(graph))
+			assert.Len(t, graph.Edges, tt.edges)
+		})
+	}
+
+	graph, err := notes.Graph(ctx, GraphOptions{Group: "decisions"})
+	require.NoError(t, err)
+	assert.Equal(t, "Decisions", graph.Nodes[0].Group, "nodes are shown in the filtered group")
+
+	_, err = notes.Graph(ctx, GraphOptions{Group: "meetings"})
+	assert.ErrorContains(t, err, `unknown group "meetings"`)
+
+	_, err = notes.Graph(ctx, GraphOptions{From: "nope.md"})
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+
+	_, err = notes.Graph(ctx, GraphOptions{Where: "nonexistent_column = 1"})
+	assert.Error(t, err)
+}
+
+func TestParseGraphFormat(t *testing.T) {
+	format, err := ParseGraphFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, GraphDOT, format)
+
+	format, err = ParseGraphFormat("GraphML")
+	require.NoError(t, err)
+	assert.Equal(t, GraphML, format)
+
+	_, err = ParseGraphFormat("svg")
+	assert.ErrorContains(t, err, "dot, mermaid, graphml, json")
+}
+
+var testGraph = &Graph{
+	Nodes: []GraphNode{
+		{ID: "adr/001.md", Title: "Use Go", Group: "Decisions"},
+		{ID: "notes/a.md", Title: `Say "hi"`},
+	},
+	Edges: []GraphEdge{
+		{Source: "adr/001.md", Target: "notes/a.md", Links: 1},
+		{Source: "notes/a.md", Target: "adr/001.md", Links: 3},
+	},
+}
+
+func TestWriteGraph_DOT(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGraph(&b, GraphDOT, testGraph))
+	assert.Equal(t, `digraph notebook {
+	rankdir=LR;
+	node [shape=box];
+	subgraph cluster_0 {
+		label="Decisions";
+		"adr/001.md" [label="Use Go"];
+	}
+	"notes/a.md" [label="Say \"hi\""];
+	"adr/001.md" -> "notes/a.md";
+	"notes/a.md" -> "adr/001.md" [penwidth=3];
+}
+`, b.String())
+}
+
+func TestWriteGraph_Mermaid(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGraph(&b, GraphMermaid, testGraph))
+	assert.Equal(t, `flowchart LR
+    subgraph g1["Decisions"]
+        n1["Use Go"]
+    end
+    n2["Say #quot;hi#quot;"]
+    n1 --> n2
+    n2 --> n1
+`, b.String())
+}
+
+func TestWriteGraph_GraphML(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGraph(&b, GraphML, testGraph))
+	assert.Contains(t, b.String(), `<graph id="notebook" edgedefault="directed">`)
+	assert.Contains(t, b.String(), `<data key="title">Say &#34;hi&#34;</data>`)
+
+	var doc graphMLDocument
+	require.NoError(t, xml.Unmarshal(b.Bytes(), &doc))
+	require.Len(t, doc.Graph.Nodes, 2)
+	assert.Equal(t, "adr/001.md", doc.Graph.Nodes[0].ID)
+	assert.Equal(t, []graphMLData{{Key: "title", Value: "Use Go"}, {Key: "group", Value: "Decisions"}}, doc.Graph.Nodes[0].Data)
+	require.Len(t, doc.Graph.Edges, 2)
+	assert.Equal(t, "3", doc.Graph.Edges[1].Data[0].Value)
+}
+
+func TestWriteGraph_JSON(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGraph(&b, GraphJSON, &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}))
+	assert.JSONEq(t, `{"nodes": [], "edges": []}`, b.String())
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// Matches reports whether the note at relative, a path from the notebook
+// root, is in the group.
+func (g NotebookGroup) Matches(relative string) bool {
+	for _, glob := range g.Globs {
+		if core.MatchGlob(glob, relative) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns the directory new notes in the group are created in, relative
+// to the notebook root: the part of its first glob without wildcards, or ""
+// for the root itself.
+func (g NotebookGroup) Dir() string {
+	if len(g.Globs) == 0 {
+		return ""
+	}
+	glob := strings.TrimPrefix(g.Globs[0], "/")
+	if !core.HasGlobMeta(glob) {
+		// A glob naming a single note, e.g. "inbox.md"
+		glob = path.Dir(glob) + "/*"
+	}
+	dir := core.GlobBase(glob)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// ApplyMetadata adds the group's metadata to the frontmatter of content.
+// Fields content already has are kept, so templates can override group
+// defaults.
+func (g NotebookGroup) ApplyMetadata(content string) (string, error) {
+	content, err := setMissingFields(content, g.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply metadata of group %s: %w", g.Name, err)
+	}
+	return content, nil
+}
+
+// setMissingFields sets the fields content's frontmatter doesn't have yet,
+// in key order.
+func setMissingFields(content string, fields map[string]any) (string, error) {
+	if len(fields) == 0 {
+		return content, nil
+	}
+
+	frontmatter, body, err := core.ReadFrontmatter(content)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if frontmatter.Has(key) {
+			continue
+		}
+		if err := frontmatter.Set(key, fields[key]); err != nil {
+			return "", err
+		}
+	}
+	return frontmatter.Render(body)
+}
+
+// FindGroup returns the notebook's group named name, ignoring case.
+func (c *NotebookConfig) FindGroup(name string) (*NotebookGroup, error) {
+	return findGroup(c.Groups, name)
+}
+
+// findGroup returns the group in groups named name, ignoring case.
+func findGroup(groups []NotebookGroup, name string) (*NotebookGroup, error) {
+	names := make([]string, len(groups))
+	for i := range groups {
+		if strings.EqualFold(groups[i].Name, name) {
+			return &groups[i], nil
+		}
+		names[i] = groups[i].Name
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("unknown group %q: the notebook has no groups", name)
+	}
+	return nil, fmt.Errorf("unknown group %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// groupsOf returns the names of the groups the note at relative is in, in
+// config order.
+func groupsOf(groups []NotebookGroup, relative string) []string {
+	var names []string
+	for _, group := range groups {
+		if group.Matches(relative) {
+			names = append(names, group.Name)
+		}
+	}
+	return names
+}
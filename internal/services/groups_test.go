@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestNotebookGroup_Matches(t *testing.T) {
+	group := NotebookGroup{Name: "Meetings", Globs: []string{"meetings/**/*.md", "standup-*.md"}}
+
+	assert.True(t, group.Matches("meetings/2025/retro.md"))
+	assert.True(t, group.Matches("standup-monday.md"))
+	assert.False(t, group.Matches("projects/meetings.md"))
+	assert.False(t, NotebookGroup{Name: "Empty"}.Matches("a.md"))
+}
+
+func TestNotebookGroup_Dir(t *testing.T) {
+	tests := []struct {
+		globs    []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"**/*.md"}, ""},
+		{[]string{"meetings/*.md"}, "meetings"},
+		{[]string{"work/meetings/**/*.md", "standup-*.md"}, "work/meetings"},
+		{[]string{"/adr/**"}, "adr"},
+		{[]string{"journal/*/*.md"}, "journal"},
+		{[]string{"inbox.md"}, ""},
+		{[]string{"lists/todo.md"}, "lists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NotebookGroup{Globs: tt.globs}.Dir())
+		})
+	}
+}
+
+func TestNotebookGroup_ApplyMetadata(t *testing.T) {
+	group := NotebookGroup{Name: "Meetings", Metadata: map[string]any{
+		"type":  "meeting",
+		"tags":  []any{"meeting"},
+		"title": "Untitled",
+	}}
+
+	content, err := group.ApplyMetadata("---\ntitle: Standup\n---\n\n# Standup\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Standup\ntags:\n  - meeting\ntype: meeting\n---\n\n# Standup\n", content)
+
+	content, err = group.ApplyMetadata("# No frontmatter\n")
+	require.NoError(t, err)
+	assert.Contains(t, content, "type: meeting\n")
+	assert.Contains(t, content, "title: Untitled\n")
+	assert.Contains(t, content, "# No frontmatter\n")
+
+	content, err = NotebookGroup{}.ApplyMetadata("unchanged")
+	require.NoError(t, err)
+	assert.Equal(t, "unchanged", content)
+}
+
+func TestNotebookConfig_FindGroup(t *testing.T) {
+	config := &NotebookConfig{StoredNotebookConfig: StoredNotebookConfig{Groups: []NotebookGroup{
+		{Name: "Default"},
+		{Name: "Meetings"},
+	}}}
+
+	group, err := config.FindGroup("meetings")
+	require.NoError(t, err)
+	assert.Equal(t, "Meetings", group.Name)
+
+	_, err = config.FindGroup("adr")
+	assert.EqualError(t, err, `unknown group "adr" (expected one of: Default, Meetings)`)
+
+	_, err = (&NotebookConfig{}).FindGroup("adr")
+	assert.EqualError(t, err, `unknown group "adr": the notebook has no groups`)
+}
+
+func TestNoteService_NoteGroups(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "meetings/retro.md", "# Retro\n")
+	testutil.WriteNote(t, root, "todo.md", "# Todo\n")
+
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+	notes.groups = []NotebookGroup{
+		{Name: "Default", Globs: []string{"**/*.md"}},
+		{Name: "Meetings", Globs: []string{"meetings/*.md"}},
+	}
+
+	all, err := notes.SearchNotes(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, []string{"Default", "Meetings"}, all[0].Groups)
+	assert.Equal(t, []string{"Default"}, all[1].Groups)
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// indexSchemaVersion is bumped whenever the index table layout changes.
+// A mismatch drops and rebuilds the index on the next refresh.
+const indexSchemaVersion = 3
+
+// IndexStats reports what a refresh changed.
+type IndexStats struct {
+	Added   int
+	Updated int
+	Removed int
+	Total   int
+}
+
+// Changed returns true if the refresh modified the index.
+func (s IndexStats) Changed() bool {
+	return s.Added+s.Updated+s.Removed > 0
+}
+
+// NoteIndex is a persistent, per-notebook cache of parsed notes.
+// Notes are stored in a DuckDB file keyed by path, mtime and size so a refresh
+// only re-parses files that changed since the last run.
+type NoteIndex struct {
+	root       string
+	path       string
+	alias      string
+	dbService  *DbService
+	persistent bool
+	opened     bool
+	log        zerolog.Logger
+}
+
+// indexedFile is the change-detection key for a note in the index.
+type indexedFile struct {
+	mtime time.Time
+	size  int64
+}
+
+// DefaultIndexDir returns the platform cache directory for note indexes.
+func DefaultIndexDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cacheDir, "opennotes", "index")
+}
+
+// IndexPath returns the index file for a notebook root inside indexDir.
+// An empty indexDir uses DefaultIndexDir.
+func IndexPath(indexDir, root string) string {
+	if indexDir == "" {
+		indexDir = DefaultIndexDir()
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(indexDir, hex.EncodeToString(sum[:8])+".duckdb")
+}
+
+// NewNoteIndex creates an index for the notes under root, stored at path.
+func NewNoteIndex(db *DbService, root, path string) *NoteIndex {
+	sum := sha256.Sum256([]byte(path))
+	return &NoteIndex{
+		root:      root,
+		path:      path,
+		alias:     "idx_" + hex.EncodeToString(sum[:8]),
+		dbService: db,
+		log:       Log("NoteIndex"),
+	}
+}
+
+// Path returns the index database file.
+func (i *NoteIndex) Path() string {
+	return i.path
+}
+
+// Table returns the fully qualified notes table on the main connection.
+func (i *NoteIndex) Table() string {
+	return i.alias + ".notes"
+}
+
+// LinksTable returns the fully qualified table of links between notes on the
+// main connection. Its columns are those of NoteLink, with a NULL target for
+// links to notes that don't exist.
+func (i *NoteIndex) LinksTable() string {
+	return i.alias + ".links"
+}
+
+// Persistent returns false when the index file could not be opened (for
+// example because another process holds its lock) and an in-memory index
+// is being used instead.
+func (i *NoteIndex) Persistent() bool {
+	return i.persistent
+}
+
+// Refresh brings the index up to date with the notes on disk.
+// New and modified files are parsed, deleted files are dropped, and unchanged
+// files are left alone.
+func (i *NoteIndex) Refresh(ctx context.Context) (IndexStats, error) {
+	var stats IndexStats
+
+	i.dbService.indexMu.Lock()
+	defer i.dbService.indexMu.Unlock()
+
+	db, err := i.dbService.GetDB(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	if err := i.open(ctx, db); err != nil {
+		return stats, err
+	}
+
+	existing, err := i.loadEntries(ctx, db)
+	if err != nil {
+		return stats, err
+	}
+
+	files, err := scanMarkdownFiles(i.root)
+	if err != nil {
+		return stats, fmt.Errorf("failed to scan notebook: %w", err)
+	}
+
+	var changed, added []string
+	for path, info := range files {
+		prev, ok := existing[path]
+		switch {
+		case !ok:
+			stats.Added++
+			changed = append(changed, path)
+			added = append(added, path)
+		case !prev.mtime.Equal(info.mtime) || prev.size != info.size:
+			stats.Updated++
+			changed = append(changed, path)
+		}
+	}
+
+	var removed []string
+	for path := range existing {
+		if _, ok := files[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	stats.Removed = len(removed)
+	stats.Total = len(files)
+
+	if !stats.Changed() {
+		return stats, nil
+	}
+
+	i.log.Debug().
+		Int("added", stats.Added).
+		Int("updated", stats.Updated).
+		Int("removed", stats.Removed).
+		Msg("refreshing note index")
+
+	if err := i.apply(ctx, db, changed, added, removed, files); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// open attaches the index file to the main connection and ensures its schema.
+// If the file can't be attached an in-memory index is used for this process.
+func (i *NoteIndex) open(ctx context.Context, db *sql.DB) error {
+	if i.opened {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(i.path), 0755); err != nil {
+		i.log.Warn().Err(err).Str("path", i.path).Msg("failed to create index directory")
+	}
+
+	i.persistent = false
+	if i.dbService.claimIndexFile(i.path) {
+		if err := i.dbService.attach(ctx, db, i.alias, i.path, false); err != nil {
+			i.log.Warn().Err(err).Str("path", i.path).Msg("failed to open note index, using in-memory index")
+		} else {
+			i.persistent = true
+		}
+	} else {
+		i.log.Debug().Str("path", i.path).Msg("note index in use, using in-memory index")
+	}
+
+	if !i.persistent {
+		if err := i.dbService.attach(ctx, db, i.alias, ":memory:", false); err != nil {
+			return fmt.Errorf("failed to open note index: %w", err)
+		}
+	}
+
+	var version int
+	row := db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT version FROM duckdb_tables() t, %s.index_info WHERE t.database_name = ? AND t.table_name = 'index_info'",
+		i.alias,
+	), i.alias)
+	if err := row.Scan(&version); err != nil {
+		version = 0
+	}
+
+	if version == indexSchemaVersion {
+		i.opened = true
+		return nil
+	}
+
+	i.log.Debug().Int("version", version).Msg("creating note index schema")
+
+	statements := []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s.notes", i.alias),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s.links", i.alias),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s.index_info", i.alias),
+		fmt.Sprintf(`CREATE TABLE %s.notes (
+			filepath VARCHAR,
+			relative VARCHAR,
+			content VARCHAR,
+			metadata MAP(VARCHAR, VARCHAR),
+			frontmatter JSON,
+			mtime TIMESTAMP,
+			size BIGINT
+		)`, i.alias),
+		fmt.Sprintf(`CREATE TABLE %s.links (
+			source VARCHAR,
+			target VARCHAR,
+			kind VARCHAR,
+			link VARCHAR,
+			fragment VARCHAR,
+			line BIGINT
+		)`, i.alias),
+		fmt.Sprintf("CREATE TABLE %s.index_info (version INTEGER, root VARCHAR)", i.alias),
+		fmt.Sprintf("INSERT INTO %s.index_info VALUES (%d, %s)", i.alias, indexSchemaVersion, quoteSQLString(i.root)),
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create note index: %w", err)
+		}
+	}
+
+	i.opened = true
+	return nil
+}
+
+// release detaches the index file so other processes can open it.
+// The next Refresh attaches it again. Changes are checkpointed by apply, so
+// nothing is lost; an in-memory fallback index is dropped and rebuilt.
+func (i *NoteIndex) release(ctx context.Context) error {
+	i.dbService.indexMu.Lock()
+	defer i.dbService.indexMu.Unlock()
+
+	if !i.opened {
+		return nil
+	}
+
+	db, err := i.dbService.GetDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	i.opened = false
+	return i.dbService.detach(ctx, db, i.alias)
+}
+
+// loadEntries returns the change-detection keys of every indexed note.
+func (i *NoteIndex) loadEntries(ctx context.Context, db *sql.DB) (map[string]indexedFile, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT filepath, mtime, size FROM %s", i.Table()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note index: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			i.log.Warn().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	entries := make(map[string]indexedFile)
+	for rows.Next() {
+		var path string
+		var entry indexedFile
+		if err := rows.Scan(&path, &entry.mtime, &entry.size); err != nil {
+			return nil, err
+		}
+		entries[path] = entry
+	}
+
+	return entries, rows.Err()
+}
+
+// apply writes changed notes, of which added are new, and removes deleted
+// ones in a single transaction, resolving the links of changed notes and the
+// links elsewhere that added and removed notes can change.
+func (i *NoteIndex) apply(ctx context.Context, db *sql.DB, changed, added, removed []string, files map[string]indexedFile) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Rollback is a no-op after a successful commit
+		_ = tx.Rollback()
+	}()
+
+	stale := append(append([]string{}, removed...), changed...)
+	staleRelatives := make([]string, len(stale))
+	for n, path := range stale {
+		staleRelatives[n] = i.relative(path)
+	}
+	if len(stale) > 0 {
+		deleteStmt := fmt.Sprintf("DELETE FROM %s WHERE filepath IN (SELECT unnest(?::VARCHAR[]))", i.Table())
+		if _, err := tx.ExecContext(ctx, deleteStmt, stale); err != nil {
+			return fmt.Errorf("failed to update note index: %w", err)
+		}
+		deleteLinksStmt := fmt.Sprintf("DELETE FROM %s WHERE source IN (SELECT unnest(?::VARCHAR[]))", i.LinksTable())
+		if _, err := tx.ExecContext(ctx, deleteLinksStmt, staleRelatives); err != nil {
+			return fmt.Errorf("failed to update note index: %w", err)
+		}
+	}
+
+	var notes noteColumns
+	var fileLinks []NoteLink
+	for _, path := range changed {
+		file, err := readMarkdownFile(path)
+		if err != nil {
+			// The file may have vanished between scan and read
+			i.log.Warn().Err(err).Str("path", path).Msg("failed to index note")
+			continue
+		}
+
+		notes.add(path, i.relative(path), file, files[path])
+		for _, link := range file.Links {
+			if kind, ok := noteLinkKind(link); ok {
+				fileLinks = append(fileLinks, NoteLink{Source: i.relative(path), Kind: kind, Link: link.Target, Fragment: link.Fragment, Line: link.Line})
+			}
+		}
+	}
+
+	if err := i.insertNotes(ctx, tx, notes); err != nil {
+		return err
+	}
+
+	resolver, err := i.loadResolver(ctx, tx)
+	if err != nil {
+		return err
+	}
+	moved := make([]string, 0, len(added)+len(removed))
+	for _, path := range added {
+		moved = append(moved, i.relative(path))
+	}
+	moved = append(moved, staleRelatives[:len(removed)]...)
+	if err := i.resolveLinks(ctx, tx, resolver, moved, len(added) > 0); err != nil {
+		return err
+	}
+
+	var links noteLinkColumns
+	for _, link := range fileLinks {
+		link.Target = resolver.resolveLink(link.Source, link.Kind, link.Link)
+		links.add(link)
+	}
+	if err := i.insertLinks(ctx, tx, links); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update note index: %w", err)
+	}
+
+	// Flush the WAL so read-only attachments see the new data
+	if i.persistent {
+		if _, err := db.ExecContext(ctx, "CHECKPOINT "+i.alias); err != nil {
+			i.log.Warn().Err(err).Msg("failed to checkpoint note index")
+		}
+	}
+
+	return nil
+}
+
+// noteColumns holds notes column by column, for inserting as lists.
+type noteColumns struct {
+	paths, relatives, contents, frontmatter []string
+	keys, values                            [][]string
+	mtimes                                  []time.Time
+	sizes                                   []int64
+}
+
+func (c *noteColumns) add(path, relative string, file *markdownFile, info indexedFile) {
+	keys := make([]string, 0, len(file.Metadata))
+	values := make([]string, 0, len(file.Metadata))
+	for key, value := range file.Metadata {
+		keys = append(keys, key)
+		values = append(values, MetadataValueString(value))
+	}
+
+	frontmatter, err := json.Marshal(indexableMetadata(file.Metadata))
+	if err != nil {
+		frontmatter = []byte("{}")
+	}
+
+	c.paths = append(c.paths, path)
+	c.relatives = append(c.relatives, relative)
+	c.contents = append(c.contents, file.Content)
+	c.keys = append(c.keys, keys)
+	c.values = append(c.values, values)
+	c.frontmatter = append(c.frontmatter, string(frontmatter))
+	c.mtimes = append(c.mtimes, info.mtime)
+	c.sizes = append(c.sizes, info.size)
+}
+
+// insertNotes adds notes to the notes table in one statement.
+func (i *NoteIndex) insertNotes(ctx context.Context, tx *sql.Tx, c noteColumns) error {
+	if len(c.paths) == 0 {
+		return nil
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s
+		SELECT filepath, relative, content, MAP(keys, "values"), frontmatter::JSON, mtime, size
+		FROM (SELECT unnest(?::VARCHAR[]) AS filepath,
+			unnest(?::VARCHAR[]) AS relative,
+			unnest(?::VARCHAR[]) AS content,
+			unnest(?::VARCHAR[][]) AS keys,
+			unnest(?::VARCHAR[][]) AS "values",
+			unnest(?::VARCHAR[]) AS frontmatter,
+			unnest(?::TIMESTAMP[]) AS mtime,
+			unnest(?::BIGINT[]) AS size)`, i.Table())
+	if _, err := tx.ExecContext(ctx, stmt,
+		c.paths, c.relatives, c.contents, c.keys, c.values, c.frontmatter, c.mtimes, c.sizes,
+	); err != nil {
+		return fmt.Errorf("failed to update note index: %w", err)
+	}
+	return nil
+}
+
+// noteLinkColumns holds links column by column, for inserting as lists.
+type noteLinkColumns struct {
+	sources, targets, kinds, links, fragments []string
+	lines                                     []int64
+}
+
+func (c *noteLinkColumns) add(link NoteLink) {
+	c.sources = append(c.sources, link.Source)
+	c.targets = append(c.targets, link.Target)
+	c.kinds = append(c.kinds, link.Kind)
+	c.links = append(c.links, link.Link)
+	c.fragments = append(c.fragments, link.Fragment)
+	c.lines = append(c.lines, int64(link.Line))
+}
+
+// insertLinks adds links to the links table in one statement.
+func (i *NoteIndex) insertLinks(ctx context.Context, tx *sql.Tx, c noteLinkColumns) error {
+	if len(c.sources) == 0 {
+		return nil
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s
+		SELECT source, nullif(target, ''), kind, link, nullif(fragment, ''), line
+		FROM (SELECT unnest(?::VARCHAR[]) AS source,
+			unnest(?::VARCHAR[]) AS target,
+			unnest(?::VARCHAR[]) AS kind,
+			unnest(?::VARCHAR[]) AS link,
+			unnest(?::VARCHAR[]) AS fragment,
+			unnest(?::BIGINT[]) AS line)`, i.LinksTable())
+	if _, err := tx.ExecContext(ctx, stmt, c.sources, c.targets, c.kinds, c.links, c.fragments, c.lines); err != nil {
+		return fmt.Errorf("failed to update note links: %w", err)
+	}
+	return nil
+}
+
+// loadResolver returns a resolver for the notes in the index.
+func (i *NoteIndex) loadResolver(ctx context.Context, tx *sql.Tx) (*noteResolver, error) {
+	var relatives []string
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT relative FROM %s", i.Table()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			i.log.Warn().Err(err).Msg("failed to close rows")
+		}
+	}()
+	for rows.Next() {
+		var rel string
+		if err := rows.Scan(&rel); err != nil {
+			return nil, fmt.Errorf("failed to resolve note links: %w", err)
+		}
+		relatives = append(relatives, rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	return newNoteResolver(i.root, relatives), nil
+}
+
+// resolveLinks resolves again the links in the index that adding or removing
+// the notes at moved (relative paths) can change: links to a removed note,
+// wikilinks to a moved note's path or filename, and, when notes were added,
+// broken markdown links. Only links whose target changes are updated.
+func (i *NoteIndex) resolveLinks(ctx context.Context, tx *sql.Tx, resolver *noteResolver, moved []string, added bool) error {
+	if len(moved) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, 2*len(moved))
+	for _, rel := range moved {
+		key := wikiKey(rel)
+		keys = append(keys, key, path.Base(key))
+	}
+
+	var ids []int64
+	var targets []string
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT rowid, source, kind, link, coalesce(target, '') FROM %s
+		WHERE target IN (SELECT unnest(?::VARCHAR[]))
+			OR (kind = '%s' AND lower(regexp_replace(link, '\.md$', '')) IN (SELECT unnest(?::VARCHAR[])))
+			OR (kind = '%s' AND target IS NULL AND ?)`, i.LinksTable(), NoteLinkWiki, NoteLinkMarkdown),
+		moved, keys, added)
+	if err != nil {
+		return fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var link NoteLink
+		if err := rows.Scan(&id, &link.Source, &link.Kind, &link.Link, &link.Target); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to resolve note links: %w", err)
+		}
+		if target := resolver.resolveLink(link.Source, link.Kind, link.Link); target != link.Target {
+			ids = append(ids, id)
+			targets = append(targets, target)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET target = nullif(resolved.target, '')
+		FROM (SELECT unnest(?::BIGINT[]) AS id, unnest(?::VARCHAR[]) AS target) AS resolved
+		WHERE %s.rowid = resolved.id`, i.LinksTable(), i.LinksTable())
+	if _, err := tx.ExecContext(ctx, stmt, ids, targets); err != nil {
+		return fmt.Errorf("failed to resolve note links: %w", err)
+	}
+	return nil
+}
+
+// relative returns a note path relative to the notebook root.
+func (i *NoteIndex) relative(path string) string {
+	rel, err := filepath.Rel(i.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// scanMarkdownFiles returns the change-detection keys for every .md file under root.
+func scanMarkdownFiles(root string) (map[string]indexedFile, error) {
+	files := make(map[string]indexedFile)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Skip unreadable entries rather than failing the whole scan
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		files[path] = indexedFile{
+			mtime: info.ModTime().Truncate(time.Microsecond),
+			size:  info.Size(),
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// indexableMetadata prepares frontmatter for JSON storage.
+// Dates are stored in the same form as the metadata MAP column rather than
+// as full RFC3339 timestamps.
+func indexableMetadata(metadata map[string]any) map[string]any {
+	result := make(map[string]any, len(metadata))
+	for key, value := range metadata {
+		if t, ok := value.(time.Time); ok {
+			result[key] = MetadataValueString(t)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// quoteSQLString quotes s as a SQL string literal.
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestIndexPath(t *testing.T) {
+	dir := t.TempDir()
+
+	a := IndexPath(dir, "/notes/a")
+	b := IndexPath(dir, "/notes/b")
+
+	assert.Equal(t, dir, filepath.Dir(a))
+	assert.Equal(t, ".duckdb", filepath.Ext(a))
+	assert.Equal(t, a, IndexPath(dir, "/notes/a"))
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, DefaultIndexDir(), filepath.Dir(IndexPath("", "/notes/a")))
+}
+
+func TestNoteIndex_Refresh_Incremental(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	index := svc.NoteIndex(root)
+
+	testutil.WriteNote(t, root, "one.md", "# One\n")
+	testutil.WriteNote(t, root, "sub/two.md", "# Two\n")
+	testutil.WriteNote(t, root, "ignored.txt", "not a note")
+
+	stats, err := index.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Added: 2, Total: 2}, stats)
+	assert.True(t, index.Persistent())
+	assert.FileExists(t, index.Path())
+
+	stats, err = index.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Total: 2}, stats)
+	assert.False(t, stats.Changed())
+
+	// Modify one note and remove the other
+	updated := testutil.WriteNote(t, root, "one.md", "# One, edited\n")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(updated, later, later))
+	require.NoError(t, os.Remove(filepath.Join(root, "sub", "two.md")))
+
+	stats, err = index.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Updated: 1, Removed: 1, Total: 1}, stats)
+
+	db, err := svc.GetDB(ctx)
+	require.NoError(t, err)
+
+	var relative, content string
+	row := db.QueryRowContext(ctx, "SELECT relative, content FROM "+index.Table())
+	require.NoError(t, row.Scan(&relative, &content))
+	assert.Equal(t, "one.md", relative)
+	assert.Equal(t, "# One, edited\n", content)
+}
+
+func TestNoteIndex_Refresh_ResolvesChangedLinksOnly(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	index := svc.NoteIndex(root)
+
+	testutil.WriteNote(t, root, "a.md", "[B](b.md) and [[c]]\n")
+	testutil.WriteNote(t, root, "b.md", "[A](a.md)\n")
+	testutil.WriteNote(t, root, "c/c.md", "# C\n")
+	testutil.WriteNote(t, root, "d.md", "[[a]] and [gone](gone.md)\n")
+	testutil.WriteNote(t, root, "e.md", "[C](c/c.md)\n")
+	_, err := index.Refresh(ctx)
+	require.NoError(t, err)
+
+	db, err := svc.GetDB(ctx)
+	require.NoError(t, err)
+	// Rows that aren't resolved again keep a target no resolution would give
+	_, err = db.ExecContext(ctx, "UPDATE "+index.LinksTable()+" SET target = 'kept' WHERE source IN ('b.md', 'd.md')")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE "+index.LinksTable()+" SET target = NULL WHERE link = 'gone.md'")
+	require.NoError(t, err)
+
+	// Edit a.md, add the note d.md links to, and move c.md to other/
+	edited := testutil.WriteNote(t, root, "a.md", "[B](b.md) and [[c]]\n\nEdited.\n")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(edited, later, later))
+	testutil.WriteNote(t, root, "gone.md", "# Back\n")
+	testutil.WriteNote(t, root, "other/c.md", "# C\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "c", "c.md")))
+	_, err = index.Refresh(ctx)
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT source, link, coalesce(target, '') FROM "+index.LinksTable())
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	targets := make(map[string]string)
+	for rows.Next() {
+		var source, link, target string
+		require.NoError(t, rows.Scan(&source, &link, &target))
+		targets[source+" -> "+link] = target
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, map[string]string{
+		"a.md -> b.md":    "b.md",
+		"a.md -> c":       "other/c.md",
+		"b.md -> a.md":    "kept",
+		"d.md -> a":       "kept",
+		"d.md -> gone.md": "gone.md",
+		"e.md -> c/c.md":  "",
+	}, targets, "only links from a.md and to added or removed notes are resolved again")
+}
+
+func TestNoteIndex_Refresh_PersistsAcrossServices(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	indexDir := t.TempDir()
+
+	testutil.WriteNote(t, root, "note.md", "---\ntitle: Note\n---\nbody\n")
+
+	first := NewDbServiceWithOptions(DbOptions{MarkdownExtension: BuiltinMarkdownReader, IndexDir: indexDir})
+	stats, err := first.NoteIndex(root).Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Added)
+	require.NoError(t, first.Close())
+
+	second := newTestDbService(t, DbOptions{IndexDir: indexDir})
+	stats, err = second.NoteIndex(root).Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Total: 1}, stats)
+}
+
+func TestNoteIndex_Refresh_InUseFallsBackToMemory(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	indexDir := t.TempDir()
+
+	testutil.WriteNote(t, root, "note.md", "# Note\n")
+
+	owner := newTestDbService(t, DbOptions{IndexDir: indexDir})
+	_, err := owner.NoteIndex(root).Refresh(ctx)
+	require.NoError(t, err)
+
+	other := newTestDbService(t, DbOptions{IndexDir: indexDir})
+	index := other.NoteIndex(root)
+	stats, err := index.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, index.Persistent())
+	assert.Equal(t, 1, stats.Added)
+}
+
+func TestNoteIndex_Refresh_MissingRoot(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestDbService(t, DbOptions{})
+
+	stats, err := svc.NoteIndex(filepath.Join(t.TempDir(), "missing")).Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{}, stats)
+}
+
+func TestNoteService_SearchNotes_TypedMetadataFromIndex(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "note.md",
+		"---\ntitle: Typed\ntags: [a, b]\npriority: 2\ncreated: 2024-01-15\n---\nbody\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+
+	assert.Equal(t, "Typed", notes[0].Metadata["title"])
+	assert.Equal(t, []any{"a", "b"}, notes[0].Metadata["tags"])
+	assert.Equal(t, float64(2), notes[0].Metadata["priority"])
+	assert.Equal(t, "2024-01-15", notes[0].Metadata["created"])
+}
+
+func TestNoteService_ExecuteSQLSafe_QueriesIndex(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	notes := NewNoteService(nil, svc, root)
+
+	testutil.WriteNote(t, root, "a.md", "# A\n")
+
+	results, err := notes.ExecuteSQLSafe(ctx, "SELECT relative FROM notebook.notes ORDER BY relative")
+	require.NoError(t, err)
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, "a.md", results.Value(0, "relative"))
+
+	// New notes are visible on the next query
+	testutil.WriteNote(t, root, "b.md", "# B\n")
+
+	results, err = notes.ExecuteSQLSafe(ctx, "SELECT relative FROM notebook.notes ORDER BY relative")
+	require.NoError(t, err)
+	require.Len(t, results.Rows, 2)
+	assert.Equal(t, "b.md", results.Value(1, "relative"))
+}
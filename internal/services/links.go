@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// Kinds of NoteLink.
+const (
+	NoteLinkMarkdown = "markdown"
+	NoteLinkWiki     = "wiki"
+)
+
+// NoteLink is a link from one note to another.
+type NoteLink struct {
+	// Source is the relative path of the note the link is in
+	Source string `json:"source"`
+	// Target is the relative path of the linked note, empty when it doesn't exist
+	Target string `json:"target"`
+	// Kind is NoteLinkMarkdown or NoteLinkWiki
+	Kind string `json:"kind"`
+	// Link is the link's path or wikilink page as written, Fragment its #fragment
+	Link     string `json:"link"`
+	Fragment string `json:"fragment,omitempty"`
+	Line     int    `json:"line"`
+}
+
+// Broken reports whether the linked note doesn't exist.
+func (l NoteLink) Broken() bool {
+	return l.Target == ""
+}
+
+// noteLinkKind returns the NoteLink kind of link, and false for links that
+// can't be to a note: external links, fragments and files other than .md.
+func noteLinkKind(link core.LinkRef) (string, bool) {
+	if link.Target == "" {
+		return "", false
+	}
+	if link.Kind == core.LinkWiki {
+		if isAttachment(link.Target) {
+			return "", false
+		}
+		return NoteLinkWiki, true
+	}
+	if isExternalLink(link.Target) {
+		return "", false
+	}
+	target := link.Target
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if ext := path.Ext(target); ext != "" && ext != ".md" {
+		return "", false
+	}
+	return NoteLinkMarkdown, true
+}
+
+// attachmentExtensions are the kinds of file wikilinks embed besides notes.
+// Other extensions are taken as part of a note name, e.g. [[Release 1.0]].
+var attachmentExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".bmp": true, ".avif": true,
+	".pdf": true, ".mp3": true, ".wav": true, ".ogg": true, ".m4a": true, ".flac": true,
+	".mp4": true, ".webm": true, ".mov": true, ".mkv": true,
+	".csv": true, ".json": true, ".txt": true, ".canvas": true,
+}
+
+// isAttachment reports whether a wikilink page names an attachment rather
+// than a note.
+func isAttachment(page string) bool {
+	return attachmentExtensions[strings.ToLower(path.Ext(page))]
+}
+
+// noteResolver resolves link targets to the notes of a notebook.
+type noteResolver struct {
+	root string
+	// relatives holds the relative path of every note
+	relatives map[string]bool
+	// paths maps lowercased relative paths without .md to relative paths
+	paths map[string]string
+	// names maps lowercased filenames without .md to relative paths
+	names map[string][]string
+}
+
+// newNoteResolver creates a resolver for the notes at relatives, paths
+// relative to root.
+func newNoteResolver(root string, relatives []string) *noteResolver {
+	r := &noteResolver{
+		root:      root,
+		relatives: make(map[string]bool, len(relatives)),
+		paths:     make(map[string]string, len(relatives)),
+		names:     make(map[string][]string, len(relatives)),
+	}
+	for _, rel := range relatives {
+		r.relatives[rel] = true
+		key := wikiKey(rel)
+		r.paths[key] = rel
+		name := path.Base(key)
+		r.names[name] = append(r.names[name], rel)
+	}
+	return r
+}
+
+// resolveLink returns the relative path of the note a link of kind in the
+// note at source (relative) points to, or "" when there is no such note.
+func (r *noteResolver) resolveLink(source, kind, link string) string {
+	if kind == NoteLinkWiki {
+		rel, _ := r.resolveWiki(link)
+		return rel
+	}
+
+	file, ok := r.resolveFile(filepath.Join(r.root, filepath.FromSlash(source)), link)
+	if !ok {
+		return ""
+	}
+	rel, err := filepath.Rel(r.root, file)
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	for _, candidate := range []string{rel, rel + ".md"} {
+		if r.relatives[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// wikiKey normalises a wikilink page or relative path for lookups.
+func wikiKey(page string) string {
+	return strings.ToLower(strings.TrimSuffix(filepath.ToSlash(page), ".md"))
+}
+
+// resolveWiki returns the relative path of the note a wikilink page refers
+// to: a note at that path from the notebook root, else the only note with
+// that filename.
+func (r *noteResolver) resolveWiki(page string) (string, bool) {
+	key := wikiKey(page)
+	if rel, ok := r.paths[key]; ok {
+		return rel, true
+	}
+	if matches := r.names[key]; len(matches) == 1 && !strings.Contains(key, "/") {
+		return matches[0], true
+	}
+	return "", false
+}
+
+// resolveFile returns the absolute file path a markdown link target in the
+// note at from (absolute) refers to. External links, e.g. https: or mailto:,
+// and fragment-only links aren't files.
+func (r *noteResolver) resolveFile(from, target string) (string, bool) {
+	if target == "" || isExternalLink(target) {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if strings.HasPrefix(target, "/") {
+		return filepath.Join(r.root, filepath.FromSlash(target)), true
+	}
+	return filepath.Join(filepath.Dir(from), filepath.FromSlash(target)), true
+}
+
+// isExternalLink reports whether target has a URL scheme.
+func isExternalLink(target string) bool {
+	scheme, _, ok := strings.Cut(target, ":")
+	return ok && scheme != "" && !strings.ContainsAny(scheme, "/.")
+}
+
+// linkTarget returns a link target from the directory dir to the file at
+// file, written in the style of the original target: root-relative when it
+// started with / (and root is set), without .md when it had none, and URL
+// escaped when it was.
+func linkTarget(original, dir, file, root string) string {
+	var target string
+	if strings.HasPrefix(original, "/") && root != "" {
+		rel, _ := filepath.Rel(root, file)
+		target = "/" + filepath.ToSlash(rel)
+	} else {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		target = filepath.ToSlash(rel)
+		if strings.HasPrefix(original, "./") && !strings.HasPrefix(target, "../") {
+			target = "./" + target
+		}
+	}
+
+	if !strings.HasSuffix(original, ".md") && path.Ext(original) == "" {
+		target = strings.TrimSuffix(target, ".md")
+	}
+	if unescaped, err := url.PathUnescape(original); err == nil && unescaped != original {
+		target = (&url.URL{Path: target}).EscapedPath()
+	}
+	return target
+}
+
+// wikiTarget returns a wikilink page for the note at rel (relative to the
+// root), in the style of the original page: a path when it had one or when
+// the filename alone is ambiguous, and without .md when it had none.
+func wikiTarget(original, rel string, unique bool) string {
+	target := filepath.ToSlash(rel)
+	if !strings.Contains(original, "/") && unique {
+		target = path.Base(target)
+	}
+	if !strings.HasSuffix(strings.ToLower(original), ".md") {
+		target = strings.TrimSuffix(target, ".md")
+	}
+	return target
+}
+
+// linksTo reports whether the markdown link target file refers to the note
+// at note, with or without its .md extension.
+func linksTo(file, note string) bool {
+	return file == note || file+".md" == note
+}
+
+// isMarkdownLink reports whether link is a markdown link or reference definition.
+func isMarkdownLink(link core.LinkRef) bool {
+	return link.Kind == core.LinkInline || link.Kind == core.LinkReference
+}
+
+// Links returns the links in note to other notes, including links to notes
+// that don't exist, in line order.
+func (s *NoteService) Links(ctx context.Context, note *Note) ([]NoteLink, error) {
+	return s.queryLinks(ctx, "source = ? ORDER BY line", note.File.Relative)
+}
+
+// Backlinks returns the links to note from other notes, ordered by the
+// linking note and line.
+func (s *NoteService) Backlinks(ctx context.Context, note *Note) ([]NoteLink, error) {
+	return s.queryLinks(ctx, "target = ? AND source <> target ORDER BY source, line", note.File.Relative)
+}
+
+// queryLinks returns the links in the refreshed index matching where.
+func (s *NoteService) queryLinks(ctx context.Context, where string, args ...any) ([]NoteLink, error) {
+	index, _, err := s.refreshIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.dbService.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT source, coalesce(target, ''), kind, link, coalesce(fragment, ''), line FROM %s WHERE %s",
+		index.LinksTable(), where,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.log.Warn().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	links := []NoteLink{}
+	for rows.Next() {
+		var link NoteLink
+		if err := rows.Scan(&link.Source, &link.Target, &link.Kind, &link.Link, &link.Fragment, &link.Line); err != nil {
+			return nil, fmt.Errorf("failed to read links: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
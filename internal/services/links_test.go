@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestNoteLinkKind(t *testing.T) {
+	tests := []struct {
+		link     core.LinkRef
+		kind     string
+		expected bool
+	}{
+		{core.LinkRef{Kind: core.LinkWiki, Target: "daily-log"}, NoteLinkWiki, true},
+		{core.LinkRef{Kind: core.LinkWiki, Target: "Release 1.0"}, NoteLinkWiki, true},
+		{core.LinkRef{Kind: core.LinkWiki, Target: "chart.PNG"}, "", false},
+		{core.LinkRef{Kind: core.LinkInline, Target: "plan.md"}, NoteLinkMarkdown, true},
+		{core.LinkRef{Kind: core.LinkReference, Target: "../plan"}, NoteLinkMarkdown, true},
+		{core.LinkRef{Kind: core.LinkInline, Target: "my%20plan.md"}, NoteLinkMarkdown, true},
+		{core.LinkRef{Kind: core.LinkInline, Target: "chart.png"}, "", false},
+		{core.LinkRef{Kind: core.LinkInline, Target: "https://example.com/a.md"}, "", false},
+		{core.LinkRef{Kind: core.LinkInline, Target: "mailto:me@example.com"}, "", false},
+		{core.LinkRef{Kind: core.LinkInline, Fragment: "#heading"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link.Target, func(t *testing.T) {
+			kind, ok := noteLinkKind(tt.link)
+			assert.Equal(t, tt.expected, ok)
+			assert.Equal(t, tt.kind, kind)
+		})
+	}
+}
+
+func TestNoteResolver_ResolveLink(t *testing.T) {
+	resolver := newNoteResolver("/nb", []string{"daily-log.md", "projects/release.md", "projects/My Plan.md", "a/todo.md", "b/todo.md"})
+
+	tests := []struct {
+		source   string
+		kind     string
+		link     string
+		expected string
+	}{
+		{"daily-log.md", NoteLinkMarkdown, "projects/release.md", "projects/release.md"},
+		{"daily-log.md", NoteLinkMarkdown, "projects/release", "projects/release.md"},
+		{"daily-log.md", NoteLinkMarkdown, "/projects/release.md", "projects/release.md"},
+		{"projects/release.md", NoteLinkMarkdown, "../daily-log.md", "daily-log.md"},
+		{"projects/release.md", NoteLinkMarkdown, "My%20Plan.md", "projects/My Plan.md"},
+		{"daily-log.md", NoteLinkMarkdown, "release.md", ""},
+		{"daily-log.md", NoteLinkWiki, "release", "projects/release.md"},
+		{"daily-log.md", NoteLinkWiki, "Projects/Release.md", "projects/release.md"},
+		{"daily-log.md", NoteLinkWiki, "todo", ""},
+		{"daily-log.md", NoteLinkWiki, "a/todo", "a/todo.md"},
+		{"daily-log.md", NoteLinkWiki, "missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind+" "+tt.link, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolver.resolveLink(tt.source, tt.kind, tt.link))
+		})
+	}
+}
+
+func TestNoteService_Links(t *testing.T) {
+	notes, _ := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "projects/release.md")
+	require.NoError(t, err)
+	assert.Equal(t, 2, note.LinkCount)
+	assert.Equal(t, 7, note.BacklinkCount)
+
+	links, err := notes.Links(ctx, note)
+	require.NoError(t, err)
+	assert.Equal(t, []NoteLink{
+		{Source: "projects/release.md", Target: "projects/specs/spec.md", Kind: NoteLinkMarkdown, Link: "specs/spec.md", Line: 6},
+		{Source: "projects/release.md", Target: "daily-log.md", Kind: NoteLinkMarkdown, Link: "../daily-log.md", Line: 6},
+	}, links)
+
+	backlinks, err := notes.Backlinks(ctx, note)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 7)
+	assert.Equal(t, NoteLink{Source: "daily-log.md", Target: "projects/release.md", Kind: NoteLinkWiki, Link: "projects/release", Line: 1}, backlinks[0])
+	assert.Equal(t, NoteLink{Source: "daily-log.md", Target: "projects/release.md", Kind: NoteLinkMarkdown, Link: "projects/release.md", Fragment: "#goals", Line: 1}, backlinks[1])
+	assert.Equal(t, "projects/specs/spec.md", backlinks[6].Source)
+}
+
+func TestNoteService_Links_ResolveWhenNotesChange(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+	testutil.WriteNote(t, root, "unrelated.md", "Waiting for [[ideas]] and [later](ideas.md).\n")
+
+	note, err := notes.FindNote(ctx, "unrelated")
+	require.NoError(t, err)
+	links, err := notes.Links(ctx, note)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	assert.True(t, links[0].Broken())
+	assert.True(t, links[1].Broken())
+
+	testutil.WriteNote(t, root, "ideas.md", "# Ideas\n")
+	links, err = notes.Links(ctx, note)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	assert.Equal(t, "ideas.md", links[0].Target)
+	assert.Equal(t, "ideas.md", links[1].Target)
+
+	require.NoError(t, os.Remove(filepath.Join(root, "ideas.md")))
+	links, err = notes.Links(ctx, note)
+	require.NoError(t, err)
+	assert.True(t, links[0].Broken())
+}
+
+func TestNoteLinksView(t *testing.T) {
+	notes, _ := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	results, err := notes.ExecuteSQLSafe(ctx,
+		"SELECT relative, link_count, backlink_count FROM notes ORDER BY relative")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"daily-log.md", int64(5), int64(2)},
+		{"projects/release.md", int64(2), int64(7)},
+		{"projects/specs/spec.md", int64(2), int64(1)},
+		{"unrelated.md", int64(1), int64(0)},
+	}, results.Rows)
+
+	results, err = notes.ExecuteSQLSafe(ctx,
+		"SELECT source, kind, link, fragment, line FROM note_links WHERE target = 'projects/release.md' AND source = 'daily-log.md' ORDER BY link, kind")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"daily-log.md", "markdown", "/projects/release.md", nil, int64(1)},
+		{"daily-log.md", "wiki", "projects/release", nil, int64(1)},
+		{"daily-log.md", "markdown", "projects/release.md", "#goals", int64(1)},
+		{"daily-log.md", "markdown", "projects/release.md", nil, int64(2)},
+		{"daily-log.md", "markdown", "projects/release.md", nil, int64(4)},
+	}, results.Rows)
+}
+
+func TestNoteLinksView_WithoutIndex(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	db, err := notes.dbService.GetReadOnlyDB(ctx, root)
+	require.NoError(t, err)
+	require.NoError(t, notes.createViews(ctx, db, false))
+
+	var links int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT count(*) FROM note_links").Scan(&links))
+	assert.Zero(t, links)
+
+	var backlinks any
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT backlink_count FROM notes LIMIT 1").Scan(&backlinks))
+	assert.Nil(t, backlinks)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// LintProblem is a frontmatter field of a note that breaks the schema.
+type LintProblem struct {
+	// Note is the relative path of the note
+	Note string `json:"note"`
+	// Field is the field's path, e.g. tags[1], empty when the frontmatter can't be read
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// LintReport is the result of linting a notebook.
+type LintReport struct {
+	Notes    int           `json:"notes"`
+	Problems []LintProblem `json:"problems"`
+}
+
+// schemaFor returns the frontmatter schema of the note at relative: schema
+// with the schemas of the note's groups laid over it, in config order.
+func schemaFor(schema core.FrontmatterSchema, groups []NotebookGroup, relative string) core.FrontmatterSchema {
+	for _, group := range groups {
+		if group.Matches(relative) {
+			schema = schema.Merge(group.Schema)
+		}
+	}
+	return schema
+}
+
+// SchemaFor returns the frontmatter schema of the note at relative, a path
+// from the notebook root.
+func (c *NotebookConfig) SchemaFor(relative string) core.FrontmatterSchema {
+	return schemaFor(c.Schema, c.Groups, relative)
+}
+
+// ValidateNote checks the frontmatter of content: the fields opennotes reads
+// itself (see core.ValidateFrontmatter) and the fields in schema. The error
+// is for frontmatter that can't be parsed. Errors are ordered by field.
+func ValidateNote(content string, schema core.FrontmatterSchema) (core.ValidationErrors, error) {
+	metadata, _, err := core.ParseFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+	errs := append(core.ValidateFrontmatter(metadata), schema.Validate(metadata)...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs, nil
+}
+
+// PrepareNote fills in the frontmatter of a new note at relative, adding the
+// metadata of group (when set) and then the defaults of the note's schema,
+// and validates it. Frontmatter the note would be invalid with is an error.
+func (c *NotebookConfig) PrepareNote(relative, content string, group *NotebookGroup) (string, error) {
+	var err error
+	if group != nil {
+		if content, err = group.ApplyMetadata(content); err != nil {
+			return "", err
+		}
+	}
+
+	schema := c.SchemaFor(relative)
+	if content, err = setMissingFields(content, schema.Defaults()); err != nil {
+		return "", fmt.Errorf("failed to apply schema defaults: %w", err)
+	}
+
+	errs, err := ValidateNote(content, schema)
+	if err != nil {
+		return "", err
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("%s would have invalid frontmatter:\n%s", relative, errs.PrettyPrint())
+	}
+	return content, nil
+}
+
+// Lint validates the frontmatter of every note against its schema and the
+// fields opennotes reads. Problems are ordered by note, then field.
+func (s *NoteService) Lint(ctx context.Context) (*LintReport, error) {
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LintReport{Notes: len(notes), Problems: []LintProblem{}}
+	for _, note := range notes {
+		data, err := os.ReadFile(note.File.Filepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note: %w", err)
+		}
+
+		errs, err := ValidateNote(string(data), schemaFor(s.schema, s.groups, note.File.Relative))
+		if err != nil {
+			report.Problems = append(report.Problems, LintProblem{Note: note.File.Relative, Message: err.Error()})
+			continue
+		}
+		for _, e := range errs {
+			report.Problems = append(report.Problems, LintProblem{Note: note.File.Relative, Field: e.Path, Message: e.Message})
+		}
+	}
+	return report, nil
+}
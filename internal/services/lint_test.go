@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func newSchemaConfig() *NotebookConfig {
+	return &NotebookConfig{StoredNotebookConfig: StoredNotebookConfig{
+		Schema: core.FrontmatterSchema{
+			"title": {Type: core.FieldString, Required: true},
+			"tags":  {Type: core.FieldList, Pattern: `^[a-z-]+$`},
+		},
+		Groups: []NotebookGroup{{
+			Name:     "Decisions",
+			Globs:    []string{"adr/*.md"},
+			Metadata: map[string]any{"tags": []any{"adr"}},
+			Schema: core.FrontmatterSchema{
+				"status": {Type: core.FieldEnum, Values: []string{"proposed", "accepted"}, Default: "proposed", Required: true},
+				"date":   {Type: core.FieldDate, Required: true},
+			},
+		}},
+	}}
+}
+
+func TestNotebookConfig_SchemaFor(t *testing.T) {
+	config := newSchemaConfig()
+
+	assert.Len(t, config.SchemaFor("notes/a.md"), 2)
+	schema := config.SchemaFor("adr/001.md")
+	assert.Len(t, schema, 4)
+	assert.Equal(t, core.FieldEnum, schema["status"].Type)
+}
+
+func TestNotebookConfig_PrepareNote(t *testing.T) {
+	config := newSchemaConfig()
+	group := &config.Groups[0]
+
+	content, err := config.PrepareNote("adr/001.md", "---\ntitle: Use Go\ndate: 2025-01-02\n---\n\n# Use Go\n", group)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Use Go\ndate: 2025-01-02\ntags:\n  - adr\nstatus: proposed\n---\n\n# Use Go\n", content)
+
+	_, err = config.PrepareNote("adr/002.md", "---\ntitle: No date\nstatus: maybe\n---\n", group)
+	require.Error(t, err)
+	assert.Equal(t, "adr/002.md would have invalid frontmatter:\n- date\n  - is required\n- status\n  - must be one of: proposed, accepted", err.Error())
+
+	_, err = config.PrepareNote("notes/a.md", "# No title\n", nil)
+	assert.ErrorContains(t, err, "- title\n  - is required")
+
+	content, err = config.PrepareNote("notes/b.md", "---\ntitle: B\n---\n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: B\n---\n", content)
+}
+
+func TestValidateNote(t *testing.T) {
+	errs, err := ValidateNote("---\ntitle: 12\ntags: [Bad, ok]\n---\n", core.FrontmatterSchema{
+		"tags": {Type: core.FieldList, Pattern: `^[a-z]+$`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, core.ValidationErrors{
+		{Path: "tags[0]", Message: "must match ^[a-z]+$"},
+		{Path: "title", Message: "must be a string"},
+	}, errs)
+
+	_, err = ValidateNote("---\ntitle: [unclosed\n---\n", nil)
+	assert.Error(t, err)
+}
+
+func TestNoteService_Lint(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "adr/001.md", "---\ntitle: Use Go\nstatus: accepted\ndate: 2025-01-02\ntags: [adr]\n---\n")
+	testutil.WriteNote(t, root, "adr/002.md", "---\ntitle: Use DuckDB\nstatus: done\ntags: [ADR]\n---\n")
+	testutil.WriteNote(t, root, "notes/broken.md", "---\ntitle: [unclosed\n---\n")
+	testutil.WriteNote(t, root, "notes/untitled.md", "Just text.\n")
+
+	config := newSchemaConfig()
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+	notes.groups = config.Groups
+	notes.schema = config.Schema
+
+	report, err := notes.Lint(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Notes)
+
+	require.Len(t, report.Problems, 5)
+	assert.Equal(t, []LintProblem{
+		{Note: "adr/002.md", Field: "date", Message: "is required"},
+		{Note: "adr/002.md", Field: "status", Message: "must be one of: proposed, accepted"},
+		{Note: "adr/002.md", Field: "tags[0]", Message: "must match ^[a-z-]+$"},
+	}, report.Problems[:3])
+	assert.Equal(t, "notes/broken.md", report.Problems[3].Note)
+	assert.Contains(t, report.Problems[3].Message, "invalid frontmatter")
+	assert.Equal(t, LintProblem{Note: "notes/untitled.md", Field: "title", Message: "is required"}, report.Problems[4])
+}
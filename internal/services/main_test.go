@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+// TestMain keeps note indexes created by tests out of the user's cache dir.
+func TestMain(m *testing.M) {
+	cacheDir, err := os.MkdirTemp("", "opennotes-cache-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create cache dir: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.Setenv("XDG_CACHE_HOME", cacheDir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set XDG_CACHE_HOME: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	_ = os.RemoveAll(cacheDir)
+	os.Exit(code)
+}
+
+// newTestDbService returns a DbService with the built-in markdown reader, so
+// it never touches the network, storing indexes in a temp dir unless options
+// name one. It's closed when the test ends.
+func newTestDbService(t *testing.T, options DbOptions) *DbService {
+	t.Helper()
+
+	options.MarkdownExtension = BuiltinMarkdownReader
+	if options.IndexDir == "" {
+		options.IndexDir = t.TempDir()
+	}
+	svc := NewDbServiceWithOptions(options)
+	t.Cleanup(func() {
+		if err := svc.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+	return svc
+}
+
+// newTestNoteService returns a NoteService for a notebook of notes, content
+// by relative path, and the notebook's root.
+func newTestNoteService(t *testing.T, notes map[string]string) (*NoteService, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for relative, content := range notes {
+		testutil.WriteNote(t, root, relative, content)
+	}
+	return NewNoteService(nil, newTestDbService(t, DbOptions{}), root), root
+}
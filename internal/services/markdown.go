@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"gopkg.in/yaml.v3"
+)
+
+// BuiltinMarkdownReader is the MarkdownExtension config value that skips the
+// DuckDB community extension entirely and uses the pure-Go reader.
+const BuiltinMarkdownReader = "builtin"
+
+// markdownFile is a single parsed markdown document.
+type markdownFile struct {
+	Path     string
+	Content  string
+	Metadata map[string]any
+	// Links are the document's links, with lines counted from the top of the file
+	Links []core.LinkRef
+}
+
+// readMarkdownFile reads and parses a markdown file from disk.
+// Frontmatter is split from the body and decoded into metadata.
+func readMarkdownFile(path string) (*markdownFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, body, err := core.ParseFrontmatter(string(data))
+	if err != nil {
+		// Keep the document readable even when its frontmatter is broken
+		log := Log("MarkdownReader")
+		log.Warn().Err(err).Str("path", path).Msg("failed to parse frontmatter")
+		body = string(data)
+	}
+
+	return &markdownFile{
+		Path:     path,
+		Content:  body,
+		Metadata: metadata,
+		Links:    core.ScanLinks(string(data)),
+	}, nil
+}
+
+// expandMarkdownGlob returns all files matching a glob pattern, sorted by path.
+// Relative patterns are resolved against the current working directory.
+func expandMarkdownGlob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+
+	if !core.HasGlobMeta(pattern) {
+		if info, err := os.Stat(pattern); err == nil && !info.IsDir() {
+			return []string{filepath.FromSlash(pattern)}, nil
+		}
+		return nil, fmt.Errorf("IO Error: File or directory does not exist: %q", pattern)
+	}
+
+	base := core.GlobBase(pattern)
+	var files []string
+
+	err := filepath.WalkDir(filepath.FromSlash(base), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the whole scan
+			if d != nil && d.IsDir() && path != filepath.FromSlash(base) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if core.MatchGlob(pattern, filepath.ToSlash(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("IO Error: failed to expand %q: %w", pattern, err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("IO Error: File or directory does not exist: %q", pattern)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// MetadataValueString renders a frontmatter value as text, as for the MAP(VARCHAR, VARCHAR)
+// metadata column produced by the markdown extension. Non-scalar values are JSON encoded.
+func MetadataValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	case []any, map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// MetadataValueYAML renders a frontmatter value as text like
+// MetadataValueString, but with lists and maps written as flow-style YAML,
+// e.g. {owner: ana, tags: [a, b]}, as they'd be written in frontmatter.
+func MetadataValueYAML(value any) string {
+	switch value.(type) {
+	case []any, map[string]any:
+	default:
+		return MetadataValueString(value)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return MetadataValueString(value)
+	}
+	setYAMLFlowStyle(&node)
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return MetadataValueString(value)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// setYAMLFlowStyle writes node and the lists and maps in it on one line.
+func setYAMLFlowStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		setYAMLFlowStyle(child)
+	}
+}
+
+// markdownFilesFunc is the scalar function backing the built-in read_markdown.
+// It returns every matching file as a LIST of STRUCTs which the read_markdown
+// table macro unnests. A scalar function is used instead of a table function
+// because scalar arguments may be prepared statement parameters.
+//
+// When confined, relative patterns are resolved against root and files
+// outside it can't be read; with an empty root no files can.
+type markdownFilesFunc struct {
+	config   duckdb.ScalarFuncConfig
+	confined bool
+	root     string
+}
+
+func (f *markdownFilesFunc) Config() duckdb.ScalarFuncConfig {
+	return f.config
+}
+
+func (f *markdownFilesFunc) Executor() duckdb.ScalarFuncExecutor {
+	return duckdb.ScalarFuncExecutor{
+		RowExecutor: func(values []driver.Value) (any, error) {
+			pattern, _ := values[0].(string)
+
+			if f.confined {
+				var err error
+				if pattern, err = sandboxPattern(f.root, pattern); err != nil {
+					return nil, err
+				}
+			}
+
+			paths, err := expandMarkdownGlob(pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			files := make([]any, 0, len(paths))
+			for _, path := range paths {
+				if f.confined && !insideRoot(f.root, path) {
+					continue
+				}
+
+				file, err := readMarkdownFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read %s: %w", path, err)
+				}
+
+				entries := make([]any, 0, len(file.Metadata))
+				for key, value := range file.Metadata {
+					entries = append(entries, map[string]any{
+						"key":   key,
+						"value": MetadataValueString(value),
+					})
+				}
+
+				files = append(files, map[string]any{
+					"filepath": file.Path,
+					"content":  file.Content,
+					"metadata": entries,
+				})
+			}
+
+			return files, nil
+		},
+	}
+}
+
+// markdownFilesFunction is the name of the internal scalar function.
+const markdownFilesFunction = "opennotes_markdown_files"
+
+// readMarkdownMacro exposes the scalar function with the same shape as the
+// extension's read_markdown table function. The filepath column is always present.
+const readMarkdownMacro = `CREATE OR REPLACE MACRO read_markdown(pattern, include_filepath := false) AS TABLE
+	SELECT f.content AS content, map_from_entries(f.metadata) AS metadata, f.filepath AS filepath
+	FROM (SELECT UNNEST(` + markdownFilesFunction + `(pattern)) AS f)`
+
+// registerMarkdownReader registers a pure-Go read_markdown on db.
+// It mirrors the columns of the community markdown extension so queries written
+// against either implementation keep working. When confined it only reads
+// files under root, and none when root is empty.
+func registerMarkdownReader(ctx context.Context, db *sql.DB, confined bool, root string) error {
+	varchar, err := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	if err != nil {
+		return err
+	}
+
+	keyEntry, err := duckdb.NewStructEntry(varchar, "key")
+	if err != nil {
+		return err
+	}
+	valueEntry, err := duckdb.NewStructEntry(varchar, "value")
+	if err != nil {
+		return err
+	}
+	entryInfo, err := duckdb.NewStructInfo(keyEntry, valueEntry)
+	if err != nil {
+		return err
+	}
+	entriesInfo, err := duckdb.NewListInfo(entryInfo)
+	if err != nil {
+		return err
+	}
+
+	filepathEntry, err := duckdb.NewStructEntry(varchar, "filepath")
+	if err != nil {
+		return err
+	}
+	contentEntry, err := duckdb.NewStructEntry(varchar, "content")
+	if err != nil {
+		return err
+	}
+	metadataEntry, err := duckdb.NewStructEntry(entriesInfo, "metadata")
+	if err != nil {
+		return err
+	}
+	fileInfo, err := duckdb.NewStructInfo(filepathEntry, contentEntry, metadataEntry)
+	if err != nil {
+		return err
+	}
+	filesInfo, err := duckdb.NewListInfo(fileInfo)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log := Log("MarkdownReader")
+			log.Warn().Err(err).Msg("failed to close registration connection")
+		}
+	}()
+
+	fn := &markdownFilesFunc{
+		config: duckdb.ScalarFuncConfig{
+			InputTypeInfos: []duckdb.TypeInfo{varchar},
+			ResultTypeInfo: filesInfo,
+			Volatile:       true,
+		},
+		confined: confined,
+		root:     root,
+	}
+	if err := duckdb.RegisterScalarUDF(conn, markdownFilesFunction, fn); err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, readMarkdownMacro); err != nil {
+		return err
+	}
+
+	return nil
+}
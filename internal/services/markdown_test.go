@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestBuiltinMarkdownReader_ReadsFrontmatterAndContent(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestDbService(t, DbOptions{})
+
+	tmpDir := t.TempDir()
+	testutil.WriteNote(t, tmpDir, "note.md", "---\ntitle: Test Note\ntags: [a, b]\n---\n\n# Test Note\n")
+
+	results, err := svc.Query(ctx, "SELECT * FROM read_markdown(?, include_filepath:=true)", filepath.Join(tmpDir, "*.md"))
+	require.NoError(t, err)
+	require.Len(t, results.Rows, 1)
+
+	assert.Equal(t, filepath.Join(tmpDir, "note.md"), results.Value(0, "filepath"))
+	assert.Contains(t, results.Value(0, "content"), "# Test Note")
+	assert.NotContains(t, results.Value(0, "content"), "title: Test Note")
+
+	metadata, ok := results.Value(0, "metadata").(duckdb.Map)
+	require.True(t, ok, "metadata should be a MAP")
+	assert.Equal(t, "Test Note", metadata["title"])
+	assert.Equal(t, `["a","b"]`, metadata["tags"])
+}
+
+func TestBuiltinMarkdownReader_RecursiveGlob(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestDbService(t, DbOptions{})
+
+	tmpDir := t.TempDir()
+	testutil.WriteNote(t, tmpDir, "a.md", "# A")
+	testutil.WriteNote(t, tmpDir, "nested/b.md", "# B")
+	testutil.WriteNote(t, tmpDir, "nested/deeper/c.md", "# C")
+	testutil.WriteNote(t, tmpDir, "nested/ignored.txt", "not markdown")
+
+	results, err := svc.Query(ctx, "SELECT COUNT(*) AS total FROM read_markdown(?)", filepath.Join(tmpDir, "**", "*.md"))
+	require.NoError(t, err)
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, int64(3), results.Value(0, "total"))
+}
+
+func TestBuiltinMarkdownReader_NoMatchingFiles(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestDbService(t, DbOptions{})
+
+	_, err := svc.Query(ctx, "SELECT * FROM read_markdown(?)", filepath.Join(t.TempDir(), "**", "*.md"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "File or directory does not exist")
+}
+
+func TestDbService_MarkdownExtensionPath_FallsBackToBuiltin(t *testing.T) {
+	ctx := context.Background()
+	svc := NewDbServiceWithOptions(DbOptions{MarkdownExtension: filepath.Join(t.TempDir(), "missing.duckdb_extension")})
+	t.Cleanup(func() {
+		if err := svc.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+
+	tmpDir := t.TempDir()
+	testutil.WriteNote(t, tmpDir, "note.md", "# Note")
+
+	results, err := svc.Query(ctx, "SELECT content FROM read_markdown(?)", filepath.Join(tmpDir, "*.md"))
+	require.NoError(t, err)
+	require.Len(t, results.Rows, 1)
+	assert.Contains(t, results.Value(0, "content"), "# Note")
+}
+
+func TestMetadataValueYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"string", "Release Plan", "Release Plan"},
+		{"number", 2, "2"},
+		{"date", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "2024-01-15"},
+		{"null", nil, ""},
+		{"list", []any{"a", "b c"}, "[a, b c]"},
+		{"map", map[string]any{"owner": "ana", "tags": []any{"a", "b"}}, "{owner: ana, tags: [a, b]}"},
+		{"list of maps", []any{map[string]any{"url": "https://example.com"}}, "[{url: 'https://example.com'}]"},
+		{"quoted", map[string]any{"title": "a: b", "count": "2"}, `{count: "2", title: 'a: b'}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MetadataValueYAML(tt.value))
+		})
+	}
+}
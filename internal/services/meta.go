@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// MetaOp is how a MetaChange changes a field.
+type MetaOp string
+
+const (
+	// MetaSet sets the field to the value, key=value
+	MetaSet MetaOp = "="
+	// MetaAppend adds the value to a list field, key+=value
+	MetaAppend MetaOp = "+="
+	// MetaRemove removes the value from a list field, key-=value
+	MetaRemove MetaOp = "-="
+	// MetaUnset removes the field
+	MetaUnset MetaOp = "unset"
+)
+
+// MetaChange is a change to a frontmatter field of a note. Values are parsed
+// with core.ParseFieldValue, so status=done sets a string and priority=2 a
+// number.
+type MetaChange struct {
+	Key   string
+	Op    MetaOp
+	Value string
+}
+
+// ParseMetaChanges parses key=value, key+=value and key-=value assignments.
+func ParseMetaChanges(args []string) ([]MetaChange, error) {
+	changes := make([]MetaChange, 0, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		op := MetaSet
+		if k, found := strings.CutSuffix(key, "+"); found {
+			key, op = k, MetaAppend
+		} else if k, found := strings.CutSuffix(key, "-"); found {
+			key, op = k, MetaRemove
+		}
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid change %q, expected key=value, key+=value or key-=value", arg)
+		}
+		changes = append(changes, MetaChange{Key: key, Op: op, Value: value})
+	}
+	return changes, nil
+}
+
+// ApplyMetaChanges applies changes to the frontmatter of content, leaving its
+// body and other fields as they are. Returns the new content and whether it
+// changed; unchanged content is returned as it was.
+func ApplyMetaChanges(content string, changes []MetaChange) (string, bool, error) {
+	frontmatter, body, err := core.ReadFrontmatter(content)
+	if err != nil {
+		return "", false, err
+	}
+
+	changed := false
+	for _, change := range changes {
+		value := core.ParseFieldValue(change.Value)
+
+		var fieldChanged bool
+		switch change.Op {
+		case MetaAppend:
+			fieldChanged, err = frontmatter.Append(change.Key, value)
+		case MetaRemove:
+			fieldChanged, err = frontmatter.Remove(change.Key, value)
+		case MetaUnset:
+			fieldChanged = frontmatter.Delete(change.Key)
+		default:
+			before, had, _ := frontmatter.Get(change.Key)
+			if err = frontmatter.Set(change.Key, value); err == nil {
+				after, _, _ := frontmatter.Get(change.Key)
+				fieldChanged = !had || !reflect.DeepEqual(before, after)
+			}
+		}
+		if err != nil {
+			return "", false, err
+		}
+		changed = changed || fieldChanged
+	}
+
+	if !changed {
+		return content, false, nil
+	}
+	result, err := frontmatter.Render(body)
+	if err != nil {
+		return "", false, err
+	}
+	return result, true, nil
+}
+
+// ReadMeta reads the frontmatter of the note at relative, a path relative to
+// the notebook root.
+func (s *NoteService) ReadMeta(relative string) (*core.Frontmatter, error) {
+	data, err := os.ReadFile(filepath.Join(s.notebookPath, filepath.FromSlash(relative)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note: %w", err)
+	}
+
+	frontmatter, _, err := core.ReadFrontmatter(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", relative, err)
+	}
+	return frontmatter, nil
+}
+
+// UpdateMeta applies changes to the frontmatter of the note at relative and
+// reports whether it changed. Changes that would leave the changed fields
+// invalid, for opennotes or for the note's schema, are an error and the note
+// is left alone. With dryRun nothing is written.
+func (s *NoteService) UpdateMeta(relative string, changes []MetaChange, dryRun bool) (bool, error) {
+	path := filepath.Join(s.notebookPath, filepath.FromSlash(relative))
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat note: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note: %w", err)
+	}
+
+	content, changed, err := ApplyMetaChanges(string(data), changes)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", relative, err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	errs, err := ValidateNote(content, schemaFor(s.schema, s.groups, relative))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", relative, err)
+	}
+	if errs = changedFieldErrors(errs, changes); len(errs) > 0 {
+		return false, fmt.Errorf("%s would have invalid frontmatter:\n%s", relative, errs.PrettyPrint())
+	}
+
+	if dryRun {
+		return true, nil
+	}
+	if err := os.WriteFile(path, []byte(content), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to update note: %w", err)
+	}
+	return true, nil
+}
+
+// changedFieldErrors keeps the validation errors of the fields changes
+// touch, so a note's other problems don't block changing it.
+func changedFieldErrors(errs core.ValidationErrors, changes []MetaChange) core.ValidationErrors {
+	var kept core.ValidationErrors
+	for _, e := range errs {
+		for _, change := range changes {
+			if e.Path == change.Key || strings.HasPrefix(e.Path, change.Key+"[") || strings.HasPrefix(e.Path, change.Key+".") {
+				kept = append(kept, e)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// SelectNotes returns the relative paths of the notes matching where, a SQL
+// predicate on the notes view such as "metadata['status'] = 'todo'", in path
+// order.
+func (s *NoteService) SelectNotes(ctx context.Context, where string) ([]string, error) {
+	results, err := s.querySandbox(ctx, fmt.Sprintf("SELECT relative FROM notes WHERE (%s) ORDER BY relative", where), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select notes: %w", err)
+	}
+
+	relatives := make([]string, 0, results.Len())
+	for _, row := range results.Rows {
+		if relative, ok := row[0].(string); ok {
+			relatives = append(relatives, relative)
+		}
+	}
+	return relatives, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestParseMetaChanges(t *testing.T) {
+	changes, err := ParseMetaChanges([]string{"status=done", "tags+=release", "tags-=draft", "note=a=b", "empty="})
+	require.NoError(t, err)
+	assert.Equal(t, []MetaChange{
+		{Key: "status", Op: MetaSet, Value: "done"},
+		{Key: "tags", Op: MetaAppend, Value: "release"},
+		{Key: "tags", Op: MetaRemove, Value: "draft"},
+		{Key: "note", Op: MetaSet, Value: "a=b"},
+		{Key: "empty", Op: MetaSet, Value: ""},
+	}, changes)
+
+	for _, arg := range []string{"status", "=done", "+=x"} {
+		_, err := ParseMetaChanges([]string{arg})
+		assert.ErrorContains(t, err, "invalid change", arg)
+	}
+}
+
+func TestApplyMetaChanges(t *testing.T) {
+	content := "---\ntitle: Release # the plan\ntags: [draft]\ndraft: true\n---\n# Release\n"
+
+	result, changed, err := ApplyMetaChanges(content, []MetaChange{
+		{Key: "status", Op: MetaSet, Value: "done"},
+		{Key: "priority", Op: MetaSet, Value: "2"},
+		{Key: "tags", Op: MetaAppend, Value: "release"},
+		{Key: "tags", Op: MetaRemove, Value: "draft"},
+		{Key: "draft", Op: MetaUnset},
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "---\ntitle: Release # the plan\ntags: [release]\nstatus: done\npriority: 2\n---\n# Release\n", result)
+
+	result, changed, err = ApplyMetaChanges(content, []MetaChange{
+		{Key: "title", Op: MetaSet, Value: "Release"},
+		{Key: "tags", Op: MetaAppend, Value: "draft"},
+		{Key: "missing", Op: MetaUnset},
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, content, result, "unchanged content is returned as it was")
+
+	result, changed, err = ApplyMetaChanges("Just text.\n", []MetaChange{{Key: "status", Op: MetaSet, Value: "todo"}})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "---\nstatus: todo\n---\nJust text.\n", result)
+
+	result, _, err = ApplyMetaChanges("+++\ntitle = \"Hi\"\ntags = [\"a\"]\n+++\nbody\n", []MetaChange{{Key: "status", Op: MetaSet, Value: "done"}})
+	require.NoError(t, err)
+	assert.Equal(t, "+++\ntitle = \"Hi\"\ntags = [\"a\"]\nstatus = \"done\"\n+++\nbody\n", result, "other fields are written as they were")
+
+	_, _, err = ApplyMetaChanges("---\ntitle: [unclosed\n---\n", []MetaChange{{Key: "status", Op: MetaSet, Value: "todo"}})
+	assert.Error(t, err)
+}
+
+func TestApplyMetaChanges_RoundTrip(t *testing.T) {
+	set := []MetaChange{{Key: "status", Op: MetaSet, Value: "done"}}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "CRLF line endings",
+			content:  "---\r\ntitle: Plan\r\nstatus: draft\r\n---\r\n# Plan\r\n",
+			expected: "---\r\ntitle: Plan\r\nstatus: done\r\n---\r\n# Plan\r\n",
+		},
+		{
+			name:     "CRLF note without frontmatter",
+			content:  "# Plan\r\n",
+			expected: "---\r\nstatus: done\r\n---\r\n# Plan\r\n",
+		},
+		{
+			name:     "TOML double quotes",
+			content:  "+++\ntitle = \"Plan\"\nstatus = \"draft\"\n+++\nbody\n",
+			expected: "+++\ntitle = \"Plan\"\nstatus = \"done\"\n+++\nbody\n",
+		},
+		{
+			name:     "TOML single quotes",
+			content:  "+++\ntitle = 'Plan'\nstatus = 'draft'\n+++\nbody\n",
+			expected: "+++\ntitle = 'Plan'\nstatus = 'done'\n+++\nbody\n",
+		},
+		{
+			name:     "TOML without strings",
+			content:  "+++\ndraft = true\n+++\nbody\n",
+			expected: "+++\ndraft = true\nstatus = \"done\"\n+++\nbody\n",
+		},
+		{
+			name:     "note without frontmatter",
+			content:  "# Plan\n\nBody.\n",
+			expected: "---\nstatus: done\n---\n# Plan\n\nBody.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changed, err := ApplyMetaChanges(tt.content, set)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, tt.expected, result)
+
+			// Setting the same value again leaves the note as it is
+			again, changed, err := ApplyMetaChanges(result, set)
+			require.NoError(t, err)
+			assert.False(t, changed)
+			assert.Equal(t, result, again)
+		})
+	}
+}
+
+func TestNoteService_UpdateMeta(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "adr/001.md", "---\ntitle: Use Go\nstatus: proposed\ntags: [adr]\n---\nBody\n")
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+	notes.schema = core.FrontmatterSchema{
+		"status": {Type: core.FieldEnum, Values: []string{"proposed", "accepted"}},
+		"date":   {Type: core.FieldDate, Required: true},
+	}
+
+	changed, err := notes.UpdateMeta("adr/001.md", []MetaChange{{Key: "status", Op: MetaSet, Value: "accepted"}}, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, testutil.ReadNote(t, root, "adr/001.md"), "status: proposed", "dry runs don't write")
+
+	changed, err = notes.UpdateMeta("adr/001.md", []MetaChange{{Key: "status", Op: MetaSet, Value: "accepted"}}, false)
+	require.NoError(t, err, "the missing date doesn't block other changes")
+	assert.True(t, changed)
+	assert.Equal(t, "---\ntitle: Use Go\nstatus: accepted\ntags: [adr]\n---\nBody\n", testutil.ReadNote(t, root, "adr/001.md"))
+
+	_, err = notes.UpdateMeta("adr/001.md", []MetaChange{{Key: "status", Op: MetaSet, Value: "done"}}, false)
+	assert.ErrorContains(t, err, "adr/001.md would have invalid frontmatter")
+	assert.ErrorContains(t, err, "must be one of: proposed, accepted")
+	assert.Contains(t, testutil.ReadNote(t, root, "adr/001.md"), "status: accepted")
+
+	_, err = notes.UpdateMeta("adr/001.md", []MetaChange{{Key: "date", Op: MetaSet, Value: "soon"}}, false)
+	assert.ErrorContains(t, err, "date")
+
+	_, err = notes.UpdateMeta("missing.md", []MetaChange{{Key: "status", Op: MetaSet, Value: "accepted"}}, false)
+	assert.Error(t, err)
+}
+
+func TestNoteService_ReadMeta(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "note.md", "+++\ntitle = \"Hello\"\n+++\nBody\n")
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+
+	frontmatter, err := notes.ReadMeta("note.md")
+	require.NoError(t, err)
+	assert.Equal(t, core.FrontmatterTOML, frontmatter.Format)
+	metadata, err := frontmatter.Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "Hello"}, metadata)
+
+	_, err = notes.ReadMeta("missing.md")
+	assert.ErrorContains(t, err, "failed to read note")
+}
+
+func TestNoteService_SelectNotes(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "bugs/b.md", "---\nstatus: open\n---\n")
+	testutil.WriteNote(t, root, "bugs/a.md", "---\nstatus: open\n---\n")
+	testutil.WriteNote(t, root, "bugs/c.md", "---\nstatus: closed\n---\n")
+	testutil.WriteNote(t, root, "readme.md", "---\nstatus: open\n---\n")
+	// The --sql row limit doesn't apply to the selection
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{SQLMaxRows: 1}), root)
+
+	relatives, err := notes.SelectNotes(context.Background(), "metadata['status'] = 'open' AND relative LIKE 'bugs/%'")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bugs/a.md", "bugs/b.md"}, relatives)
+
+	relatives, err = notes.SelectNotes(context.Background(), "false")
+	require.NoError(t, err)
+	assert.Empty(t, relatives)
+
+	_, err = notes.SelectNotes(context.Background(), "no_such_column = 1")
+	assert.ErrorContains(t, err, "failed to select notes")
+}
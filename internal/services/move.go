@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// MovePlan is a note move and the link updates that go with it.
+type MovePlan struct {
+	// From and To are the note's absolute paths before and after the move
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Edits are the notes whose links change, including the moved note
+	Edits []NoteEdit `json:"edits"`
+	// Warnings are links that can't follow the note, e.g. wikilinks across notebooks
+	Warnings []string `json:"warnings,omitempty"`
+
+	root string
+}
+
+// NoteEdit is new content for a note.
+type NoteEdit struct {
+	// Path is where the content is written, the destination for the moved note
+	Path     string `json:"path"`
+	Relative string `json:"relative"`
+	// Links is the number of links rewritten
+	Links  int    `json:"links"`
+	Before string `json:"-"`
+	After  string `json:"-"`
+}
+
+// LinkCount returns the number of links the plan rewrites.
+func (p *MovePlan) LinkCount() int {
+	count := 0
+	for _, edit := range p.Edits {
+		count += edit.Links
+	}
+	return count
+}
+
+// PlanMove works out moving note to dest, an absolute path either inside the
+// notebook or in another notebook, and rewriting the markdown links and
+// wikilinks that point at it. The moved note's own relative links are
+// rewritten so they still resolve from its new directory. Nothing is changed
+// until the plan is applied.
+func (s *NoteService) PlanMove(ctx context.Context, note *Note, dest string) (*MovePlan, error) {
+	src := note.File.Filepath
+	dest = filepath.Clean(dest)
+	if dest == src {
+		return nil, fmt.Errorf("%s is already at %s", note.File.Relative, dest)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil, fmt.Errorf("destination already exists: %s", dest)
+	}
+
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	relatives := make([]string, len(notes))
+	for i, n := range notes {
+		relatives[i] = n.File.Relative
+	}
+	resolver := newNoteResolver(s.notebookPath, relatives)
+
+	destRel, err := filepath.Rel(s.notebookPath, dest)
+	inNotebook := err == nil && destRel != ".." && !strings.HasPrefix(destRel, ".."+string(filepath.Separator))
+
+	// Wikilinks by filename only stay unambiguous if no other note shares the new name
+	unique := true
+	if inNotebook {
+		for _, rel := range resolver.names[path.Base(wikiKey(destRel))] {
+			if rel != note.File.Relative {
+				unique = false
+			}
+		}
+	}
+
+	plan := &MovePlan{From: src, To: dest, root: s.notebookPath}
+	for _, n := range notes {
+		data, err := os.ReadFile(n.File.Filepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note: %w", err)
+		}
+		before := string(data)
+
+		moved := n.File.Filepath == src
+		dir := filepath.Dir(n.File.Filepath)
+		if moved {
+			dir = filepath.Dir(dest)
+		}
+		relocated := moved && dir != filepath.Dir(src)
+		warn := func(link core.LinkRef, reason string) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s:%d: %s %s", n.File.Relative, link.Line, link.Target, reason))
+		}
+
+		edit := NoteEdit{Path: n.File.Filepath, Relative: n.File.Relative, Before: before}
+		if moved {
+			edit.Path = dest
+		}
+
+		edit.After = core.RewriteLinks(before, func(link core.LinkRef) (string, bool) {
+			var target string
+			if isMarkdownLink(link) {
+				file, ok := resolver.resolveFile(n.File.Filepath, link.Target)
+				if !ok {
+					return "", false
+				}
+				root := s.notebookPath
+				if !inNotebook {
+					root = ""
+				}
+				switch {
+				case linksTo(file, src):
+					target = linkTarget(link.Target, dir, dest, root)
+				case relocated && (!strings.HasPrefix(link.Target, "/") || !inNotebook):
+					target = linkTarget(link.Target, dir, file, root)
+				default:
+					return "", false
+				}
+			} else {
+				rel, ok := resolver.resolveWiki(link.Target)
+				switch {
+				case !ok:
+					return "", false
+				case rel == note.File.Relative && !inNotebook:
+					if !moved {
+						warn(link, "can't follow the note to another notebook")
+					}
+					return "", false
+				case rel == note.File.Relative:
+					target = wikiTarget(link.Target, destRel, unique)
+				case moved && !inNotebook:
+					warn(link, "points to a note in the old notebook")
+					return "", false
+				default:
+					return "", false
+				}
+			}
+
+			if target == link.Target {
+				return "", false
+			}
+			edit.Links++
+			return target, true
+		})
+
+		if edit.Links > 0 {
+			plan.Edits = append(plan.Edits, edit)
+		}
+	}
+
+	return plan, nil
+}
+
+// Diff returns the plan as a git-style diff: a rename of the note and a
+// unified diff of every edited note.
+func (p *MovePlan) Diff() string {
+	from, to := p.displayPath(p.From), p.displayPath(p.To)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\nrename from %s\nrename to %s\n", from, to, from, to)
+
+	// The moved note's changes belong to the rename
+	edits := make([]NoteEdit, 0, len(p.Edits))
+	for _, edit := range p.Edits {
+		if edit.Path == p.To {
+			edits = append([]NoteEdit{edit}, edits...)
+		} else {
+			edits = append(edits, edit)
+		}
+	}
+
+	for _, edit := range edits {
+		oldName, newName := edit.Relative, p.displayPath(edit.Path)
+		if edit.Path != p.To {
+			fmt.Fprintf(&b, "diff --git a/%s b/%s\n", oldName, newName)
+		}
+		b.WriteString(unifiedDiff(oldName, newName, edit.Before, edit.After))
+	}
+	return b.String()
+}
+
+// unifiedDiff returns a unified diff of a note's content before and after an
+// edit, with the note's path before and after.
+func unifiedDiff(from, to, before, after string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(before),
+		B:        diffLines(after),
+		FromFile: "a/" + from,
+		ToFile:   "b/" + to,
+		Context:  1,
+	})
+	return diff
+}
+
+// diffLines splits content into lines for a diff, ending each with a newline.
+func diffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// displayPath returns path relative to the notebook root, or absolute when
+// it's outside the notebook.
+func (p *MovePlan) displayPath(file string) string {
+	rel, err := filepath.Rel(p.root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Apply moves the note and writes the edited notes. The notes are checked
+// to be writable before the note moves, and if one still can't be written
+// the notes already written are put back and the note is moved back, so a
+// failed move doesn't leave links pointing at nothing.
+func (p *MovePlan) Apply() error {
+	info, err := os.Stat(p.From)
+	if err != nil {
+		return fmt.Errorf("failed to stat note: %w", err)
+	}
+	mode := info.Mode().Perm()
+	for _, edit := range p.Edits {
+		if edit.Path == p.To {
+			continue
+		}
+		file, err := os.OpenFile(edit.Path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("failed to update links in %s: %w", edit.Relative, err)
+		}
+		// Nothing has changed yet, so a failed close just stops the move
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to update links in %s: %w", edit.Relative, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p.To), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if _, err := os.Stat(p.To); err == nil {
+		return fmt.Errorf("destination already exists: %s", p.To)
+	}
+
+	if err := moveFile(p.From, p.To, mode); err != nil {
+		return fmt.Errorf("failed to move note: %w", err)
+	}
+
+	for i, edit := range p.Edits {
+		if err := os.WriteFile(edit.Path, []byte(edit.After), mode); err != nil {
+			return p.undo(p.Edits[:i], mode, fmt.Errorf("failed to update links in %s: %w", edit.Relative, err))
+		}
+	}
+	return nil
+}
+
+// undo puts back the notes in written and moves the note back to where it
+// was, after err stopped the plan being applied.
+func (p *MovePlan) undo(written []NoteEdit, mode os.FileMode, err error) error {
+	var failed []string
+	for _, edit := range written {
+		if writeErr := os.WriteFile(edit.Path, []byte(edit.Before), mode); writeErr != nil {
+			failed = append(failed, p.displayPath(edit.Path))
+		}
+	}
+	if moveErr := moveFile(p.To, p.From, mode); moveErr != nil {
+		failed = append(failed, p.displayPath(p.To))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w; putting back %s also failed", err, strings.Join(failed, ", "))
+	}
+	return fmt.Errorf("%w; the move was undone", err)
+}
+
+// moveFile renames from to to, copying it when the rename fails, as it does
+// across filesystems, e.g. into a notebook on another disk.
+func moveFile(from, to string, mode os.FileMode) error {
+	err := os.Rename(from, to)
+	if err == nil {
+		return nil
+	}
+	data, readErr := os.ReadFile(from)
+	if readErr != nil {
+		return err
+	}
+	if err := os.WriteFile(to, data, mode); err != nil {
+		return err
+	}
+	if err := os.Remove(from); err != nil {
+		return fmt.Errorf("failed to remove %s after copying it: %w", from, err)
+	}
+	return nil
+}
+
+// MoveDestination returns the absolute path a move to dest puts the note
+// filename at. dest is relative to root unless absolute, and must be inside
+// root. A dest that ends in / or names a directory keeps the filename, and
+// .md is added when missing.
+func MoveDestination(root, dest, filename string) (string, error) {
+	if dest == "" {
+		return "", fmt.Errorf("destination is required")
+	}
+
+	isDir := strings.HasSuffix(dest, "/") || strings.HasSuffix(dest, string(filepath.Separator))
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(root, dest)
+	}
+	dest = filepath.Clean(dest)
+
+	rel, err := filepath.Rel(root, dest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("destination %s is outside the notebook %s", dest, root)
+	}
+
+	if info, err := os.Stat(dest); isDir || rel == "." || (err == nil && info.IsDir()) {
+		dest = filepath.Join(dest, filepath.Base(filename))
+	}
+	if !strings.HasSuffix(dest, ".md") {
+		dest += ".md"
+	}
+	return dest, nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+const moveReleaseNote = `---
+title: Release Plan
+---
+# Release
+
+See [spec](specs/spec.md) and [log](../daily-log.md).
+![chart](img/chart.png) [web](https://example.com) [top](#release)
+`
+
+const moveDailyLog = "Today: [[projects/release|plan]], [plan](projects/release.md#goals), [again](/projects/release.md)\n" +
+	"[bracketed](<projects/release.md>) `[code](projects/release.md)`\n" +
+	"\n[ref]: projects/release.md\n"
+
+// moveNotes is a notebook with links to and from projects/release.md.
+var moveNotes = map[string]string{
+	"projects/release.md":    moveReleaseNote,
+	"projects/specs/spec.md": "Back to [plan](../release.md) and [[release]].\n",
+	"daily-log.md":           moveDailyLog,
+	"unrelated.md":           "Nothing to see, [[daily-log]].\n",
+}
+
+func TestNoteService_PlanMove(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "projects/release.md")
+	require.NoError(t, err)
+
+	dest := filepath.Join(root, "archive", "2025", "release.md")
+	plan, err := notes.PlanMove(ctx, note, dest)
+	require.NoError(t, err)
+
+	assert.Equal(t, note.File.Filepath, plan.From)
+	assert.Equal(t, dest, plan.To)
+	assert.Empty(t, plan.Warnings)
+	assert.Equal(t, 9, plan.LinkCount())
+	require.Len(t, plan.Edits, 3)
+
+	// Nothing changes until the plan is applied
+	assert.Equal(t, moveDailyLog, testutil.ReadNote(t, root, "daily-log.md"))
+	assert.NoFileExists(t, dest)
+
+	diff := plan.Diff()
+	assert.Contains(t, diff, "rename from projects/release.md\nrename to archive/2025/release.md\n")
+	assert.Contains(t, diff, "+[bracketed](<archive/2025/release.md>) `[code](projects/release.md)`\n")
+
+	require.NoError(t, plan.Apply())
+	assert.NoFileExists(t, note.File.Filepath)
+
+	assert.Equal(t, `---
+title: Release Plan
+---
+# Release
+
+See [spec](../../projects/specs/spec.md) and [log](../../daily-log.md).
+![chart](../../projects/img/chart.png) [web](https://example.com) [top](#release)
+`, testutil.ReadNote(t, root, "archive/2025/release.md"))
+
+	assert.Equal(t, "Today: [[archive/2025/release|plan]], [plan](archive/2025/release.md#goals), [again](/archive/2025/release.md)\n"+
+		"[bracketed](<archive/2025/release.md>) `[code](projects/release.md)`\n"+
+		"\n[ref]: archive/2025/release.md\n", testutil.ReadNote(t, root, "daily-log.md"))
+
+	// Wikilinks by filename still resolve while the name is unique
+	assert.Equal(t, "Back to [plan](../../archive/2025/release.md) and [[release]].\n", testutil.ReadNote(t, root, "projects/specs/spec.md"))
+	assert.Equal(t, "Nothing to see, [[daily-log]].\n", testutil.ReadNote(t, root, "unrelated.md"))
+}
+
+func TestNoteService_PlanMove_Rename(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "daily-log")
+	require.NoError(t, err)
+
+	plan, err := notes.PlanMove(ctx, note, filepath.Join(root, "journal.md"))
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply())
+
+	// The moved note's links are untouched when it stays in the same directory
+	assert.Equal(t, moveDailyLog, testutil.ReadNote(t, root, "journal.md"))
+	assert.Equal(t, "Nothing to see, [[journal]].\n", testutil.ReadNote(t, root, "unrelated.md"))
+	assert.Contains(t, testutil.ReadNote(t, root, "projects/release.md"), "[log](../journal.md)")
+}
+
+func TestNoteService_PlanMove_AmbiguousFilename(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	testutil.WriteNote(t, root, "archive/spec.md", "Old spec\n")
+	testutil.WriteNote(t, root, "index.md", "[[daily-log]]\n")
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "daily-log.md")
+	require.NoError(t, err)
+
+	// Another note is already called spec, so the wikilink needs the path
+	plan, err := notes.PlanMove(ctx, note, filepath.Join(root, "projects", "spec.md"))
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply())
+
+	assert.Equal(t, "[[projects/spec]]\n", testutil.ReadNote(t, root, "index.md"))
+}
+
+func TestNoteService_PlanMove_OtherNotebook(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	other := t.TempDir()
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "projects/release.md")
+	require.NoError(t, err)
+
+	dest := filepath.Join(other, "inbox", "release.md")
+	plan, err := notes.PlanMove(ctx, note, dest)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"daily-log.md:1: projects/release can't follow the note to another notebook",
+		"projects/specs/spec.md:1: release can't follow the note to another notebook",
+	}, plan.Warnings)
+
+	require.NoError(t, plan.Apply())
+	assert.FileExists(t, dest)
+
+	// Markdown links still resolve on disk
+	log := testutil.ReadNote(t, root, "daily-log.md")
+	rel, err := filepath.Rel(root, dest)
+	require.NoError(t, err)
+	assert.Contains(t, log, "[plan]("+filepath.ToSlash(rel)+"#goals)")
+	assert.Contains(t, log, "[again]("+filepath.ToSlash(rel)+")")
+	assert.Contains(t, log, "[[projects/release|plan]]")
+
+	moved := testutil.ReadNote(t, other, "inbox/release.md")
+	specRel, err := filepath.Rel(filepath.Dir(dest), filepath.Join(root, "projects", "specs", "spec.md"))
+	require.NoError(t, err)
+	assert.Contains(t, moved, "[spec]("+filepath.ToSlash(specRel)+")")
+}
+
+func TestNoteService_PlanMove_ApplyFails(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "projects/release.md")
+	require.NoError(t, err)
+
+	dest := filepath.Join(root, "archive", "release.md")
+	plan, err := notes.PlanMove(ctx, note, dest)
+	require.NoError(t, err)
+
+	// A linking note that can't be written stops the move before it starts
+	log := filepath.Join(root, "daily-log.md")
+	require.NoError(t, os.Remove(log))
+	require.NoError(t, os.Mkdir(log, 0755))
+
+	err = plan.Apply()
+	assert.ErrorContains(t, err, "failed to update links in daily-log.md")
+	assert.FileExists(t, note.File.Filepath)
+	assert.NoFileExists(t, dest)
+	assert.Equal(t, "Back to [plan](../release.md) and [[release]].\n", testutil.ReadNote(t, root, "projects/specs/spec.md"))
+}
+
+func TestMovePlan_Undo(t *testing.T) {
+	root := t.TempDir()
+	from := filepath.Join(root, "release.md")
+	to := testutil.WriteNote(t, root, "archive/release.md", "Moved\n")
+	log := testutil.WriteNote(t, root, "log.md", "[plan](archive/release.md)\n")
+
+	plan := &MovePlan{From: from, To: to, root: root}
+	written := []NoteEdit{
+		{Path: to, Relative: "release.md", Before: "Original\n", After: "Moved\n"},
+		{Path: log, Relative: "log.md", Before: "[plan](release.md)\n", After: "[plan](archive/release.md)\n"},
+	}
+
+	err := plan.undo(written, 0644, errors.New("failed to update links in index.md"))
+	assert.EqualError(t, err, "failed to update links in index.md; the move was undone")
+	assert.NoFileExists(t, to)
+	assert.Equal(t, "Original\n", testutil.ReadNote(t, root, "release.md"))
+	assert.Equal(t, "[plan](release.md)\n", testutil.ReadNote(t, root, "log.md"))
+}
+
+func TestNoteService_PlanMove_Errors(t *testing.T) {
+	notes, root := newTestNoteService(t, moveNotes)
+	ctx := context.Background()
+
+	note, err := notes.FindNote(ctx, "daily-log")
+	require.NoError(t, err)
+
+	_, err = notes.PlanMove(ctx, note, note.File.Filepath)
+	assert.ErrorContains(t, err, "already at")
+
+	_, err = notes.PlanMove(ctx, note, filepath.Join(root, "unrelated.md"))
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestMoveDestination(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "archive"), 0755))
+	filename := filepath.Join(root, "projects", "release.md")
+
+	tests := []struct {
+		dest     string
+		expected string
+	}{
+		{"renamed", filepath.Join(root, "renamed.md")},
+		{"notes/renamed.md", filepath.Join(root, "notes", "renamed.md")},
+		{"archive", filepath.Join(root, "archive", "release.md")},
+		{"new-dir/", filepath.Join(root, "new-dir", "release.md")},
+		{".", filepath.Join(root, "release.md")},
+		{filepath.Join(root, "abs.md"), filepath.Join(root, "abs.md")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			dest, err := MoveDestination(root, tt.dest, filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dest)
+		})
+	}
+
+	_, err := MoveDestination(root, "../outside.md", filename)
+	assert.ErrorContains(t, err, "outside the notebook")
+
+	_, err = MoveDestination(root, "", filename)
+	assert.Error(t, err)
+}
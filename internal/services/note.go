@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path"
 	"path/filepath"
-	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -22,6 +24,18 @@ type Note struct {
 	} `json:"file"`
 	Content  string         `json:"content"`
 	Metadata map[string]any `json:"metadata"`
+	// Groups are the names of the notebook groups whose globs match the note
+	Groups []string `json:"groups,omitempty"`
+	// Tags are the note's frontmatter tags and #hashtags, normalised and sorted
+	Tags []string `json:"tags"`
+	// LinkCount is the number of links to existing notes in the note
+	LinkCount int `json:"link_count"`
+	// BacklinkCount is the number of links to the note from other notes
+	BacklinkCount int `json:"backlink_count"`
+	// Score is the search relevance of the note (set by ranked searches only)
+	Score float64 `json:"score,omitempty"`
+	// Snippet is an excerpt around the search match with terms in **bold**
+	Snippet string `json:"snippet,omitempty"`
 }
 
 // DisplayName returns the display name for the note.
@@ -48,7 +62,14 @@ type NoteService struct {
 	configService *ConfigService
 	dbService     *DbService
 	notebookPath  string
-	log           zerolog.Logger
+	// groups are the notebook's groups, used for the groups column of the notes view
+	groups []NotebookGroup
+	// schema is the notebook's frontmatter schema, checked by Lint
+	schema core.FrontmatterSchema
+	// queries are the notebook's saved queries, also created as views
+	queries    []SavedQuery
+	queryViews sync.Once
+	log        zerolog.Logger
 }
 
 // NewNoteService creates a note service for a notebook.
@@ -61,23 +82,149 @@ func NewNoteService(cfg *ConfigService, db *DbService, notebookPath string) *Not
 	}
 }
 
-// SearchNotes returns all notes in the notebook matching the query.
+// SearchNotes returns the notes in the notebook matching the query.
+// An empty query returns every note in path order.
+//
+// The query may contain field filters (tag:work, title:"release plan",
+// -status:done, created:>2025-01-01, path:projects/*), see
+// core.ParseSearchQuery. Any remaining text is a full-text search: all words
+// (stemmed) and "quoted phrases" must appear in the note's title, path or
+// content, and results are ranked by BM25 score. Without text, filtered notes
+// are returned in path order.
 func (s *NoteService) SearchNotes(ctx context.Context, query string) ([]Note, error) {
 	if s.notebookPath == "" {
 		return nil, fmt.Errorf("no notebook selected")
 	}
 
+	parsed, err := core.ParseSearchQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search query: %w", err)
+	}
+
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notes = filterNotes(notes, parsed)
+
+	switch {
+	case parsed.Text == "":
+	case parsed.FullText.Empty():
+		// Nothing to rank on (e.g. only punctuation), fall back to a substring match
+		notes = filterNotesBySubstring(notes, parsed.Text)
+	default:
+		ranked := rankNotes(notes, parsed.FullText)
+		if len(ranked) == 0 {
+			// Words can match inside other words ("eeting" in "meeting"),
+			// which ranking doesn't find
+			ranked = filterNotesBySubstring(notes, parsed.Text)
+		}
+		notes = ranked
+	}
+
+	s.log.Debug().Str("query", query).Int("count", len(notes)).Msg("notes found")
+	return notes, nil
+}
+
+// ErrNoteNotFound is returned by FindNote when no note matches.
+var ErrNoteNotFound = errors.New("note not found")
+
+// noteMatchers are the ways FindNote matches a reference, in order of
+// preference. ref is already trimmed and has forward slashes.
+var noteMatchers = []func(note *Note, ref string) bool{
+	// Relative path, the .md is optional
+	func(note *Note, ref string) bool {
+		return note.File.Relative == ref || note.File.Relative == ref+".md"
+	},
+	// Filename
+	func(note *Note, ref string) bool {
+		name := path.Base(note.File.Relative)
+		return name == ref || name == ref+".md"
+	},
+	// Slug of the filename or title
+	func(note *Note, ref string) bool {
+		slug := core.Slugify(strings.TrimSuffix(ref, ".md"))
+		return slug != "" && (core.Slugify(strings.TrimSuffix(path.Base(note.File.Relative), ".md")) == slug ||
+			core.Slugify(note.DisplayName()) == slug)
+	},
+	// Title
+	func(note *Note, ref string) bool {
+		return strings.EqualFold(note.DisplayName(), ref)
+	},
+}
+
+// FindNote returns the note ref refers to. ref may be a path relative to the
+// notebook root or an absolute path (the .md extension is optional), a
+// filename, the slug of a filename or title, or a title ignoring case. Matches
+// are tried in that order, and it's an error if the first kind of match finds
+// more than one note.
+func (s *NoteService) FindNote(ctx context.Context, ref string) (*Note, error) {
+	if s.notebookPath == "" {
+		return nil, fmt.Errorf("no notebook selected")
+	}
+
+	ref = strings.TrimSpace(ref)
+	if filepath.IsAbs(ref) {
+		if rel, err := filepath.Rel(s.notebookPath, ref); err == nil {
+			ref = rel
+		}
+	}
+	ref = filepath.ToSlash(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("%w: empty reference", ErrNoteNotFound)
+	}
+
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, matches := range noteMatchers {
+		var found []*Note
+		for i := range notes {
+			if matches(&notes[i], ref) {
+				found = append(found, &notes[i])
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			paths := make([]string, len(found))
+			for i, note := range found {
+				paths[i] = note.File.Relative
+			}
+			return nil, fmt.Errorf("%q matches %d notes: %s", ref, len(found), strings.Join(paths, ", "))
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoteNotFound, ref)
+}
+
+// loadNotes returns every note from the refreshed index, ordered by path.
+func (s *NoteService) loadNotes(ctx context.Context) ([]Note, error) {
+	index, _, err := s.refreshIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := s.dbService.GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	glob := filepath.Join(s.notebookPath, "**", "*.md")
-	s.log.Debug().Str("glob", glob).Str("query", query).Msg("searching notes")
+	s.log.Debug().Str("index", index.Path()).Msg("loading notes")
 
-	// Use DuckDB's read_markdown function with filepath included
-	sqlQuery := `SELECT * FROM read_markdown(?, include_filepath:=true)`
-	rows, err := db.QueryContext(ctx, sqlQuery, glob)
+	sqlQuery := fmt.Sprintf(`SELECT n.filepath, n.relative, n.content, n.frontmatter::VARCHAR,
+			(SELECT count(*) FROM %[2]s l WHERE l.source = n.relative AND l.target IS NOT NULL),
+			(SELECT count(*) FROM %[2]s l WHERE l.target = n.relative AND l.source <> n.relative)
+		FROM %[1]s n
+		ORDER BY n.filepath`, index.Table(), index.LinksTable())
+	rows, err := db.QueryContext(ctx, sqlQuery)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -88,75 +235,23 @@ func (s *NoteService) SearchNotes(ctx context.Context, query string) ([]Note, er
 	}()
 
 	var notes []Note
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-
 	for rows.Next() {
-		// Create slice of interface{} to hold values
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
+		var note Note
+		var frontmatter sql.NullString
 
-		if err := rows.Scan(valuePtrs...); err != nil {
+		if err := rows.Scan(&note.File.Filepath, &note.File.Relative, &note.Content, &frontmatter, &note.LinkCount, &note.BacklinkCount); err != nil {
 			s.log.Warn().Err(err).Msg("failed to scan row")
 			continue
 		}
 
-		// Map columns to Note struct
-		note := Note{
-			Metadata: make(map[string]any),
-		}
-
-		for i, col := range columns {
-			val := values[i]
-			switch col {
-			case "filepath", "file_path", "filename":
-				if v, ok := val.(string); ok {
-					note.File.Filepath = v
-					note.File.Relative = strings.TrimPrefix(v, s.notebookPath+"/")
-				}
-			case "content", "body":
-				if v, ok := val.(string); ok {
-					note.Content = v
-				}
-			case "metadata":
-				// metadata column contains a DuckDB MAP with frontmatter data
-				// The type might be duckdb.Map or map[any]any
-				// Try to handle it as a map type by using reflection if needed
-				rv := reflect.ValueOf(val)
-				if rv.Kind() == reflect.Map {
-					// It's some kind of map - iterate over it
-					for _, key := range rv.MapKeys() {
-						if keyStr, ok := key.Interface().(string); ok {
-							note.Metadata[keyStr] = rv.MapIndex(key).Interface()
-						}
-					}
-				} else if v, ok := val.(map[any]any); ok {
-					for k, val := range v {
-						if keyStr, ok := k.(string); ok {
-							note.Metadata[keyStr] = val
-						}
-					}
-				} else if v, ok := val.(map[string]any); ok {
-					note.Metadata = v
-				}
-			default:
-				note.Metadata[col] = val
-			}
-		}
-
-		// Filter by query if provided
-		if query != "" {
-			// Simple contains check on content and filepath
-			if !strings.Contains(strings.ToLower(note.Content), strings.ToLower(query)) &&
-				!strings.Contains(strings.ToLower(note.File.Filepath), strings.ToLower(query)) {
-				continue
+		note.Groups = groupsOf(s.groups, note.File.Relative)
+		note.Metadata = make(map[string]any)
+		if frontmatter.Valid && frontmatter.String != "" {
+			if err := json.Unmarshal([]byte(frontmatter.String), &note.Metadata); err != nil {
+				s.log.Warn().Err(err).Str("path", note.File.Filepath).Msg("failed to decode metadata")
 			}
 		}
+		note.Tags = core.NoteTags(note.Metadata, note.Content)
 
 		notes = append(notes, note)
 	}
@@ -165,25 +260,42 @@ func (s *NoteService) SearchNotes(ctx context.Context, query string) ([]Note, er
 		return nil, err
 	}
 
-	s.log.Debug().Int("count", len(notes)).Msg("notes found")
 	return notes, nil
 }
 
+// filterNotesBySubstring keeps notes whose content or path contains query,
+// ignoring case.
+func filterNotesBySubstring(notes []Note, query string) []Note {
+	query = strings.ToLower(query)
+
+	var matched []Note
+	for _, note := range notes {
+		if strings.Contains(strings.ToLower(note.Content), query) ||
+			strings.Contains(strings.ToLower(note.File.Filepath), query) {
+			matched = append(matched, note)
+		}
+	}
+	return matched
+}
+
 // Count returns the number of notes in the notebook.
 func (s *NoteService) Count(ctx context.Context) (int, error) {
 	if s.notebookPath == "" {
 		return 0, nil
 	}
 
-	db, err := s.dbService.GetDB(ctx)
+	index, _, err := s.refreshIndex(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	glob := filepath.Join(s.notebookPath, "**", "*.md")
+	db, err := s.dbService.GetDB(ctx)
+	if err != nil {
+		return 0, err
+	}
 
 	var count int
-	row := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM read_markdown(?)`, glob)
+	row := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, index.Table()))
 	if err := row.Scan(&count); err != nil {
 		return 0, err
 	}
@@ -191,9 +303,41 @@ func (s *NoteService) Count(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// ValidateSQL validates a user-provided SQL query for safety.
-// Only SELECT and WITH (CTE) queries are allowed.
-// Dangerous keywords (DROP, DELETE, UPDATE, etc.) are blocked.
+// refreshIndex brings the notebook's persistent index up to date.
+func (s *NoteService) refreshIndex(ctx context.Context) (*NoteIndex, IndexStats, error) {
+	index := s.dbService.NoteIndex(s.notebookPath)
+
+	stats, err := index.Refresh(ctx)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to refresh note index: %w", err)
+	}
+
+	if stats.Changed() {
+		s.log.Debug().
+			Int("added", stats.Added).
+			Int("updated", stats.Updated).
+			Int("removed", stats.Removed).
+			Int("total", stats.Total).
+			Msg("note index refreshed")
+	}
+
+	return index, stats, nil
+}
+
+// Watch blocks until ctx is cancelled, keeping the notebook index up to date
+// and calling handler for every note that is created, modified, renamed or deleted.
+func (s *NoteService) Watch(ctx context.Context, handler func(WatchEvent)) error {
+	if s.notebookPath == "" {
+		return fmt.Errorf("no notebook selected")
+	}
+
+	watcher := NewNoteWatcher(s.dbService.NoteIndex(s.notebookPath), s.notebookPath)
+	return watcher.Watch(ctx, handler)
+}
+
+// ValidateSQL is a quick check that a user-provided SQL query is a query.
+// Only SELECT and WITH (CTE) queries are allowed. ExecuteSQLSafe also has
+// DuckDB parse the query, which is what actually rejects other statements.
 func ValidateSQL(query string) error {
 	// Trim and normalize to uppercase
 	normalized := strings.TrimSpace(strings.ToUpper(query))
@@ -207,60 +351,59 @@ func ValidateSQL(query string) error {
 		return fmt.Errorf("only SELECT queries are allowed")
 	}
 
-	// Dangerous keywords blocklist - check with word boundaries
-	// Split query by spaces and other delimiters to find keywords
-	tokens := strings.FieldsFunc(normalized, func(r rune) bool {
-		return r == ' ' || r == '\t' || r == '\n' || r == '(' || r == ')' ||
-			r == ',' || r == ';' || r == '=' || r == '<' || r == '>'
-	})
-
-	dangerous := map[string]bool{
-		"DROP":     true,
-		"DELETE":   true,
-		"UPDATE":   true,
-		"INSERT":   true,
-		"ALTER":    true,
-		"CREATE":   true,
-		"TRUNCATE": true,
-		"REPLACE":  true,
-		"ATTACH":   true,
-		"DETACH":   true,
-		"PRAGMA":   true,
-	}
-
-	for _, token := range tokens {
-		if dangerous[token] {
-			return fmt.Errorf("keyword '%s' is not allowed", token)
-		}
-	}
-
 	return nil
 }
 
 // ExecuteSQLSafe executes a user-provided SQL query safely.
-// Validates the query, executes with a 30-second timeout on a read-only connection,
-// and returns results as maps.
-func (s *NoteService) ExecuteSQLSafe(ctx context.Context, query string) ([]map[string]any, error) {
+// The query runs in a sandboxed database that can only read files in the
+// notebook, must parse as a single SELECT statement, has a 30-second timeout,
+// and returns at most the configured number of rows with columns in SELECT order.
+func (s *NoteService) ExecuteSQLSafe(ctx context.Context, query string) (*ResultSet, error) {
+	return s.querySandbox(ctx, query, s.dbService.sqlMaxRows())
+}
+
+// querySandbox runs query the way ExecuteSQLSafe does, returning at most
+// maxRows rows, or every row when maxRows is zero. Internal queries built
+// around a user predicate use it to select from the whole notebook.
+func (s *NoteService) querySandbox(ctx context.Context, query string, maxRows int) (*ResultSet, error) {
 	// 1. Validate query
 	if err := ValidateSQL(query); err != nil {
 		s.log.Warn().Err(err).Msg("SQL query validation failed")
 		return nil, fmt.Errorf("invalid query: %w", err)
 	}
 
-	// 2. Get read-only connection
-	db, err := s.dbService.GetReadOnlyDB(ctx)
+	// 2. Get the notebook's sandboxed connection
+	db, err := s.dbService.GetReadOnlyDB(ctx, s.notebookPath)
 	if err != nil {
 		s.log.Error().Err(err).Msg("failed to get read-only database connection")
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
-	// 3. Create context with 30-second timeout
+	// 3. Expose the notebook index as the "notebook" catalog, and the notes views
+	if s.notebookPath != "" {
+		attached := s.attachIndex(ctx, db)
+		if err := s.createViews(ctx, db, attached); err != nil {
+			s.log.Error().Err(err).Msg("failed to create notebook views")
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+	}
+
+	// 4. Create context with 30-second timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	// 5. Check the statement type with DuckDB's parser
+	if err := checkStatement(timeoutCtx, db, query); err != nil {
+		s.log.Warn().Err(err).Msg("SQL query validation failed")
+		if errors.Is(err, errOnlySelect) || errors.Is(err, errSingleStatement) {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+
 	s.log.Debug().Str("query", query).Msg("executing SQL query")
 
-	// 4. Execute query
+	// 6. Execute query
 	rows, err := db.QueryContext(timeoutCtx, query)
 	if err != nil {
 		s.log.Error().Err(err).Str("query", query).Msg("query execution failed")
@@ -272,54 +415,52 @@ func (s *NoteService) ExecuteSQLSafe(ctx context.Context, query string) ([]map[s
 		}
 	}()
 
-	// 5. Convert rows to maps using local implementation
-	results, err := s.rowsToMaps(rows)
+	// 7. Read the result set, up to the row limit
+	results, err := readResultSet(rows, maxRows)
 	if err != nil {
 		s.log.Error().Err(err).Msg("failed to scan query results")
 		return nil, fmt.Errorf("failed to read results: %w", err)
 	}
 
-	s.log.Debug().Int("rows", len(results)).Msg("query executed successfully")
+	if results.Truncated {
+		s.log.Debug().Int("rows", results.Len()).Msg("query results truncated at row limit")
+	}
+	s.log.Debug().Int("rows", results.Len()).Msg("query executed successfully")
 	return results, nil
 }
 
-// Query executes a raw SQL query.
-func (s *NoteService) Query(ctx context.Context, sql string) ([]map[string]any, error) {
-	return s.dbService.Query(ctx, sql)
-}
+// IndexCatalog is the catalog name the notebook index is attached as for
+// user queries, e.g. SELECT * FROM notebook.notes.
+const IndexCatalog = "notebook"
 
-// rowsToMaps converts sql.Rows to a slice of maps.
-func (s *NoteService) rowsToMaps(rows *sql.Rows) ([]map[string]any, error) {
-	columns, err := rows.Columns()
+// attachIndex refreshes the notebook index and attaches it read-only to db,
+// reporting whether it is attached. Failures are logged and leave user
+// queries to read_markdown.
+func (s *NoteService) attachIndex(ctx context.Context, db *sql.DB) bool {
+	index, stats, err := s.refreshIndex(ctx)
 	if err != nil {
-		return nil, err
+		s.log.Warn().Err(err).Msg("note index unavailable")
+		return false
+	}
+	if !index.Persistent() {
+		return false
 	}
 
-	var results []map[string]any
-
-	for rows.Next() {
-		// Create slice of interface{} to hold values
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, err
-		}
-
-		// Create map for this row
-		row := make(map[string]any)
-		for i, col := range columns {
-			row[col] = values[i]
+	// A read-only attachment doesn't see later writes, so re-attach after changes
+	if stats.Changed() {
+		if err := s.dbService.detach(ctx, db, IndexCatalog); err != nil {
+			s.log.Warn().Err(err).Msg("failed to detach note index")
 		}
-		results = append(results, row)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if err := s.dbService.attach(ctx, db, IndexCatalog, index.Path(), true); err != nil {
+		s.log.Warn().Err(err).Msg("failed to attach note index")
+		return false
 	}
+	return true
+}
 
-	return results, nil
+// Query executes a raw SQL query.
+func (s *NoteService) Query(ctx context.Context, sql string) (*ResultSet, error) {
+	return s.dbService.Query(ctx, sql)
 }
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findNotes is a notebook with notes found by path, filename, slug or title,
+// and two notes sharing a filename.
+var findNotes = map[string]string{
+	"notes/release.md":             "---\ntitle: Release Plan\n---\n# Release\n",
+	"notes/Meeting Notes.md":       "# Meeting\n",
+	"notes/projects/alpha/todo.md": "# Alpha todo\n",
+	"notes/projects/beta/todo.md":  "# Beta todo\n",
+}
+
+func TestNoteService_FindNote(t *testing.T) {
+	svc, _ := newTestNoteService(t, findNotes)
+	ctx := context.Background()
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"notes/release.md", "notes/release.md"},
+		{"notes/release", "notes/release.md"},
+		{"release.md", "notes/release.md"},
+		{"release", "notes/release.md"},
+		{"Meeting Notes", "notes/Meeting Notes.md"},
+		{"meeting-notes", "notes/Meeting Notes.md"},
+		{"release-plan", "notes/release.md"},
+		{"release plan", "notes/release.md"},
+		{"notes/projects/beta/todo", "notes/projects/beta/todo.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			note, err := svc.FindNote(ctx, tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, note.File.Relative)
+		})
+	}
+}
+
+func TestNoteService_FindNote_AbsolutePath(t *testing.T) {
+	svc, _ := newTestNoteService(t, findNotes)
+	ctx := context.Background()
+
+	note, err := svc.FindNote(ctx, "release")
+	require.NoError(t, err)
+
+	found, err := svc.FindNote(ctx, note.File.Filepath)
+	require.NoError(t, err)
+	assert.Equal(t, note.File.Relative, found.File.Relative)
+}
+
+func TestNoteService_FindNote_Ambiguous(t *testing.T) {
+	svc, _ := newTestNoteService(t, findNotes)
+
+	_, err := svc.FindNote(context.Background(), "todo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"todo" matches 2 notes: notes/projects/alpha/todo.md, notes/projects/beta/todo.md`)
+}
+
+func TestNoteService_FindNote_NotFound(t *testing.T) {
+	svc, _ := newTestNoteService(t, findNotes)
+
+	_, err := svc.FindNote(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+
+	_, err = svc.FindNote(context.Background(), "  ")
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+}
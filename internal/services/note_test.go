@@ -3,6 +3,7 @@ package services_test
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -152,12 +153,9 @@ func TestNoteService_SearchNotes_EmptyNotebook(t *testing.T) {
 
 	svc := services.NewNoteService(cfg, db, notebookDir)
 
-	// Note: DuckDB's read_markdown errors when no files match the glob.
-	// This tests the current behavior - the service returns an error for empty notebooks.
 	notes, err := svc.SearchNotes(ctx, "")
-	assert.Error(t, err)
-	assert.Nil(t, notes)
-	assert.Contains(t, err.Error(), "File or directory does not exist")
+	require.NoError(t, err)
+	assert.Empty(t, notes)
 }
 
 func TestNoteService_SearchNotes_ExtractsMetadata(t *testing.T) {
@@ -277,12 +275,9 @@ func TestNoteService_Count_EmptyNotebook(t *testing.T) {
 
 	svc := services.NewNoteService(cfg, db, notebookDir)
 
-	// Note: DuckDB's read_markdown errors when no files match the glob.
-	// This tests the current behavior - Count returns an error for empty notebooks.
 	count, err := svc.Count(ctx)
-	assert.Error(t, err)
+	require.NoError(t, err)
 	assert.Equal(t, 0, count)
-	assert.Contains(t, err.Error(), "File or directory does not exist")
 }
 
 func TestNoteService_Query_ExecutesSQL(t *testing.T) {
@@ -301,8 +296,8 @@ func TestNoteService_Query_ExecutesSQL(t *testing.T) {
 	results, err := svc.Query(ctx, "SELECT 42 as answer")
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	assert.Equal(t, int32(42), results[0]["answer"])
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, int32(42), results.Value(0, "answer"))
 }
 
 func TestNoteService_Query_ReturnsResults(t *testing.T) {
@@ -328,8 +323,8 @@ func TestNoteService_Query_ReturnsResults(t *testing.T) {
 	results, err := svc.Query(ctx, query)
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	assert.NotNil(t, results[0]["content"])
+	require.Len(t, results.Rows, 1)
+	assert.NotNil(t, results.Value(0, "content"))
 }
 
 func TestNoteService_SearchNotes_MultipleQueryMatches(t *testing.T) {
@@ -466,81 +461,20 @@ func TestValidateSQL_InvalidQueryType(t *testing.T) {
 	}
 }
 
-func TestValidateSQL_BlockedKeyword_Drop(t *testing.T) {
-	err := services.ValidateSQL("SELECT * FROM markdown DROP TABLE temp")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "DROP")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Delete(t *testing.T) {
-	err := services.ValidateSQL("SELECT * DELETE FROM markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "DELETE")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Update(t *testing.T) {
-	err := services.ValidateSQL("SELECT * UPDATE markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "UPDATE")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Insert(t *testing.T) {
-	err := services.ValidateSQL("SELECT * INSERT INTO markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "INSERT")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Alter(t *testing.T) {
-	err := services.ValidateSQL("SELECT * ALTER TABLE markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "ALTER")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Create(t *testing.T) {
-	err := services.ValidateSQL("SELECT * CREATE TABLE markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "CREATE")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Truncate(t *testing.T) {
-	err := services.ValidateSQL("SELECT * TRUNCATE TABLE x")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "TRUNCATE")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Replace(t *testing.T) {
-	err := services.ValidateSQL("SELECT * REPLACE INTO markdown")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "REPLACE")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Attach(t *testing.T) {
-	err := services.ValidateSQL("SELECT * ATTACH DATABASE 'file.db'")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "ATTACH")
-	assert.Contains(t, err.Error(), "not allowed")
-}
-
-func TestValidateSQL_BlockedKeyword_Detach(t *testing.T) {
-	err := services.ValidateSQL("SELECT * DETACH DATABASE db")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "DETACH")
-	assert.Contains(t, err.Error(), "not allowed")
-}
+func TestValidateSQL_KeywordsInsideSelect(t *testing.T) {
+	// Statement types are checked by DuckDB's parser in ExecuteSQLSafe, so
+	// functions and column names that look like keywords are fine
+	tests := []string{
+		"SELECT replace(content, 'a', 'b') FROM markdown",
+		"SELECT * REPLACE (upper(title) AS title) FROM markdown",
+		"SELECT 'x' AS update, 1 AS delete",
+	}
 
-func TestValidateSQL_BlockedKeyword_Pragma(t *testing.T) {
-	err := services.ValidateSQL("SELECT * PRAGMA table_info")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "PRAGMA")
-	assert.Contains(t, err.Error(), "not allowed")
+	for _, query := range tests {
+		t.Run(fmt.Sprintf("keyword_%s", query[:10]), func(t *testing.T) {
+			assert.NoError(t, services.ValidateSQL(query))
+		})
+	}
 }
 
 func TestValidateSQL_CaseInsensitive(t *testing.T) {
@@ -560,15 +494,10 @@ func TestValidateSQL_CaseInsensitive(t *testing.T) {
 }
 
 func TestValidateSQL_KeywordInStrings(t *testing.T) {
-	// With word boundary checking, keywords in string literals are now allowed
-	// This is better UX - users can search for content containing these words
+	// Keywords in string literals are allowed so users can search for content
+	// containing these words
 	err := services.ValidateSQL("SELECT 'DROP' as dangerous FROM markdown")
 	assert.NoError(t, err, "keywords in string literals should be allowed")
-
-	// But if the keyword appears outside strings, it should still be blocked
-	err = services.ValidateSQL("SELECT 'drop' as text DROP TABLE markdown")
-	assert.Error(t, err, "actual DROP keyword should be blocked")
-	assert.Contains(t, err.Error(), "DROP")
 }
 
 func TestValidateSQL_ComplexValidQuery(t *testing.T) {
@@ -650,9 +579,9 @@ func TestNoteService_ExecuteSQLSafe_ValidSelect(t *testing.T) {
 	results, err := svc.ExecuteSQLSafe(ctx, "SELECT 1 as value, 'test' as message")
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	assert.Equal(t, int32(1), results[0]["value"])
-	assert.Equal(t, "test", results[0]["message"])
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, int32(1), results.Value(0, "value"))
+	assert.Equal(t, "test", results.Value(0, "message"))
 }
 
 func TestNoteService_ExecuteSQLSafe_InvalidQuery(t *testing.T) {
@@ -695,8 +624,8 @@ func TestNoteService_ExecuteSQLSafe_EmptyResult(t *testing.T) {
 	results, err := svc.ExecuteSQLSafe(ctx, "SELECT 1 WHERE 1=0")
 	require.NoError(t, err)
 
-	assert.Empty(t, results)
-	assert.Len(t, results, 0)
+	assert.Empty(t, results.Rows)
+	assert.Len(t, results.Rows, 0)
 }
 
 func TestNoteService_ExecuteSQLSafe_MultipleRows(t *testing.T) {
@@ -722,13 +651,13 @@ func TestNoteService_ExecuteSQLSafe_MultipleRows(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	require.Len(t, results, 3)
-	assert.Equal(t, int32(1), results[0]["id"])
-	assert.Equal(t, "a", results[0]["letter"])
-	assert.Equal(t, int32(2), results[1]["id"])
-	assert.Equal(t, "b", results[1]["letter"])
-	assert.Equal(t, int32(3), results[2]["id"])
-	assert.Equal(t, "c", results[2]["letter"])
+	require.Len(t, results.Rows, 3)
+	assert.Equal(t, int32(1), results.Value(0, "id"))
+	assert.Equal(t, "a", results.Value(0, "letter"))
+	assert.Equal(t, int32(2), results.Value(1, "id"))
+	assert.Equal(t, "b", results.Value(1, "letter"))
+	assert.Equal(t, int32(3), results.Value(2, "id"))
+	assert.Equal(t, "c", results.Value(2, "letter"))
 }
 
 func TestNoteService_ExecuteSQLSafe_WithClause(t *testing.T) {
@@ -757,9 +686,9 @@ func TestNoteService_ExecuteSQLSafe_WithClause(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	assert.Equal(t, int32(2), results[0]["num"])
-	assert.Equal(t, "second", results[0]["label"])
+	require.Len(t, results.Rows, 1)
+	assert.Equal(t, int32(2), results.Value(0, "num"))
+	assert.Equal(t, "second", results.Value(0, "label"))
 }
 
 func TestNoteService_ExecuteSQLSafe_InvalidSyntax(t *testing.T) {
@@ -832,14 +761,22 @@ func TestNoteService_ExecuteSQLSafe_TypeConversions(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	require.Len(t, results, 1)
-	row := results[0]
+	require.Len(t, results.Rows, 1)
 
 	// Check type conversions
-	assert.NotNil(t, row["int_val"])
-	assert.NotNil(t, row["str_val"])
-	assert.Equal(t, true, row["bool_val"])
-	assert.Nil(t, row["null_val"])
+	assert.Equal(t, int32(42), results.Value(0, "int_val"))
+	assert.Equal(t, 3.14, results.Value(0, "float_val"))
+	assert.Equal(t, "text", results.Value(0, "str_val"))
+	assert.Equal(t, true, results.Value(0, "bool_val"))
+	assert.Nil(t, results.Value(0, "null_val"))
+
+	assert.Equal(t, []services.Column{
+		{Name: "int_val", Type: "INTEGER"},
+		{Name: "float_val", Type: "DECIMAL(3,2)"},
+		{Name: "str_val", Type: "VARCHAR"},
+		{Name: "bool_val", Type: "BOOLEAN"},
+		{Name: "null_val", Type: "INTEGER"},
+	}, results.Columns)
 }
 
 func TestNoteService_ExecuteSQLSafe_ComplexQuery(t *testing.T) {
@@ -876,15 +813,15 @@ func TestNoteService_ExecuteSQLSafe_ComplexQuery(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	require.Len(t, results, 2)
+	require.Len(t, results.Rows, 2)
 	
 	// First result (n=3)
-	assert.Equal(t, int32(3), results[0]["n"])
-	assert.Equal(t, "c", results[0]["letter"])
+	assert.Equal(t, int32(3), results.Value(0, "n"))
+	assert.Equal(t, "c", results.Value(0, "letter"))
 	
 	// Second result (n=2)
-	assert.Equal(t, int32(2), results[1]["n"])
-	assert.Equal(t, "b", results[1]["letter"])
+	assert.Equal(t, int32(2), results.Value(1, "n"))
+	assert.Equal(t, "b", results.Value(1, "letter"))
 }
 
 func TestNoteService_ExecuteSQLSafe_ReadOnlyEnforcement(t *testing.T) {
@@ -914,6 +851,80 @@ func TestNoteService_ExecuteSQLSafe_ReadOnlyEnforcement(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid query")
 }
 
+func TestNoteService_ExecuteSQLSafe_ParserRejectsStatements(t *testing.T) {
+	db := services.NewDbService()
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+
+	tmpDir := t.TempDir()
+	cfg, _ := services.NewConfigServiceWithPath(tmpDir + "/config.json")
+	notebookDir := testutil.CreateTestNotebook(t, tmpDir, "test-notebook")
+
+	svc := services.NewNoteService(cfg, db, notebookDir)
+
+	tests := map[string]string{
+		"SELECT 1; SELECT 2":                     "invalid query: only a single statement is allowed",
+		"SELECT 1; DROP TABLE markdown":          "invalid query: only SELECT queries are allowed",
+		"SELECT 1; COPY (SELECT 1) TO 'out.csv'": "invalid query: only SELECT queries are allowed",
+		"SELECT * REPLACE INTO markdown":         "query execution failed: Parser Error",
+	}
+
+	for query, expected := range tests {
+		t.Run(query, func(t *testing.T) {
+			_, err := svc.ExecuteSQLSafe(context.Background(), query)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expected)
+		})
+	}
+}
+
+func TestNoteService_ExecuteSQLSafe_ReplaceFunction(t *testing.T) {
+	db := services.NewDbService()
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+
+	tmpDir := t.TempDir()
+	cfg, _ := services.NewConfigServiceWithPath(tmpDir + "/config.json")
+	notebookDir := testutil.CreateTestNotebook(t, tmpDir, "test-notebook")
+
+	svc := services.NewNoteService(cfg, db, notebookDir)
+
+	results, err := svc.ExecuteSQLSafe(context.Background(), "SELECT replace('a-b', '-', '+') AS value")
+	require.NoError(t, err)
+	assert.Equal(t, "a+b", results.Value(0, "value"))
+}
+
+func TestNoteService_ExecuteSQLSafe_FilesOutsideNotebook(t *testing.T) {
+	db := services.NewDbService()
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+
+	tmpDir := t.TempDir()
+	cfg, _ := services.NewConfigServiceWithPath(tmpDir + "/config.json")
+	notebookDir := testutil.CreateTestNotebook(t, tmpDir, "test-notebook")
+	secret := filepath.Join(tmpDir, "secret.csv")
+	require.NoError(t, os.WriteFile(secret, []byte("password\nhunter2\n"), 0644))
+
+	svc := services.NewNoteService(cfg, db, notebookDir)
+
+	_, err := svc.ExecuteSQLSafe(context.Background(), fmt.Sprintf("SELECT * FROM read_csv('%s')", secret))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permission Error")
+
+	_, err = svc.ExecuteSQLSafe(context.Background(), fmt.Sprintf("SELECT * FROM read_markdown('%s/*.md')", tmpDir))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permission Error")
+}
+
 func TestNoteService_SearchNotes_DisplayNameWithTitle(t *testing.T) {
 	ctx := context.Background()
 	db := services.NewDbService()
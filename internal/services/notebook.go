@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog"
+	"github.com/zenobi-us/opennotes/internal/core"
 )
 
 // NotebookGroup defines a group of notes with shared properties.
@@ -17,6 +18,8 @@ type NotebookGroup struct {
 	Globs    []string       `json:"globs"`
 	Metadata map[string]any `json:"metadata"`
 	Template string         `json:"template,omitempty"`
+	// Schema describes the frontmatter of the group's notes, over the notebook's schema
+	Schema core.FrontmatterSchema `json:"schema,omitempty"`
 }
 
 // StoredNotebookConfig is what's stored in .opennotes.json.
@@ -26,6 +29,9 @@ type StoredNotebookConfig struct {
 	Contexts  []string          `json:"contexts,omitempty"`
 	Templates map[string]string `json:"templates,omitempty"`
 	Groups    []NotebookGroup   `json:"groups,omitempty"`
+	Queries   []SavedQuery      `json:"queries,omitempty"`
+	// Schema describes the frontmatter of every note in the notebook
+	Schema core.FrontmatterSchema `json:"schema,omitempty"`
 }
 
 // NotebookConfig includes runtime-resolved paths.
@@ -104,6 +110,8 @@ func (s *NotebookService) LoadConfig(path string) (*NotebookConfig, error) {
 			Contexts:  stored.Contexts,
 			Templates: stored.Templates,
 			Groups:    stored.Groups,
+			Queries:   stored.Queries,
+			Schema:    stored.Schema,
 		},
 		Path: configPath,
 	}, nil
@@ -117,6 +125,9 @@ func (s *NotebookService) Open(notebookPath string) (*Notebook, error) {
 	}
 
 	noteService := NewNoteService(s.configService, s.dbService, config.Root)
+	noteService.groups = config.Groups
+	noteService.queries = config.Queries
+	noteService.schema = config.Schema
 
 	return &Notebook{
 		Config: *config,
@@ -155,6 +166,9 @@ func (s *NotebookService) Create(name, path string, register bool) (*Notebook, e
 	}
 
 	noteService := NewNoteService(s.configService, s.dbService, notesDir)
+	noteService.groups = config.Groups
+	noteService.queries = config.Queries
+	noteService.schema = config.Schema
 	notebook := &Notebook{
 		Config: config,
 		Notes:  noteService,
@@ -288,6 +302,8 @@ func (n *Notebook) SaveConfig(register bool, configService *ConfigService) error
 		Contexts:  n.Config.Contexts,
 		Templates: n.Config.Templates,
 		Groups:    n.Config.Groups,
+		Queries:   n.Config.Queries,
+		Schema:    n.Config.Schema,
 	}
 
 	data, err := json.MarshalIndent(stored, "", "  ")
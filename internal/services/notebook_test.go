@@ -688,3 +688,31 @@ func TestNotebook_SaveConfig_AvoidsDuplicateRegistration(t *testing.T) {
 	}
 	assert.Equal(t, 1, count)
 }
+
+func TestNotebook_SaveConfig_KeepsQueries(t *testing.T) {
+	tmpDir := t.TempDir()
+	notebookDir := createTestNotebook(t, tmpDir, "notebook")
+
+	configSvc := createTestConfigService(t, tmpDir, nil)
+	dbSvc := NewDbService()
+	t.Cleanup(func() {
+		if err := dbSvc.Close(); err != nil {
+			t.Logf("warning: failed to close db: %v", err)
+		}
+	})
+	svc := NewNotebookService(configSvc, dbSvc)
+
+	notebook, err := svc.Open(notebookDir)
+	require.NoError(t, err)
+
+	notebook.Config.Queries = []SavedQuery{{Name: "recent", SQL: "SELECT title FROM notes", Format: "csv"}}
+	require.NoError(t, notebook.SaveConfig(false, configSvc))
+
+	reopened, err := svc.Open(notebookDir)
+	require.NoError(t, err)
+	assert.Equal(t, notebook.Config.Queries, reopened.Config.Queries)
+
+	query, ok := reopened.Notes.SavedQuery("recent")
+	assert.True(t, ok)
+	assert.Equal(t, "csv", query.Format)
+}
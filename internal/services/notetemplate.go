@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+	"gopkg.in/yaml.v3"
+)
+
+// NoteTemplateData is what note templates are rendered with.
+type NoteTemplateData struct {
+	Title string
+	// Date is when the note is created
+	Date time.Time
+	// Notebook is the notebook's name
+	Notebook string
+	// Group is the name of the group the note is added to, if any
+	Group string
+	// Context is the notebook context the note is added from, or the
+	// working directory when none matches
+	Context string
+	// Path is the note's path relative to the notebook root
+	Path string
+	// Vars are the values set with notes add --var key=value
+	Vars map[string]string
+}
+
+// RenderNoteTemplate renders a note template with Go's text/template.
+// Besides the fields of NoteTemplateData, templates can use:
+//
+//	now                       the current time
+//	date "2006-01-02" t       t formatted with a Go layout
+//	dateAdd "7d" t            t moved by a duration: Go durations (36h) or days (7d) and weeks (2w)
+//	slugify s                 s as a slug, as note filenames are made
+//	env "NAME"                an environment variable
+//	default "x" s             s, or x when s is empty
+//	upper s, lower s          s in upper or lower case
+//
+// Missing vars are empty, and {{title}} still works for templates written
+// before templates were rendered.
+func RenderNoteTemplate(text string, data NoteTemplateData) (string, error) {
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+
+	tmpl, err := newNoteTemplate("note", data).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid note template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render note template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// newNoteTemplate returns an empty note template with the note template
+// functions, rendering missing vars as empty strings.
+func newNoteTemplate(name string, data NoteTemplateData) *template.Template {
+	return template.New(name).Option("missingkey=zero").Funcs(noteTemplateFuncs(data))
+}
+
+// noteTemplateFuncs returns the functions note templates can use.
+func noteTemplateFuncs(data NoteTemplateData) template.FuncMap {
+	return template.FuncMap{
+		"title":   func() string { return data.Title },
+		"now":     time.Now,
+		"date":    func(layout string, t time.Time) string { return t.Format(layout) },
+		"dateAdd": dateAdd,
+		"slugify": core.Slugify,
+		"env":     os.Getenv,
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"yaml":  yamlValue,
+	}
+}
+
+// yamlValue returns value written so it can follow a frontmatter key on one
+// line: plain when YAML would read it back unchanged, quoted otherwise, so
+// "Bug: crash on save" or "#1" stay strings.
+func yamlValue(value any) (string, error) {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to write %v as YAML: %w", value, err)
+	}
+	if text := strings.TrimSuffix(string(out), "\n"); !strings.Contains(text, "\n") {
+		return text, nil
+	}
+
+	// Multi-line values would need a block; JSON is YAML that fits on a line
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to write %v as YAML: %w", value, err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// dateAdd returns t moved by duration: a Go duration such as 36h or -90m, or
+// a whole number of days (7d) or weeks (2w).
+func dateAdd(duration string, t time.Time) (time.Time, error) {
+	for suffix, days := range map[string]int{"d": 1, "w": 7} {
+		if n, ok := strings.CutSuffix(duration, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return t, fmt.Errorf("invalid duration %q", duration)
+			}
+			return t.AddDate(0, 0, count*days), nil
+		}
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return t, fmt.Errorf("invalid duration %q, expected e.g. 36h, 7d or 2w", duration)
+	}
+	return t.Add(d), nil
+}
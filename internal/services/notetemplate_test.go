@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderNoteTemplate(t *testing.T) {
+	t.Setenv("OPENNOTES_TEST_USER", "ana")
+	data := NoteTemplateData{
+		Title:    "Release Plan",
+		Date:     time.Date(2025, 3, 28, 9, 30, 0, 0, time.UTC),
+		Notebook: "Work",
+		Group:    "Projects",
+		Context:  "/src/app",
+		Path:     "projects/release-plan.md",
+		Vars:     map[string]string{"owner": "bo"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"fields", "{{ .Title }} in {{ .Notebook }}/{{ .Group }} at {{ .Path }} from {{ .Context }}", "Release Plan in Work/Projects at projects/release-plan.md from /src/app"},
+		{"legacy title", "# {{title}}", "# Release Plan"},
+		{"date", `{{ .Date | date "2006-01-02" }}`, "2025-03-28"},
+		{"date method", `{{ .Date.Format "Jan 2" }}`, "Mar 28"},
+		{"dateAdd days", `{{ .Date | dateAdd "7d" | date "2006-01-02" }}`, "2025-04-04"},
+		{"dateAdd weeks", `{{ .Date | dateAdd "-2w" | date "2006-01-02" }}`, "2025-03-14"},
+		{"dateAdd duration", `{{ .Date | dateAdd "36h" | date "2006-01-02 15:04" }}`, "2025-03-29 21:30"},
+		{"slugify", `{{ slugify .Title }}`, "release-plan"},
+		{"env", `{{ env "OPENNOTES_TEST_USER" }}`, "ana"},
+		{"vars", `{{ .Vars.owner }}`, "bo"},
+		{"missing var", `[{{ .Vars.reviewer }}]`, "[]"},
+		{"default", `{{ .Vars.reviewer | default "nobody" }} {{ .Vars.owner | default "nobody" }}`, "nobody bo"},
+		{"case", `{{ upper .Group }} {{ lower .Title }}`, "PROJECTS release plan"},
+		{"yaml plain", `title: {{ yaml .Title }}`, "title: Release Plan"},
+		{"yaml quoted", `owner: {{ .Vars.owner | printf "%s: lead" | yaml }}`, "owner: 'bo: lead'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := RenderNoteTemplate(tt.template, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRenderNoteTemplate_YAML(t *testing.T) {
+	values := []string{"Bug: crash on save", "[WIP] plan", "#1 priority", "yes", "12", "it's \"done\"", "two\nlines", "", "very " + strings.Repeat("long ", 40)}
+	for _, value := range values {
+		result, err := RenderNoteTemplate("title: {{ yaml .Title }}", NoteTemplateData{Title: value})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "\n", "%q fits on one line", value)
+
+		var fields map[string]any
+		require.NoError(t, yaml.Unmarshal([]byte(result), &fields), value)
+		assert.Equal(t, value, fields["title"], value)
+	}
+}
+
+func TestRenderNoteTemplate_Now(t *testing.T) {
+	result, err := RenderNoteTemplate(`{{ now | date "2006" }}`, NoteTemplateData{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Now().Format("2006"), result)
+}
+
+func TestRenderNoteTemplate_Errors(t *testing.T) {
+	_, err := RenderNoteTemplate("{{ .Title", NoteTemplateData{})
+	assert.ErrorContains(t, err, "invalid note template")
+
+	_, err = RenderNoteTemplate("{{ .Nope }}", NoteTemplateData{})
+	assert.ErrorContains(t, err, "failed to render note template")
+
+	_, err = RenderNoteTemplate(`{{ .Date | dateAdd "soon" }}`, NoteTemplateData{})
+	assert.ErrorContains(t, err, `invalid duration "soon"`)
+
+	_, err = RenderNoteTemplate(`{{ .Date | dateAdd "xd" }}`, NoteTemplateData{})
+	assert.ErrorContains(t, err, `invalid duration "xd"`)
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// Where note templates come from, in order of precedence: a template in
+// .opennotes.json hides a notebook template file of the same name, which
+// hides a user template file.
+const (
+	TemplateSourceConfig   = "config"
+	TemplateSourceNotebook = "notebook"
+	TemplateSourceUser     = "user"
+)
+
+// Frontmatter keys configuring a template rather than the note: the
+// template it extends and the values it prompts for. They aren't part of the
+// rendered note.
+const (
+	templateExtendsKey = "extends"
+	templatePromptsKey = "prompts"
+)
+
+// frontmatterBodyTemplate names the merged frontmatter of an extending
+// template, apart from the body's blocks.
+const frontmatterBodyTemplate = "opennotes-frontmatter"
+
+// NoteTemplate is a template notes add can create notes from.
+type NoteTemplate struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	// Path is the template's file, empty for templates in .opennotes.json
+	Path string `json:"path,omitempty"`
+	// Extends names the template this one extends, if any
+	Extends string `json:"extends,omitempty"`
+	Content string `json:"-"`
+}
+
+// NoteTemplates are the templates available to a notebook.
+type NoteTemplates struct {
+	templates map[string]*NoteTemplate
+}
+
+// TemplatesDir returns the notebook's template directory,
+// .opennotes/templates next to its config file.
+func (c *NotebookConfig) TemplatesDir() string {
+	return filepath.Join(filepath.Dir(c.Path), ".opennotes", "templates")
+}
+
+// TemplatesDir returns the user's template directory, templates next to the
+// global config file.
+func (c *ConfigService) TemplatesDir() string {
+	return filepath.Join(filepath.Dir(c.path), "templates")
+}
+
+// LoadNoteTemplates loads the templates in .opennotes.json, the *.md files
+// in the notebook's template directory and those in the user's template
+// directory. Either directory may be empty or missing.
+func LoadNoteTemplates(inline map[string]string, notebookDir, userDir string) (*NoteTemplates, error) {
+	t := &NoteTemplates{templates: make(map[string]*NoteTemplate)}
+
+	for name, content := range inline {
+		t.templates[name] = &NoteTemplate{
+			Name:    name,
+			Source:  TemplateSourceConfig,
+			Extends: templateExtends(content),
+			Content: content,
+		}
+	}
+
+	for _, dir := range []struct{ path, source string }{
+		{notebookDir, TemplateSourceNotebook},
+		{userDir, TemplateSourceUser},
+	} {
+		if dir.path == "" {
+			continue
+		}
+		if err := t.loadDir(dir.path, dir.source); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// loadDir adds the templates in dir that aren't already loaded.
+func (t *NoteTemplates) loadDir(dir, source string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("failed to list templates in %s: %w", dir, err)
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		if _, ok := t.templates[name]; ok {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		t.templates[name] = &NoteTemplate{
+			Name:    name,
+			Source:  source,
+			Path:    path,
+			Extends: templateExtends(string(content)),
+			Content: string(content),
+		}
+	}
+	return nil
+}
+
+// List returns the templates sorted by name.
+func (t *NoteTemplates) List() []NoteTemplate {
+	list := make([]NoteTemplate, 0, len(t.templates))
+	for _, tmpl := range t.templates {
+		list = append(list, *tmpl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the named template.
+func (t *NoteTemplates) Get(name string) (*NoteTemplate, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		names := make([]string, 0, len(t.templates))
+		for _, tmpl := range t.List() {
+			names = append(names, tmpl.Name)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown template %q (no templates defined)", name)
+		}
+		return nil, fmt.Errorf("unknown template %q (expected one of: %s)", name, strings.Join(names, ", "))
+	}
+	return tmpl, nil
+}
+
+// Render renders the named template with data.
+//
+// A template whose frontmatter has "extends: base" is rendered from base:
+// its frontmatter fields replace base's fields with the same key, or are
+// added after them, and its {{ define "name" }} blocks replace base's
+// {{ block "name" . }} blocks. A body with content outside define blocks
+// replaces base's body.
+func (t *NoteTemplates) Render(name string, data NoteTemplateData) (string, error) {
+	chain, err := t.chain(name)
+	if err != nil {
+		return "", err
+	}
+	if len(chain) == 1 && templateField(chain[0].Content, templatePromptsKey) == "" {
+		return RenderNoteTemplate(chain[0].Content, data)
+	}
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+
+	var fields []frontmatterEntry
+	body := newNoteTemplate("note", data)
+	for _, tmpl := range chain {
+		frontmatter, text, ok := core.SplitFrontmatter(tmpl.Content)
+		if ok {
+			fields = mergeFrontmatterEntries(fields, frontmatterEntries(frontmatter))
+		}
+		if _, err := body.Parse(text); err != nil {
+			return "", fmt.Errorf("invalid note template %s: %w", tmpl.Name, err)
+		}
+	}
+
+	var frontmatter strings.Builder
+	for _, field := range fields {
+		frontmatter.WriteString(field.text)
+	}
+	if _, err := body.New(frontmatterBodyTemplate).Parse(frontmatter.String()); err != nil {
+		return "", fmt.Errorf("invalid note template %s: %w", name, err)
+	}
+
+	var b strings.Builder
+	if len(fields) > 0 {
+		b.WriteString("---\n")
+		if err := body.ExecuteTemplate(&b, frontmatterBodyTemplate, data); err != nil {
+			return "", fmt.Errorf("failed to render note template: %w", err)
+		}
+		b.WriteString("---\n")
+	}
+	if err := body.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render note template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// chain returns the named template after the templates it extends, base
+// first.
+func (t *NoteTemplates) chain(name string) ([]*NoteTemplate, error) {
+	tmpl, err := t.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*NoteTemplate{tmpl}
+	seen := map[string]bool{name: true}
+	for tmpl.Extends != "" {
+		base, ok := t.templates[tmpl.Extends]
+		if !ok {
+			return nil, fmt.Errorf("template %s extends unknown template %q", tmpl.Name, tmpl.Extends)
+		}
+		if seen[base.Name] {
+			return nil, fmt.Errorf("template %s extends itself through %s", name, tmpl.Name)
+		}
+		seen[base.Name] = true
+		chain = append([]*NoteTemplate{base}, chain...)
+		tmpl = base
+	}
+	return chain, nil
+}
+
+// NewTemplateFile writes a starter template named name to dir, extending
+// base when it isn't empty, and returns its path.
+func NewTemplateFile(dir, name, base string) (string, error) {
+	name = strings.TrimSuffix(name, ".md")
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+
+	path := filepath.Join(dir, name+".md")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("template already exists: %s", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to check template %s: %w", path, err)
+	}
+
+	content := "---\ntitle: {{ yaml .Title }}\ncreated: {{ .Date | date \"2006-01-02T15:04:05Z07:00\" }}\n---\n\n{{ block \"content\" . }}# {{ .Title }}\n{{ end }}"
+	if base != "" {
+		content = fmt.Sprintf("---\n%s: %s\n---\n{{/* Fields here replace %s's fields, and {{ define \"name\" }}...{{ end }} replaces its {{ block \"name\" . }} blocks. */}}\n", templateExtendsKey, base, base)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create template directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to create template: %w", err)
+	}
+	return path, nil
+}
+
+// frontmatterEntry is a top-level frontmatter field as written in a
+// template: its key line and the indented or list lines under it.
+type frontmatterEntry struct {
+	key  string
+	text string
+}
+
+// frontmatterKeyLine matches the line starting a top-level field.
+var frontmatterKeyLine = regexp.MustCompile(`^([^\s#:{-][^:]*):(\s|$)`)
+
+// frontmatterEntries splits template frontmatter into its top-level fields.
+// Template frontmatter may hold template actions, so it's split by line
+// rather than parsed as YAML. Lines before the first field have no key.
+func frontmatterEntries(frontmatter string) []frontmatterEntry {
+	var entries []frontmatterEntry
+	for _, line := range strings.SplitAfter(frontmatter, "\n") {
+		if line == "" {
+			continue
+		}
+		if match := frontmatterKeyLine.FindStringSubmatch(line); match != nil {
+			entries = append(entries, frontmatterEntry{key: strings.TrimSpace(match[1])})
+		} else if len(entries) == 0 {
+			entries = append(entries, frontmatterEntry{})
+		}
+		entries[len(entries)-1].text += line
+	}
+	return entries
+}
+
+// mergeFrontmatterEntries replaces the fields of base with those of child
+// with the same key and adds the rest after them. The extends and prompts
+// fields are dropped.
+func mergeFrontmatterEntries(base, child []frontmatterEntry) []frontmatterEntry {
+	merged := append([]frontmatterEntry(nil), base...)
+	for _, entry := range child {
+		if entry.key == templateExtendsKey || entry.key == templatePromptsKey {
+			continue
+		}
+		replaced := false
+		for i := range merged {
+			if entry.key != "" && merged[i].key == entry.key {
+				merged[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// templateExtends returns the template content's extends field, if any.
+func templateExtends(content string) string {
+	_, value, _ := strings.Cut(templateField(content, templateExtendsKey), ":")
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// templateField returns the text of the template content's frontmatter
+// field key, from its key line to the end of its indented or list lines, or
+// "" when there's no such field.
+func templateField(content, key string) string {
+	frontmatter, _, ok := core.SplitFrontmatter(content)
+	if !ok {
+		return ""
+	}
+	for _, entry := range frontmatterEntries(frontmatter) {
+		if entry.key == key {
+			return entry.text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func TestLoadNoteTemplates(t *testing.T) {
+	notebookDir := filepath.Join(t.TempDir(), "notebook")
+	userDir := filepath.Join(t.TempDir(), "user")
+	testutil.WriteNote(t, notebookDir, "meeting.md", "notebook meeting")
+	testutil.WriteNote(t, notebookDir, "bug.md", "---\nextends: base\n---\n")
+	testutil.WriteNote(t, userDir, "bug.md", "user bug")
+	testutil.WriteNote(t, userDir, "base.md", "user base")
+	testutil.WriteNote(t, userDir, "notes.txt", "ignored")
+
+	templates, err := LoadNoteTemplates(map[string]string{"meeting": "config meeting"}, notebookDir, userDir)
+	require.NoError(t, err)
+
+	list := templates.List()
+	require.Len(t, list, 3)
+	assert.Equal(t, NoteTemplate{Name: "base", Source: TemplateSourceUser, Path: filepath.Join(userDir, "base.md"), Content: "user base"}, list[0])
+	assert.Equal(t, NoteTemplate{Name: "bug", Source: TemplateSourceNotebook, Path: filepath.Join(notebookDir, "bug.md"), Extends: "base", Content: "---\nextends: base\n---\n"}, list[1])
+	assert.Equal(t, NoteTemplate{Name: "meeting", Source: TemplateSourceConfig, Content: "config meeting"}, list[2])
+
+	_, err = templates.Get("retro")
+	assert.EqualError(t, err, `unknown template "retro" (expected one of: base, bug, meeting)`)
+
+	empty, err := LoadNoteTemplates(nil, filepath.Join(t.TempDir(), "missing"), "")
+	require.NoError(t, err)
+	assert.Empty(t, empty.List())
+	_, err = empty.Get("retro")
+	assert.EqualError(t, err, `unknown template "retro" (no templates defined)`)
+}
+
+func TestNoteTemplates_Render(t *testing.T) {
+	templates, err := LoadNoteTemplates(map[string]string{
+		"plain": "# {{ .Title }}\n",
+		"base": "---\n# base fields\ntitle: {{ .Title }}\ntags:\n  - note\ncreated: {{ .Date | date \"2006-01-02\" }}\n---\n\n" +
+			"# {{ .Title }}\n{{ block \"content\" . }}Write here.\n{{ end }}{{ block \"footer\" . }}{{ end }}",
+		"meeting": "---\nextends: base\ntags:\n  - meeting\n  - {{ .Vars.team | default \"all\" }}\nattendees: []\n---\n" +
+			"{{ define \"content\" }}## Agenda\n{{ end }}",
+		"standup": "---\nextends: \"meeting\"\n---\n{{ define \"footer\" }}Next: {{ .Date | dateAdd \"1d\" | date \"Mon\" }}\n{{ end }}",
+		"memo":    "---\nextends: base\n---\nA memo.\n",
+	}, "", "")
+	require.NoError(t, err)
+
+	data := NoteTemplateData{Title: "Sync", Date: time.Date(2025, 3, 28, 9, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"plain", "# Sync\n"},
+		{"base", "---\n# base fields\ntitle: Sync\ntags:\n  - note\ncreated: 2025-03-28\n---\n\n# Sync\nWrite here.\n"},
+		{"meeting", "---\n# base fields\ntitle: Sync\ntags:\n  - meeting\n  - all\ncreated: 2025-03-28\nattendees: []\n---\n\n# Sync\n## Agenda\n"},
+		{"standup", "---\n# base fields\ntitle: Sync\ntags:\n  - meeting\n  - all\ncreated: 2025-03-28\nattendees: []\n---\n\n# Sync\n## Agenda\nNext: Sat\n"},
+		{"memo", "---\n# base fields\ntitle: Sync\ntags:\n  - note\ncreated: 2025-03-28\n---\nA memo.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := templates.Render(tt.name, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNoteTemplates_RenderErrors(t *testing.T) {
+	templates, err := LoadNoteTemplates(map[string]string{
+		"orphan": "---\nextends: missing\n---\n",
+		"a":      "---\nextends: b\n---\n",
+		"b":      "---\nextends: a\n---\n",
+		"base":   "{{ block \"content\" . }}{{ end }}",
+		"broken": "---\nextends: base\n---\n{{ define \"content\" }}{{ .Title }\n",
+	}, "", "")
+	require.NoError(t, err)
+
+	_, err = templates.Render("orphan", NoteTemplateData{})
+	assert.EqualError(t, err, `template orphan extends unknown template "missing"`)
+
+	_, err = templates.Render("a", NoteTemplateData{})
+	assert.ErrorContains(t, err, "template a extends itself")
+
+	_, err = templates.Render("broken", NoteTemplateData{})
+	assert.ErrorContains(t, err, "invalid note template broken")
+
+	_, err = templates.Render("nope", NoteTemplateData{})
+	assert.ErrorContains(t, err, `unknown template "nope"`)
+}
+
+func TestNewTemplateFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".opennotes", "templates")
+
+	path, err := NewTemplateFile(dir, "base.md", "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "base.md"), path)
+
+	path, err = NewTemplateFile(dir, "meeting", "base")
+	require.NoError(t, err)
+
+	_, err = NewTemplateFile(dir, "meeting", "")
+	assert.ErrorContains(t, err, "template already exists")
+	_, err = NewTemplateFile(dir, "../escape", "")
+	assert.ErrorContains(t, err, "invalid template name")
+
+	templates, err := LoadNoteTemplates(nil, dir, "")
+	require.NoError(t, err)
+	meeting, err := templates.Get("meeting")
+	require.NoError(t, err)
+	assert.Equal(t, path, meeting.Path)
+	assert.Equal(t, "base", meeting.Extends)
+
+	result, err := templates.Render("meeting", NoteTemplateData{Title: "Sync", Date: time.Date(2025, 3, 28, 9, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Sync\ncreated: 2025-03-28T09:00:00Z\n---\n\n# Sync\n", result)
+
+	for _, title := range []string{"Bug: crash on save", "[WIP] plan", "#1 priority"} {
+		result, err := templates.Render("base", NoteTemplateData{Title: title})
+		require.NoError(t, err)
+		content, err := (&NotebookConfig{}).PrepareNote("note.md", result, nil)
+		require.NoError(t, err, title)
+
+		frontmatter, _, err := core.ReadFrontmatter(content)
+		require.NoError(t, err)
+		value, _, err := frontmatter.Get("title")
+		require.NoError(t, err)
+		assert.Equal(t, title, value)
+	}
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is how a command writes its results.
+type OutputFormat string
+
+// Output formats. OutputText is the default human-readable rendering.
+const (
+	OutputText          OutputFormat = "text"
+	OutputJSON          OutputFormat = "json"
+	OutputNDJSON        OutputFormat = "ndjson"
+	OutputCSV           OutputFormat = "csv"
+	OutputTSV           OutputFormat = "tsv"
+	OutputYAML          OutputFormat = "yaml"
+	OutputMarkdownTable OutputFormat = "markdown-table"
+)
+
+// OutputFormats lists every supported output format.
+var OutputFormats = []OutputFormat{
+	OutputText, OutputJSON, OutputNDJSON, OutputCSV, OutputTSV, OutputYAML, OutputMarkdownTable,
+}
+
+// ParseOutputFormat validates a format name. An empty name means OutputText.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	if name == "" {
+		return OutputText, nil
+	}
+	for _, format := range OutputFormats {
+		if string(format) == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(OutputFormats))
+	for i, format := range OutputFormats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// tabular reports whether the format writes rows and columns.
+func (f OutputFormat) tabular() bool {
+	return f == OutputCSV || f == OutputTSV || f == OutputMarkdownTable
+}
+
+// WriteOutput writes value, a struct or a slice of structs, in a machine
+// readable format. Field names come from the values' JSON tags.
+//
+// json, ndjson and yaml keep the JSON structure. The tabular formats flatten
+// nested structs into dotted columns ("file.relative") and write maps and
+// slices as JSON in a single cell.
+func WriteOutput(w io.Writer, format OutputFormat, value any) error {
+	if format.tabular() {
+		columns, rows := flattenRecords(value)
+		return writeTable(w, format, columns, rows)
+	}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(emptySliceIfNil(value))
+	case OutputNDJSON:
+		encoder := json.NewEncoder(w)
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice {
+			return encoder.Encode(value)
+		}
+		for i := 0; i < items.Len(); i++ {
+			if err := encoder.Encode(items.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYAML:
+		data, err := json.Marshal(emptySliceIfNil(value))
+		if err != nil {
+			return err
+		}
+		return writeYAML(w, data)
+	}
+
+	return fmt.Errorf("output format %q is not supported here", format)
+}
+
+// WriteTable writes rows of values under the given columns.
+// json, ndjson and yaml write each row as an object keyed by column, in column
+// order; repeated column names get a numeric suffix ("id", "id_1").
+func WriteTable(w io.Writer, format OutputFormat, columns []string, rows [][]any) error {
+	if format.tabular() {
+		return writeTable(w, format, columns, rows)
+	}
+
+	keys := uniqueKeys(columns)
+	objects := make([]json.RawMessage, len(rows))
+	for i, row := range rows {
+		object, err := orderedObject(keys, row)
+		if err != nil {
+			return err
+		}
+		objects[i] = object
+	}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(objects)
+	case OutputNDJSON:
+		for _, object := range objects {
+			if _, err := fmt.Fprintf(w, "%s\n", object); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYAML:
+		data, err := json.Marshal(objects)
+		if err != nil {
+			return err
+		}
+		return writeYAML(w, data)
+	}
+
+	return fmt.Errorf("output format %q is not supported here", format)
+}
+
+// WriteSQLResults writes a SQL result set in format, keeping column order.
+func WriteSQLResults(w io.Writer, format OutputFormat, results *ResultSet) error {
+	return WriteTable(w, format, results.ColumnNames(), results.Rows)
+}
+
+func writeTable(w io.Writer, format OutputFormat, columns []string, rows [][]any) error {
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(columns))
+		for j := range columns {
+			if j < len(row) {
+				cells[i][j] = formatCell(row[j])
+			}
+		}
+	}
+
+	if format == OutputMarkdownTable {
+		return writeMarkdownTable(w, columns, cells)
+	}
+
+	writer := csv.NewWriter(w)
+	if format == OutputTSV {
+		writer.Comma = '\t'
+	}
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(cells); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func writeMarkdownTable(w io.Writer, columns []string, cells [][]string) error {
+	var b strings.Builder
+
+	writeRow := func(values []string) {
+		b.WriteString("|")
+		for _, value := range values {
+			value = strings.ReplaceAll(value, "|", `\|`)
+			value = strings.ReplaceAll(value, "\r\n", "<br>")
+			value = strings.ReplaceAll(value, "\n", "<br>")
+			b.WriteString(" " + value + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(columns)
+	separators := make([]string, len(columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	writeRow(separators)
+	for _, row := range cells {
+		writeRow(row)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAML re-encodes JSON as block-style YAML, keeping key order.
+func writeYAML(w io.Writer, data []byte) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetYAMLStyle drops the flow and quoting styles a JSON document decodes
+// with; the encoder still quotes strings that would otherwise change type.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// uniqueKeys renames repeated column names ("id", "id") to "id", "id_1" so
+// they can be used as object keys.
+func uniqueKeys(columns []string) []string {
+	seen := make(map[string]bool, len(columns))
+	keys := make([]string, len(columns))
+	for i, column := range columns {
+		key := column
+		for n := 1; seen[key]; n++ {
+			key = fmt.Sprintf("%s_%d", column, n)
+		}
+		seen[key] = true
+		keys[i] = key
+	}
+	return keys
+}
+
+// orderedObject encodes a row as a JSON object with keys in column order.
+func orderedObject(columns []string, row []any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if i < len(row) {
+			value = row[i]
+		}
+		data, err := json.Marshal(jsonValue(value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %s: %w", column, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonValue converts values encoding/json can't handle, such as DuckDB
+// MAPs (map[any]any), into equivalents it can.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case nil, string, bool, float64, int64, int32, int, time.Time:
+		return v
+	case []byte:
+		return string(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		result := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result[fmt.Sprint(iter.Key().Interface())] = jsonValue(iter.Value().Interface())
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		result := make([]any, rv.Len())
+		for i := range result {
+			result[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return result
+	}
+	return value
+}
+
+// formatCell renders a value for a CSV, TSV or markdown table cell.
+// NULLs are empty and nested values are written as JSON.
+func formatCell(value any) string {
+	if value == nil {
+		return ""
+	}
+
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return ""
+		}
+		if _, isBytes := value.([]byte); isBytes {
+			break
+		}
+		fallthrough
+	case reflect.Array, reflect.Struct:
+		if _, isTime := value.(time.Time); isTime {
+			break
+		}
+		if data, err := json.Marshal(jsonValue(value)); err == nil {
+			return string(data)
+		}
+	}
+	return FormatValue(value)
+}
+
+// flattenRecords turns a struct or slice of structs into columns and rows.
+func flattenRecords(value any) ([]string, [][]any) {
+	items := reflect.ValueOf(value)
+	if items.Kind() != reflect.Slice {
+		items = reflect.ValueOf([]any{value})
+	}
+
+	var columns []string
+	var rows [][]any
+	for i := 0; i < items.Len(); i++ {
+		var names []string
+		var values []any
+		flattenValue(items.Index(i), "", &names, &values)
+		if columns == nil {
+			columns = names
+		}
+		rows = append(rows, values)
+	}
+
+	if columns == nil {
+		columns = flattenColumns(items.Type().Elem())
+	}
+	return columns, rows
+}
+
+// flattenColumns returns the columns of an element type without a value,
+// so empty results still get a header row.
+func flattenColumns(t reflect.Type) []string {
+	var names []string
+	var values []any
+	flattenValue(reflect.Zero(t), "", &names, &values)
+	if len(names) == 1 && names[0] == "" {
+		return nil
+	}
+	return names
+}
+
+// flattenValue appends the fields of v to names and values, descending into
+// structs. Field names follow encoding/json: tags are honoured, "-" fields are
+// skipped and embedded structs are inlined.
+func flattenValue(v reflect.Value, prefix string, names *[]string, values *[]any) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct {
+				flattenValue(reflect.Zero(v.Type().Elem()), prefix, names, values)
+				return
+			}
+			*names = append(*names, prefix)
+			*values = append(*values, nil)
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || v.Type() == reflect.TypeOf(time.Time{}) {
+		*names = append(*names, prefix)
+		*values = append(*values, v.Interface())
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			flattenValue(v.Field(i), prefix, names, values)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		flattenValue(v.Field(i), name, names, values)
+	}
+}
+
+// emptySliceIfNil makes nil slices encode as [] rather than null.
+func emptySliceIfNil(value any) any {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return value
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outputNotes() []Note {
+	note := newSearchNote("projects/plan.md", "Plan", "# Plan\n\nShip it, \"now\".\n")
+	note.Metadata["tags"] = []any{"work", "a|b"}
+	note.Tags = []string{"work"}
+	return []Note{note}
+}
+
+func writeOutput(t *testing.T, format OutputFormat, value any) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, WriteOutput(&buf, format, value))
+	return buf.String()
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, format := range OutputFormats {
+		parsed, err := ParseOutputFormat(string(format))
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+
+	parsed, err := ParseOutputFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, OutputText, parsed)
+
+	parsed, err = ParseOutputFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, OutputJSON, parsed)
+
+	_, err = ParseOutputFormat("xml")
+	assert.ErrorContains(t, err, `unknown output format "xml"`)
+}
+
+func TestWriteOutput_JSON(t *testing.T) {
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(writeOutput(t, OutputJSON, outputNotes())), &decoded))
+
+	require.Len(t, decoded, 1)
+	assert.Equal(t, map[string]any{"filepath": "/nb/projects/plan.md", "relative": "projects/plan.md"}, decoded[0]["file"])
+	assert.Equal(t, map[string]any{"title": "Plan", "tags": []any{"work", "a|b"}}, decoded[0]["metadata"])
+	assert.NotContains(t, decoded[0], "score", "omitempty fields stay omitted")
+}
+
+func TestWriteOutput_JSONEmptyList(t *testing.T) {
+	assert.Equal(t, "[]\n", writeOutput(t, OutputJSON, []Note(nil)))
+}
+
+func TestWriteOutput_NDJSON(t *testing.T) {
+	notes := append(outputNotes(), newSearchNote("b.md", "", "B"))
+
+	lines := bytes.Split(bytes.TrimSpace([]byte(writeOutput(t, OutputNDJSON, notes))), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var second Note
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+	assert.Equal(t, "b.md", second.File.Relative)
+}
+
+func TestWriteOutput_CSV(t *testing.T) {
+	expected := "file.filepath,file.relative,content,metadata,groups,tags,link_count,backlink_count,score,snippet\n" +
+		"/nb/projects/plan.md,projects/plan.md,\"# Plan\n\nShip it, \"\"now\"\".\n\",\"{\"\"tags\"\":[\"\"work\"\",\"\"a|b\"\"],\"\"title\"\":\"\"Plan\"\"}\",,\"[\"\"work\"\"]\",0,0,0,\n"
+
+	assert.Equal(t, expected, writeOutput(t, OutputCSV, outputNotes()))
+}
+
+func TestWriteOutput_TSV(t *testing.T) {
+	note := newSearchNote("a.md", "", "text")
+	note.Score = 1.5
+
+	expected := "file.filepath\tfile.relative\tcontent\tmetadata\tgroups\ttags\tlink_count\tbacklink_count\tscore\tsnippet\n" +
+		"/nb/a.md\ta.md\ttext\t{}\t\t[]\t0\t0\t1.5\t\n"
+	assert.Equal(t, expected, writeOutput(t, OutputTSV, []Note{note}))
+}
+
+func TestWriteOutput_TabularEmptyListHasHeader(t *testing.T) {
+	assert.Equal(t, "file.filepath,file.relative,content,metadata,groups,tags,link_count,backlink_count,score,snippet\n", writeOutput(t, OutputCSV, []Note{}))
+}
+
+func TestWriteOutput_MarkdownTable(t *testing.T) {
+	expected := "| file.filepath | file.relative | content | metadata | groups | tags | link_count | backlink_count | score | snippet |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| /nb/projects/plan.md | projects/plan.md | # Plan<br><br>Ship it, \"now\".<br> | {\"tags\":[\"work\",\"a\\|b\"],\"title\":\"Plan\"} |  | [\"work\"] | 0 | 0 | 0 |  |\n"
+
+	assert.Equal(t, expected, writeOutput(t, OutputMarkdownTable, outputNotes()))
+}
+
+func TestWriteOutput_YAML(t *testing.T) {
+	note := newSearchNote("a.md", "", "line one\nline two\n")
+	note.Metadata["created"] = "2025-01-01"
+
+	expected := `- file:
+    filepath: /nb/a.md
+    relative: a.md
+  content: |
+    line one
+    line two
+  metadata:
+    created: "2025-01-01"
+  tags: []
+  link_count: 0
+  backlink_count: 0
+`
+	assert.Equal(t, expected, writeOutput(t, OutputYAML, []Note{note}))
+}
+
+func TestWriteOutput_NotebookConfig(t *testing.T) {
+	config := NotebookConfig{
+		StoredNotebookConfig: StoredNotebookConfig{
+			Root:     "/nb/.notes",
+			Name:     "Work",
+			Contexts: []string{"/nb"},
+		},
+		Path: "/nb/.opennotes.json",
+	}
+
+	// Embedded fields are inlined and json:"-" fields are skipped
+	assert.Equal(t,
+		"root,name,contexts,templates,groups,queries,schema\n/nb/.notes,Work,\"[\"\"/nb\"\"]\",,,,\n",
+		writeOutput(t, OutputCSV, []NotebookConfig{config}))
+
+	assert.Equal(t, "root: /nb/.notes\nname: Work\ncontexts:\n  - /nb\n", writeOutput(t, OutputYAML, config))
+}
+
+func TestWriteOutput_TextUnsupported(t *testing.T) {
+	err := WriteOutput(&bytes.Buffer{}, OutputText, outputNotes())
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestWriteTable_KeepsColumnOrder(t *testing.T) {
+	columns := []string{"title", "filepath", "count"}
+	rows := [][]any{{"Plan", "plan.md", int64(2)}, {nil, "b.md", int64(0)}}
+
+	tests := []struct {
+		format   OutputFormat
+		expected string
+	}{
+		{OutputNDJSON, "{\"title\":\"Plan\",\"filepath\":\"plan.md\",\"count\":2}\n{\"title\":null,\"filepath\":\"b.md\",\"count\":0}\n"},
+		{OutputCSV, "title,filepath,count\nPlan,plan.md,2\n,b.md,0\n"},
+		{OutputYAML, "- title: Plan\n  filepath: plan.md\n  count: 2\n- title: null\n  filepath: b.md\n  count: 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteTable(&buf, tt.format, columns, rows))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteTable_NestedValues(t *testing.T) {
+	type duckMap map[any]any
+
+	columns := []string{"metadata", "created"}
+	rows := [][]any{{duckMap{"title": "Plan", 1: "one"}, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTable(&buf, OutputNDJSON, columns, rows))
+	assert.Equal(t, "{\"metadata\":{\"1\":\"one\",\"title\":\"Plan\"},\"created\":\"2025-01-02T00:00:00Z\"}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteTable(&buf, OutputCSV, columns, rows))
+	assert.Equal(t, "metadata,created\n\"{\"\"1\"\":\"\"one\"\",\"\"title\"\":\"\"Plan\"\"}\",2025-01-02\n", buf.String())
+}
+
+func TestWriteSQLResults(t *testing.T) {
+	results := newResultSet([]string{"b", "a", "a"}, []any{1, "x", "y"})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSQLResults(&buf, OutputCSV, results))
+	assert.Equal(t, "b,a,a\n1,x,y\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteSQLResults(&buf, OutputNDJSON, results))
+	assert.Equal(t, "{\"b\":1,\"a\":\"x\",\"a_1\":\"y\"}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteSQLResults(&buf, OutputJSON, newResultSet([]string{"a"})))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SavedQuery is a named SQL query stored in the notebook config.
+type SavedQuery struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// SQL is a single SELECT statement. $name refers to a parameter.
+	SQL    string       `json:"sql"`
+	Params []QueryParam `json:"params,omitempty"`
+	// Format is the default output format, e.g. "csv"
+	Format string `json:"format,omitempty"`
+}
+
+// QueryParam is a parameter of a saved query.
+type QueryParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when no value is given. Without one the parameter is required.
+	Default *string `json:"default,omitempty"`
+}
+
+// HasRequiredParams reports whether any parameter lacks a default.
+func (q SavedQuery) HasRequiredParams() bool {
+	for _, param := range q.Params {
+		if param.Default == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Bind returns the query's SQL with every $param replaced by its value from
+// values, or its default, as a SQL string literal. Values for undeclared
+// parameters, missing required values and undeclared $params in the SQL are
+// errors.
+func (q SavedQuery) Bind(values map[string]string) (string, error) {
+	bound := make(map[string]string, len(q.Params))
+	for _, param := range q.Params {
+		if value, ok := values[param.Name]; ok {
+			bound[param.Name] = value
+		} else if param.Default != nil {
+			bound[param.Name] = *param.Default
+		} else {
+			return "", fmt.Errorf("query %s: missing value for parameter %s", q.Name, param.Name)
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if _, ok := bound[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("query %s: unknown parameter %s", q.Name, strings.Join(unknown, ", "))
+	}
+
+	query, err := bindParams(q.SQL, bound)
+	if err != nil {
+		return "", fmt.Errorf("query %s: %w", q.Name, err)
+	}
+	return query, nil
+}
+
+// bindParams replaces $name placeholders outside strings, quoted identifiers
+// and comments with values quoted as SQL strings.
+func bindParams(query string, values map[string]string) (string, error) {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case c == '$':
+			end := i + 1
+			for end < len(query) && isWordByte(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			if name == "" {
+				break
+			}
+			value, ok := values[name]
+			if !ok {
+				return "", fmt.Errorf("undeclared parameter $%s", name)
+			}
+			b.WriteString(quoteSQLString(value))
+			i = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// SavedQueries returns the notebook's saved queries.
+func (s *NoteService) SavedQueries() []SavedQuery {
+	return s.queries
+}
+
+// SavedQuery returns the saved query called name.
+func (s *NoteService) SavedQuery(name string) (SavedQuery, bool) {
+	for _, query := range s.queries {
+		if query.Name == name {
+			return query, true
+		}
+	}
+	return SavedQuery{}, false
+}
+
+// RunSavedQuery binds values to the saved query called name and runs it
+// with ExecuteSQLSafe.
+func (s *NoteService) RunSavedQuery(ctx context.Context, name string, values map[string]string) (*ResultSet, error) {
+	query, ok := s.SavedQuery(name)
+	if !ok {
+		return nil, fmt.Errorf("no saved query named %q", name)
+	}
+
+	bound, err := query.Bind(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ExecuteSQLSafe(ctx, bound)
+}
+
+// createQueryViews creates a view for each saved query that can run without
+// parameter values, named after the query. Queries that aren't a single
+// SELECT, clash with a built-in view or fail to bind are skipped with a warning,
+// so one broken query doesn't stop the others.
+func (s *NoteService) createQueryViews(ctx context.Context, db *sql.DB) {
+	for _, query := range s.queries {
+		if query.HasRequiredParams() {
+			continue
+		}
+		log := s.log.With().Str("query", query.Name).Logger()
+
+		if isBuiltinView(query.Name) {
+			log.Warn().Msg("saved query has the name of a built-in view, not creating a view for it")
+			continue
+		}
+
+		bound, err := query.Bind(nil)
+		if err == nil {
+			err = checkStatement(ctx, db, bound)
+		}
+		if err == nil {
+			_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE OR REPLACE VIEW main.%s AS %s", quoteSQLIdentifier(query.Name), strings.TrimSuffix(strings.TrimSpace(bound), ";")))
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to create view for saved query")
+		}
+	}
+}
+
+func isBuiltinView(name string) bool {
+	if name == "notes" || name == "note_links" {
+		return true
+	}
+	for _, view := range elementViews {
+		if view.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteSQLIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
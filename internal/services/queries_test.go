@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestSavedQuery_Bind(t *testing.T) {
+	query := SavedQuery{
+		Name: "modified_since",
+		SQL:  "SELECT title FROM notes WHERE mtime >= $since AND \"group\" = $group LIMIT $limit",
+		Params: []QueryParam{
+			{Name: "since"},
+			{Name: "group", Default: stringPtr("Projects")},
+			{Name: "limit", Default: stringPtr("10")},
+		},
+	}
+
+	bound, err := query.Bind(map[string]string{"since": "2025-01-01", "limit": "5"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT title FROM notes WHERE mtime >= '2025-01-01' AND \"group\" = 'Projects' LIMIT '5'", bound)
+
+	_, err = query.Bind(nil)
+	assert.ErrorContains(t, err, "missing value for parameter since")
+
+	_, err = query.Bind(map[string]string{"since": "x", "until": "y"})
+	assert.ErrorContains(t, err, "unknown parameter until")
+}
+
+func TestBindParams(t *testing.T) {
+	values := map[string]string{"name": "it's"}
+
+	tests := []struct {
+		query    string
+		expected string
+		err      string
+	}{
+		{"SELECT $name", "SELECT 'it''s'", ""},
+		{"SELECT '$name', \"$name\"", "SELECT '$name', \"$name\"", ""},
+		{"SELECT 1 -- $name\nWHERE x = $name", "SELECT 1 -- $name\nWHERE x = 'it''s'", ""},
+		{"SELECT $ 1", "SELECT $ 1", ""},
+		{"SELECT $other", "", "undeclared parameter $other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			bound, err := bindParams(tt.query, values)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bound)
+		})
+	}
+}
+
+func TestNoteService_RunSavedQuery(t *testing.T) {
+	notes, _ := newTestNoteService(t, viewsNotes)
+	notes.groups = viewsGroups
+	notes.queries = []SavedQuery{{
+		Name:   "in_group",
+		SQL:    "SELECT relative FROM notes WHERE \"group\" = $group",
+		Params: []QueryParam{{Name: "group", Default: stringPtr("Default")}},
+	}}
+	ctx := context.Background()
+
+	results, err := notes.RunSavedQuery(ctx, "in_group", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"daily-log.md"}}, results.Rows)
+
+	results, err = notes.RunSavedQuery(ctx, "in_group", map[string]string{"group": "Projects"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"projects/release.md"}}, results.Rows)
+
+	_, err = notes.RunSavedQuery(ctx, "missing", nil)
+	assert.ErrorContains(t, err, `no saved query named "missing"`)
+}
+
+func TestNoteService_SavedQueryViews(t *testing.T) {
+	notes, _ := newTestNoteService(t, viewsNotes)
+	notes.queries = []SavedQuery{
+		{Name: "open_actions", SQL: "SELECT relative, text FROM tasks WHERE NOT done;"},
+		{Name: "since", SQL: "SELECT relative FROM notes WHERE relative > $after", Params: []QueryParam{{Name: "after"}}},
+		{Name: "tasks", SQL: "SELECT 1"},
+		{Name: "writes", SQL: "COPY (SELECT 1) TO 'out.csv'"},
+	}
+	ctx := context.Background()
+
+	results, err := notes.ExecuteSQLSafe(ctx, "SELECT * FROM open_actions")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"projects/release.md", "Ship it"}}, results.Rows)
+
+	// Built-in views win over saved queries of the same name
+	results, err = notes.ExecuteSQLSafe(ctx, "SELECT count(*) FROM tasks")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{int64(2)}}, results.Rows)
+
+	// Queries with required parameters and non-SELECT queries get no view
+	for _, name := range []string{"since", "writes"} {
+		_, err := notes.ExecuteSQLSafe(ctx, "SELECT * FROM "+name)
+		assert.Error(t, err, name)
+	}
+}
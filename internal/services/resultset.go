@@ -0,0 +1,349 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// Column describes a column of a query result.
+type Column struct {
+	Name string `json:"name"`
+	// Type is the DuckDB type, e.g. VARCHAR or MAP(VARCHAR, VARCHAR)
+	Type string `json:"type"`
+}
+
+// ResultSet holds the rows of a query result with columns in SELECT order.
+// Duplicate column names are kept.
+type ResultSet struct {
+	Columns []Column `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	// Truncated is set when rows were dropped to stay within a row limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewResultSet reads every row from rows.
+//
+// Values are converted to plain Go types where the driver's own types don't
+// print or encode well: UUIDs, TIMEs, DATEs and INTERVALs become strings and
+// DECIMALs become float64.
+func NewResultSet(rows *sql.Rows) (*ResultSet, error) {
+	return readResultSet(rows, 0)
+}
+
+// readResultSet reads at most maxRows rows, or every row when maxRows is 0.
+func readResultSet(rows *sql.Rows, maxRows int) (*ResultSet, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResultSet{Columns: make([]Column, len(types)), Rows: [][]any{}}
+	for i, t := range types {
+		result.Columns[i] = Column{Name: t.Name(), Type: t.DatabaseTypeName()}
+	}
+
+	for rows.Next() {
+		if maxRows > 0 && len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]any, len(types))
+		valuePtrs := make([]any, len(types))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+
+		for i, value := range values {
+			values[i] = normalizeValue(value, result.Columns[i].Type)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Len returns the number of rows.
+func (r *ResultSet) Len() int {
+	return len(r.Rows)
+}
+
+// ColumnNames returns the column names in order.
+func (r *ResultSet) ColumnNames() []string {
+	names := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// Value returns the value of the first column called name in row i,
+// or nil if there is no such column.
+func (r *ResultSet) Value(i int, name string) any {
+	for j, column := range r.Columns {
+		if column.Name == name {
+			return r.Rows[i][j]
+		}
+	}
+	return nil
+}
+
+// normalizeValue converts driver values into types that print and encode
+// naturally. Nested LIST, STRUCT and MAP values are converted recursively.
+func normalizeValue(value any, dbType string) any {
+	switch v := value.(type) {
+	case []byte:
+		if dbType == "UUID" && len(v) == 16 {
+			return formatUUID(v)
+		}
+	case time.Time:
+		switch dbType {
+		case "DATE":
+			return v.Format("2006-01-02")
+		case "TIME", "TIMETZ", "TIME WITH TIME ZONE":
+			return v.Format("15:04:05.999999")
+		}
+	case duckdb.Decimal:
+		return v.Float64()
+	case duckdb.Interval:
+		return formatInterval(v)
+	case duckdb.Map:
+		result := make(duckdb.Map, len(v))
+		for key, item := range v {
+			result[key] = normalizeValue(item, "")
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = normalizeValue(item, "")
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalizeValue(item, "")
+		}
+		return result
+	}
+	return value
+}
+
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// formatInterval formats an interval the way DuckDB prints it,
+// e.g. "1 year 2 months 3 days 04:05:06".
+func formatInterval(i duckdb.Interval) string {
+	var parts []string
+	plural := func(n int64, unit string) {
+		if n == 0 {
+			return
+		}
+		if n == 1 || n == -1 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, unit))
+			return
+		}
+		parts = append(parts, fmt.Sprintf("%d %ss", n, unit))
+	}
+	plural(int64(i.Months/12), "year")
+	plural(int64(i.Months%12), "month")
+	plural(int64(i.Days), "day")
+
+	if i.Micros != 0 || len(parts) == 0 {
+		d := time.Duration(i.Micros) * time.Microsecond
+		sign := ""
+		if d < 0 {
+			sign = "-"
+			d = -d
+		}
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+		if micros := d % time.Second / time.Microsecond; micros != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%06d", micros), "0")
+		}
+		parts = append(parts, clock)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// FormatValue renders a query result value for display. NULL is shown as
+// "NULL", LISTs as [a, b], and STRUCTs and MAPs as {key: value}. Without the
+// column type, STRUCT fields are sorted by name; FormatColumnValue keeps
+// their declared order.
+func FormatValue(value any) string {
+	return FormatColumnValue(value, "")
+}
+
+// FormatColumnValue renders a value of a column of DuckDB type dbType, such
+// as Column.Type, like FormatValue, with STRUCT fields in the order the type
+// declares them.
+func FormatColumnValue(value any, dbType string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return v
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return `\x` + hex.EncodeToString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return MetadataValueString(v)
+	case []any:
+		elemType := listElementType(dbType)
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = FormatColumnValue(item, elemType)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		return formatEntries(v, structFields(dbType), "")
+	case duckdb.Map:
+		entries := make(map[string]any, len(v))
+		for key, item := range v {
+			entries[FormatValue(key)] = item
+		}
+		var valueType string
+		if args, ok := typeArgs(dbType, "MAP"); ok {
+			if types := splitTypeArgs(args); len(types) == 2 {
+				valueType = types[1]
+			}
+		}
+		return formatEntries(entries, nil, valueType)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// formatEntries formats key/value pairs, the declared fields first in
+// order and the rest sorted by key, since Go maps don't keep DuckDB's
+// order. Values of undeclared keys have type valueType.
+func formatEntries(entries map[string]any, fields []typeField, valueType string) string {
+	items := make([]string, 0, len(entries))
+	done := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if value, ok := entries[field.name]; ok && !done[field.name] {
+			done[field.name] = true
+			items = append(items, field.name+": "+FormatColumnValue(value, field.dbType))
+		}
+	}
+
+	keys := make([]string, 0, len(entries)-len(done))
+	for key := range entries {
+		if !done[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		items = append(items, key+": "+FormatColumnValue(entries[key], valueType))
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+// typeField is a field of a DuckDB STRUCT type.
+type typeField struct {
+	name   string
+	dbType string
+}
+
+// structFields returns the fields of a STRUCT type such as
+// STRUCT("a" INTEGER, "b" VARCHAR[]) in declared order, or nil for other
+// types.
+func structFields(dbType string) []typeField {
+	args, ok := typeArgs(dbType, "STRUCT")
+	if !ok {
+		return nil
+	}
+
+	var fields []typeField
+	for _, arg := range splitTypeArgs(args) {
+		name, rest := arg, ""
+		if strings.HasPrefix(arg, `"`) {
+			// Field names are quoted, with quotes in them doubled
+			end := 1
+			for end < len(arg) && (arg[end] != '"' || strings.HasPrefix(arg[end:], `""`)) {
+				if arg[end] == '"' {
+					end++
+				}
+				end++
+			}
+			name = strings.ReplaceAll(arg[1:min(end, len(arg))], `""`, `"`)
+			rest = arg[min(end+1, len(arg)):]
+		} else if i := strings.IndexByte(arg, ' '); i >= 0 {
+			name, rest = arg[:i], arg[i:]
+		}
+		fields = append(fields, typeField{name: name, dbType: strings.TrimSpace(rest)})
+	}
+	return fields
+}
+
+// listElementType returns the element type of a LIST or ARRAY type such as
+// INTEGER[] or VARCHAR[3], or "" for other types.
+func listElementType(dbType string) string {
+	if !strings.HasSuffix(dbType, "]") {
+		return ""
+	}
+	if i := strings.LastIndexByte(dbType, '['); i >= 0 {
+		return dbType[:i]
+	}
+	return ""
+}
+
+// typeArgs returns what's between the parentheses of a type such as
+// MAP(VARCHAR, INTEGER) when its kind is kind.
+func typeArgs(dbType, kind string) (string, bool) {
+	if !strings.HasPrefix(dbType, kind+"(") || !strings.HasSuffix(dbType, ")") {
+		return "", false
+	}
+	return dbType[len(kind)+1 : len(dbType)-1], true
+}
+
+// splitTypeArgs splits the arguments of a type at the commas outside
+// nested types and quoted names.
+func splitTypeArgs(args string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(args); i++ {
+		switch c := args[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(args[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(args[start:]))
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryResultSet(t *testing.T, query string) *ResultSet {
+	t.Helper()
+	svc := NewDbService()
+	t.Cleanup(func() { _ = svc.Close() })
+
+	results, err := svc.Query(context.Background(), query)
+	require.NoError(t, err)
+	return results
+}
+
+func TestResultSet_KeepsColumnOrderAndDuplicates(t *testing.T) {
+	results := queryResultSet(t, "SELECT 'Plan' AS title, 'plan.md' AS filepath, 1 AS n, 2 AS n")
+
+	assert.Equal(t, []string{"title", "filepath", "n", "n"}, results.ColumnNames())
+	assert.Equal(t, [][]any{{"Plan", "plan.md", int32(1), int32(2)}}, results.Rows)
+	assert.Equal(t, int32(1), results.Value(0, "n"), "Value returns the first matching column")
+	assert.Nil(t, results.Value(0, "missing"))
+}
+
+func TestResultSet_EmptyResult(t *testing.T) {
+	results := queryResultSet(t, "SELECT 1 AS a WHERE false")
+
+	assert.Equal(t, 0, results.Len())
+	assert.Equal(t, []Column{{Name: "a", Type: "INTEGER"}}, results.Columns)
+	assert.NotNil(t, results.Rows)
+}
+
+func TestResultSet_ColumnTypes(t *testing.T) {
+	results := queryResultSet(t, `SELECT
+		'x' AS s,
+		MAP(['a'], [1]) AS m,
+		[1, 2] AS l,
+		{'k': 'v'} AS st`)
+
+	assert.Equal(t, []Column{
+		{Name: "s", Type: "VARCHAR"},
+		{Name: "m", Type: "MAP(VARCHAR, INTEGER)"},
+		{Name: "l", Type: "INTEGER[]"},
+		{Name: "st", Type: `STRUCT("k" VARCHAR)`},
+	}, results.Columns)
+}
+
+func TestResultSet_NormalizesValues(t *testing.T) {
+	results := queryResultSet(t, `SELECT
+		'00000000-0000-0000-0000-000000000001'::UUID AS id,
+		DATE '2025-01-02' AS d,
+		TIME '01:02:03' AS tm,
+		TIMESTAMP '2025-01-02 03:04:05' AS ts,
+		1.25::DECIMAL(4,2) AS dec,
+		INTERVAL 3 DAY AS i,
+		[1.5::DECIMAL(3,1)] AS decs`)
+
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", results.Value(0, "id"))
+	assert.Equal(t, "2025-01-02", results.Value(0, "d"))
+	assert.Equal(t, "01:02:03", results.Value(0, "tm"))
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), results.Value(0, "ts"))
+	assert.Equal(t, 1.25, results.Value(0, "dec"))
+	assert.Equal(t, "3 days", results.Value(0, "i"))
+	assert.Equal(t, []any{1.5}, results.Value(0, "decs"))
+}
+
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		interval duckdb.Interval
+		expected string
+	}{
+		{duckdb.Interval{}, "00:00:00"},
+		{duckdb.Interval{Days: 1}, "1 day"},
+		{duckdb.Interval{Months: 14, Days: 3}, "1 year 2 months 3 days"},
+		{duckdb.Interval{Micros: int64(90*time.Minute/time.Microsecond) + 500}, "01:30:00.0005"},
+		{duckdb.Interval{Days: -2, Micros: -int64(time.Hour / time.Microsecond)}, "-2 days -01:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatInterval(tt.interval))
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "NULL"},
+		{"string", "text", "text"},
+		{"int", int32(42), "42"},
+		{"float", 3.5, "3.5"},
+		{"bool", true, "true"},
+		{"date", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), "2025-01-02"},
+		{"timestamp", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), "2025-01-02T03:04:05Z"},
+		{"list", []any{"a", int32(1), nil}, "[a, 1, NULL]"},
+		{"struct", map[string]any{"b": int32(2), "a": []any{"x"}}, "{a: [x], b: 2}"},
+		{"map", duckdb.Map{"title": "Plan", "tags": []any{"work"}}, "{tags: [work], title: Plan}"},
+		{"nested", []any{map[string]any{"a": int32(1)}}, "[{a: 1}]"},
+		{"blob", []byte("abc"), "abc"},
+		{"binary blob", []byte{0xff, 0x00}, `\xff00`},
+		{"hugeint", big.NewInt(12345678901), "12345678901"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatValue(tt.value))
+		})
+	}
+}
+
+func TestFormatColumnValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		dbType   string
+		expected string
+	}{
+		{"struct", map[string]any{"b": int32(2), "a": "x"}, `STRUCT("b" INTEGER, "a" VARCHAR)`, "{b: 2, a: x}"},
+		{"quoted names", map[string]any{`say "hi"`: "x", "a, b": int32(1)}, `STRUCT("say ""hi""" VARCHAR, "a, b" INTEGER)`, `{say "hi": x, a, b: 1}`},
+		{"list of structs", []any{map[string]any{"z": int32(1), "y": int32(2)}}, `STRUCT("z" INTEGER, "y" INTEGER)[]`, "[{z: 1, y: 2}]"},
+		{"nested struct", map[string]any{"n": map[string]any{"d": int32(1), "c": int32(2)}, "m": nil}, `STRUCT("n" STRUCT("d" INTEGER, "c" INTEGER), "m" INTEGER)`, "{n: {d: 1, c: 2}, m: NULL}"},
+		{"map of structs", duckdb.Map{"k": map[string]any{"b": int32(1), "a": int32(2)}}, `MAP(VARCHAR, STRUCT("b" INTEGER, "a" INTEGER))`, "{k: {b: 1, a: 2}}"},
+		{"unknown type", map[string]any{"b": int32(2), "a": int32(1)}, "", "{a: 1, b: 2}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatColumnValue(tt.value, tt.dbType))
+		})
+	}
+}
+
+func TestFormatColumnValue_StructFromQuery(t *testing.T) {
+	results := queryResultSet(t, `SELECT {'title': 'Plan', 'status': 'todo', 'about': {'z': 1, 'a': 2}} AS note`)
+
+	assert.Equal(t, "{title: Plan, status: todo, about: {z: 1, a: 2}}", FormatColumnValue(results.Rows[0][0], results.Columns[0].Type))
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Default limits for user SQL queries.
+const (
+	DefaultSQLMemoryLimit = "512MB"
+	DefaultSQLThreads     = 2
+	DefaultSQLMaxRows     = 10000
+)
+
+// openSandbox opens an in-memory database for user queries against the
+// notebook at root. Once configured it can only read files under root and
+// the notebook's index, can't install or load extensions, and its settings
+// are locked so queries can't loosen them.
+func (d *DbService) openSandbox(ctx context.Context, root string) (*sql.DB, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+
+	// Extensions must be loaded before external access is switched off
+	if err := d.loadMarkdown(ctx, db, true, root); err != nil {
+		closeSandbox(db)
+		return nil, fmt.Errorf("read-only connection: %w", err)
+	}
+
+	for _, stmt := range d.sandboxSettings(root) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			closeSandbox(db)
+			return nil, fmt.Errorf("failed to configure read-only database: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+func closeSandbox(db *sql.DB) {
+	if err := db.Close(); err != nil {
+		log := Log("DbService")
+		log.Warn().Err(err).Msg("failed to close read-only database")
+	}
+}
+
+// sandboxSettings returns the SET statements that restrict a sandbox
+// database to root. lock_configuration must come last.
+func (d *DbService) sandboxSettings(root string) []string {
+	var directories, paths []string
+	if root != "" {
+		// The trailing separator stops /notes from also allowing /notes-old
+		directories = append(directories, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+
+		index := IndexPath(d.options.IndexDir, root)
+		paths = append(paths, index, index+".wal")
+	}
+
+	memoryLimit := d.options.SQLMemoryLimit
+	if memoryLimit == "" {
+		memoryLimit = DefaultSQLMemoryLimit
+	}
+	threads := d.options.SQLThreads
+	if threads <= 0 {
+		threads = DefaultSQLThreads
+	}
+
+	return []string{
+		"SET GLOBAL allowed_directories = " + sqlStringList(directories),
+		"SET GLOBAL allowed_paths = " + sqlStringList(paths),
+		"SET GLOBAL enable_external_access = false",
+		"SET GLOBAL autoinstall_known_extensions = false",
+		"SET GLOBAL autoload_known_extensions = false",
+		"SET GLOBAL memory_limit = " + quoteSQLString(memoryLimit),
+		fmt.Sprintf("SET GLOBAL threads = %d", threads),
+		"SET GLOBAL lock_configuration = true",
+	}
+}
+
+// sqlMaxRows returns the most rows a user query may return.
+func (d *DbService) sqlMaxRows() int {
+	if d.options.SQLMaxRows <= 0 {
+		return DefaultSQLMaxRows
+	}
+	return d.options.SQLMaxRows
+}
+
+func sqlStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteSQLString(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Errors for user queries that aren't a single read-only statement.
+var (
+	errOnlySelect      = errors.New("only SELECT queries are allowed")
+	errSingleStatement = errors.New("only a single statement is allowed")
+)
+
+// checkStatement parses query with DuckDB and rejects anything but a single
+// SELECT statement with errOnlySelect or errSingleStatement. Statements such
+// as COPY or EXPORT can write to allowed directories, so they must be stopped
+// before execution, and even preparing them has side effects.
+//
+// json_serialize_sql only parses, and only supports SELECT statements.
+// Syntax errors are left for DuckDB to report when the query runs.
+func checkStatement(ctx context.Context, db *sql.DB, query string) error {
+	var serialized string
+	if err := db.QueryRowContext(ctx, "SELECT json_serialize_sql(?::VARCHAR)::VARCHAR", query).Scan(&serialized); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	var parsed struct {
+		Error      bool              `json:"error"`
+		ErrorType  string            `json:"error_type"`
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal([]byte(serialized), &parsed); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	switch {
+	case parsed.Error && parsed.ErrorType == "parser":
+		return nil
+	case parsed.Error:
+		return errOnlySelect
+	case len(parsed.Statements) != 1:
+		return errSingleStatement
+	}
+	return nil
+}
+
+// sandboxPattern resolves a read_markdown pattern for a reader confined to
+// root. Relative patterns are taken relative to root. With an empty root
+// there's no notebook to read, so every pattern is refused.
+func sandboxPattern(root, pattern string) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("Permission Error: Cannot access file %q - no notebook is open", pattern)
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(root, pattern)
+	}
+	pattern = filepath.Clean(pattern)
+
+	if !withinDir(root, pattern) {
+		return "", fmt.Errorf("Permission Error: Cannot access file %q - only files in the notebook can be read", pattern)
+	}
+	return pattern, nil
+}
+
+// insideRoot reports whether path, after following symlinks, is under root.
+// Nothing is inside an empty root.
+func insideRoot(root, path string) bool {
+	if root == "" {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return withinDir(root, path)
+}
+
+// withinDir reports whether path is dir or lexically below it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStatement(t *testing.T) {
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	ctx := context.Background()
+	db, err := svc.GetReadOnlyDB(ctx, root)
+	require.NoError(t, err)
+
+	allowed := []string{
+		"SELECT 1",
+		"WITH a AS (SELECT 1) SELECT * FROM a",
+		"FROM (SELECT 1 AS a)",
+		"SELECT replace('a', 'a', 'b')",
+		"SELECT 1;",
+		// Syntax errors are reported when the query runs
+		"SELECT * REPLACE INTO markdown",
+	}
+	for _, query := range allowed {
+		assert.NoError(t, checkStatement(ctx, db, query), query)
+	}
+
+	// COPY and EXPORT can reach the notebook, so only the statement type stops them
+	out := filepath.Join(root, "out")
+	rejected := map[string]error{
+		"COPY (SELECT 1) TO '" + out + ".csv'": errOnlySelect,
+		"EXPORT DATABASE '" + out + "'":        errOnlySelect,
+		"ATTACH ':memory:' AS other":           errOnlySelect,
+		"CREATE TABLE t (a INTEGER)":           errOnlySelect,
+		"SET threads = 8":                      errOnlySelect,
+		"LOAD markdown":                        errOnlySelect,
+		"PRAGMA version":                       errOnlySelect,
+		"SELECT 1; SELECT 2":                   errSingleStatement,
+		"SELECT 1; DROP TABLE t":               errOnlySelect,
+	}
+	for query, expected := range rejected {
+		assert.ErrorIs(t, checkStatement(ctx, db, query), expected, query)
+	}
+	assert.NoFileExists(t, out+".csv")
+	assert.NoDirExists(t, out)
+}
+
+func TestSandbox_RestrictsFilesToRoot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "notes")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "a.md"), []byte("# A\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "data.csv"), []byte("x\n1\n"), 0644))
+	require.NoError(t, os.MkdirAll(root+"-old", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root+"-old", "data.csv"), []byte("x\n2\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.md"), []byte("secret\n"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret.md"), filepath.Join(root, "link.md")))
+
+	svc := newTestDbService(t, DbOptions{})
+	db, err := svc.GetReadOnlyDB(ctx, root)
+	require.NoError(t, err)
+
+	query := func(q string) (*ResultSet, error) {
+		rows, err := db.QueryContext(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rows.Close() }()
+		return NewResultSet(rows)
+	}
+
+	results, err := query("SELECT * FROM read_csv('" + filepath.Join(root, "data.csv") + "')")
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Len())
+
+	// Relative patterns resolve against the root; symlinks out of it are skipped
+	results, err = query("SELECT filepath FROM read_markdown('**/*.md')")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{filepath.Join(root, "sub", "a.md")}}, results.Rows)
+
+	denied := []string{
+		"SELECT * FROM read_csv('" + filepath.Join(root+"-old", "data.csv") + "')",
+		"SELECT * FROM read_text('/etc/hostname')",
+		"SELECT * FROM read_markdown('../*.md')",
+		"SELECT * FROM read_markdown('" + filepath.Join(dir, "*.md") + "')",
+	}
+	for _, q := range denied {
+		_, err := query(q)
+		require.Error(t, err, q)
+		assert.Contains(t, err.Error(), "Permission Error", q)
+	}
+}
+
+func TestSandbox_ConfigurationIsLocked(t *testing.T) {
+	svc := newTestDbService(t, DbOptions{SQLMemoryLimit: "128MB", SQLThreads: 1})
+	db, err := svc.GetReadOnlyDB(context.Background(), t.TempDir())
+	require.NoError(t, err)
+
+	var threads int64
+	require.NoError(t, db.QueryRow("SELECT current_setting('threads')").Scan(&threads))
+	assert.Equal(t, int64(1), threads)
+
+	_, err = db.Exec("SET enable_external_access = true")
+	assert.ErrorContains(t, err, "locked")
+
+	_, err = db.Exec("SET allowed_directories = ['/']")
+	assert.ErrorContains(t, err, "locked")
+}
+
+func TestSandbox_AttachesIndex(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.md"), []byte("# A\n"), 0644))
+
+	svc := newTestDbService(t, DbOptions{})
+	notes := NewNoteService(nil, svc, root)
+
+	results, err := notes.ExecuteSQLSafe(ctx, "SELECT relative FROM notebook.notes")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"a.md"}}, results.Rows)
+}
+
+func TestSandbox_MaxRows(t *testing.T) {
+	svc := newTestDbService(t, DbOptions{SQLMaxRows: 2})
+	notes := NewNoteService(nil, svc, t.TempDir())
+
+	results, err := notes.ExecuteSQLSafe(context.Background(), "SELECT * FROM range(5)")
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Len())
+	assert.True(t, results.Truncated)
+
+	results, err = notes.ExecuteSQLSafe(context.Background(), "SELECT * FROM range(2)")
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Len())
+	assert.False(t, results.Truncated)
+}
+
+func TestSandboxPattern(t *testing.T) {
+	root := filepath.FromSlash("/nb")
+
+	tests := []struct {
+		pattern  string
+		expected string
+		err      bool
+	}{
+		{"**/*.md", "/nb/**/*.md", false},
+		{"projects/../a.md", "/nb/a.md", false},
+		{"/nb/a.md", "/nb/a.md", false},
+		{"../*.md", "", true},
+		{"/etc/*", "", true},
+		{"/nb-old/*.md", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pattern, err := sandboxPattern(root, tt.pattern)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expected), pattern)
+		})
+	}
+
+	_, err := sandboxPattern("", "*.md")
+	assert.ErrorContains(t, err, "Permission Error", "an empty root allows no files")
+	assert.False(t, insideRoot("", "/usr/share/doc/a.md"))
+}
+
+func TestNoteService_ExecuteSQLSafe_NoNotebookReadsNoFiles(t *testing.T) {
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), "")
+
+	_, err := notes.ExecuteSQLSafe(context.Background(), "SELECT filepath FROM read_markdown('/usr/share/**/*.md')")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permission Error")
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// BM25 tuning parameters. These are the commonly used defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// prefixMatchWeight is how much a prefix-only match ("java" in "javascript")
+// counts towards term frequency compared with a stem match.
+const prefixMatchWeight = 0.5
+
+// snippetLength is the approximate length of search result snippets.
+const snippetLength = 160
+
+// searchDocument is a note prepared for full-text ranking.
+type searchDocument struct {
+	note   *Note
+	tokens []core.Token
+}
+
+// newSearchDocument tokenizes a note's title, path and content.
+// Title tokens are included twice so title matches rank above body matches.
+func newSearchDocument(note *Note) searchDocument {
+	var tokens []core.Token
+	if title, ok := note.Metadata["title"].(string); ok {
+		titleTokens := core.Tokenize(title)
+		tokens = append(tokens, titleTokens...)
+		tokens = append(tokens, titleTokens...)
+	}
+	tokens = append(tokens, core.Tokenize(note.File.Relative)...)
+	tokens = append(tokens, core.Tokenize(note.Content)...)
+
+	return searchDocument{note: note, tokens: tokens}
+}
+
+// frequency counts the tokens matching term, weighting prefix-only matches lower.
+func (d searchDocument) frequency(term core.SearchTerm) float64 {
+	n := 0.0
+	for _, token := range d.tokens {
+		switch {
+		case token.Term == term.Stem:
+			n++
+		case term.Matches(token):
+			n += prefixMatchWeight
+		}
+	}
+	return n
+}
+
+// containsPhrase reports whether the document has the phrase's terms in order.
+func (d searchDocument) containsPhrase(phrase []core.SearchTerm) bool {
+	for i := 0; i+len(phrase) <= len(d.tokens); i++ {
+		found := true
+		for j, term := range phrase {
+			if !term.Matches(d.tokens[i+j]) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// rankNotes returns the notes matching query, best match first, with each
+// note's Score and Snippet set. Every term and phrase must match.
+func rankNotes(notes []Note, query core.FullTextQuery) []Note {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	terms := query.AllTerms()
+
+	docs := make([]searchDocument, len(notes))
+	frequencies := make([][]float64, len(notes))
+	documentFrequency := make([]int, len(terms))
+	totalLength := 0
+	for i := range notes {
+		docs[i] = newSearchDocument(&notes[i])
+		totalLength += len(docs[i].tokens)
+
+		frequencies[i] = make([]float64, len(terms))
+		for j, term := range terms {
+			frequencies[i][j] = docs[i].frequency(term)
+			if frequencies[i][j] > 0 {
+				documentFrequency[j]++
+			}
+		}
+	}
+	avgLength := float64(totalLength) / float64(len(docs))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	idf := make([]float64, len(terms))
+	for j, n := range documentFrequency {
+		idf[j] = math.Log(1 + (float64(len(docs))-float64(n)+0.5)/(float64(n)+0.5))
+	}
+
+	var results []Note
+	for i, doc := range docs {
+		if !matchesQuery(doc, frequencies[i], query) {
+			continue
+		}
+
+		score := 0.0
+		length := float64(len(doc.tokens))
+		for j := range terms {
+			tf := frequencies[i][j]
+			score += idf[j] * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+		}
+
+		note := *doc.note
+		note.Score = score
+		note.Snippet = core.Snippet(note.Content, terms, snippetLength)
+		results = append(results, note)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].File.Relative < results[j].File.Relative
+	})
+
+	return results
+}
+
+// matchesQuery reports whether a document with the given per-term
+// frequencies contains every term and phrase of query.
+func matchesQuery(doc searchDocument, frequencies []float64, query core.FullTextQuery) bool {
+	for _, n := range frequencies {
+		if n == 0 {
+			return false
+		}
+	}
+	for _, phrase := range query.Phrases {
+		if !doc.containsPhrase(phrase) {
+			return false
+		}
+	}
+	return true
+}
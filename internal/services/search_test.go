@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/core"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+func newSearchNote(relative, title, content string) Note {
+	note := Note{Content: content, Metadata: map[string]any{}, Tags: []string{}}
+	note.File.Relative = relative
+	note.File.Filepath = "/nb/" + relative
+	if title != "" {
+		note.Metadata["title"] = title
+	}
+	return note
+}
+
+func relatives(notes []Note) []string {
+	paths := make([]string, len(notes))
+	for i, note := range notes {
+		paths[i] = note.File.Relative
+	}
+	return paths
+}
+
+func TestRankNotes_OrdersByRelevance(t *testing.T) {
+	notes := []Note{
+		newSearchNote("a.md", "", "A passing mention of golang among many other words about cooking and gardening."),
+		newSearchNote("b.md", "", "Golang golang golang. Writing golang services."),
+		newSearchNote("c.md", "", "Nothing relevant here."),
+	}
+
+	results := rankNotes(notes, core.ParseFullTextQuery("golang"))
+
+	assert.Equal(t, []string{"b.md", "a.md"}, relatives(results))
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+func TestRankNotes_Stemming(t *testing.T) {
+	notes := []Note{newSearchNote("a.md", "", "Weekly meetings with the team.")}
+
+	results := rankNotes(notes, core.ParseFullTextQuery("meeting"))
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "Weekly **meetings** with the team.", results[0].Snippet)
+}
+
+func TestRankNotes_RequiresAllTerms(t *testing.T) {
+	notes := []Note{
+		newSearchNote("a.md", "", "release notes"),
+		newSearchNote("b.md", "", "release plan"),
+	}
+
+	assert.Equal(t, []string{"b.md"}, relatives(rankNotes(notes, core.ParseFullTextQuery("release plan"))))
+}
+
+func TestRankNotes_PrefixMatch(t *testing.T) {
+	notes := []Note{
+		newSearchNote("a.md", "", "Some javascript tricks."),
+		newSearchNote("b.md", "", "Some java tricks."),
+		newSearchNote("c.md", "", "Some python tricks."),
+	}
+
+	assert.Equal(t, []string{"b.md", "a.md"}, relatives(rankNotes(notes, core.ParseFullTextQuery("java"))))
+}
+
+func TestRankNotes_Phrase(t *testing.T) {
+	notes := []Note{
+		newSearchNote("a.md", "", "plan the release"),
+		newSearchNote("b.md", "", "the release plan"),
+	}
+
+	assert.Equal(t, []string{"b.md"}, relatives(rankNotes(notes, core.ParseFullTextQuery(`"release plan"`))))
+}
+
+func TestRankNotes_TitleAndPath(t *testing.T) {
+	notes := []Note{
+		newSearchNote("body.md", "", "Some text about roadmap items."),
+		newSearchNote("title.md", "Roadmap", "Some text about items."),
+		newSearchNote("roadmap-2025.md", "", "Unrelated body."),
+	}
+
+	results := rankNotes(notes, core.ParseFullTextQuery("roadmap"))
+
+	assert.ElementsMatch(t, []string{"body.md", "title.md", "roadmap-2025.md"}, relatives(results))
+	assert.Equal(t, "title.md", results[0].File.Relative)
+	for _, note := range results {
+		if note.File.Relative == "roadmap-2025.md" {
+			assert.Empty(t, note.Snippet, "path-only matches have no content snippet")
+		}
+	}
+}
+
+func TestNoteService_SearchNotes_Ranked(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "once.md", "# Notes\n\nOne deploy step among a long list of unrelated chores and errands.\n")
+	testutil.WriteNote(t, root, "many.md", "# Deploys\n\nDeploy checklist: deploy, verify, deploy again.\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "deploying")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"many.md", "once.md"}, relatives(notes))
+	assert.Contains(t, notes[0].Snippet, "**Deploy**")
+}
+
+func TestNoteService_SearchNotes_PunctuationFallsBackToSubstring(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "a.md", "Question?! Answer.\n")
+	testutil.WriteNote(t, root, "b.md", "Nothing.\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "?!")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md"}, relatives(notes))
+}
+
+func TestNoteService_SearchNotes_CJK(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "a.md", "这是测试文档\n")
+	testutil.WriteNote(t, root, "b.md", "试一下, 测量\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "测试")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md"}, relatives(notes))
+	assert.Equal(t, "这是**测试**文档", notes[0].Snippet)
+}
+
+func TestNoteService_SearchNotes_InnerWordFallsBackToSubstring(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+
+	testutil.WriteNote(t, root, "a.md", "Weekly meeting notes.\n")
+	testutil.WriteNote(t, root, "b.md", "Nothing.\n")
+
+	notes, err := NewNoteService(nil, svc, root).SearchNotes(ctx, "eeting")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md"}, relatives(notes))
+}
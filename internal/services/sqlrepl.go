@@ -0,0 +1,455 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog"
+)
+
+// SQL REPL prompts.
+const (
+	SQLPrompt             = "sql> "
+	SQLContinuationPrompt = "  -> "
+)
+
+// DefaultSQLHistorySize is the number of statements kept in the history file.
+const DefaultSQLHistorySize = 1000
+
+// ErrQuit is returned by SQLRepl.HandleLine when the user asks to leave.
+var ErrQuit = errors.New("quit")
+
+// sqlKeywords are offered by tab completion along with table and column names.
+var sqlKeywords = []string{
+	"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "HAVING", "LIMIT",
+	"OFFSET", "JOIN", "LEFT JOIN", "USING", "DISTINCT", "WITH", "AS", "AND",
+	"OR", "NOT", "IN", "IS NULL", "IS NOT NULL", "LIKE", "ILIKE", "COUNT",
+	"DESC", "ASC", "UNNEST", "LATERAL",
+}
+
+const sqlReplHelp = `Enter SQL ending with ; to run it. Statements may span lines.
+
+Meta-commands:
+  .tables           List the tables and views you can query
+  .schema [name]    Show the columns of a table or view (all if no name)
+  .help             Show this help
+  .quit             Leave the REPL (or press Ctrl-D)
+`
+
+// SQLRepl is an interactive SQL session against a notebook. Queries run
+// through NoteService.ExecuteSQLSafe, so they have the same restrictions as
+// --sql. It handles input a line at a time; reading lines and line editing
+// are left to the caller.
+type SQLRepl struct {
+	notes   *NoteService
+	display *Display
+	format  OutputFormat
+	out     io.Writer
+	history *SQLHistory
+	pending []string
+	// words are the tab completion candidates, see LoadCompletions
+	words []string
+	log   zerolog.Logger
+}
+
+// NewSQLRepl creates a REPL writing results to out, rendered by display in
+// text format. history may be nil.
+func NewSQLRepl(notes *NoteService, display *Display, format OutputFormat, out io.Writer, history *SQLHistory) *SQLRepl {
+	return &SQLRepl{
+		notes:   notes,
+		display: display,
+		format:  format,
+		out:     out,
+		history: history,
+		words:   append([]string(nil), sqlKeywords...),
+		log:     Log("SQLRepl"),
+	}
+}
+
+// Prompt returns the prompt for the next line of input.
+func (r *SQLRepl) Prompt() string {
+	if len(r.pending) > 0 {
+		return SQLContinuationPrompt
+	}
+	return SQLPrompt
+}
+
+// HandleLine processes one line of input. Lines are collected until they end
+// a statement with ";", then the statement is run. Meta-commands start with
+// "." and take a single line. Query errors are written to the output and
+// don't stop the session; ErrQuit means the user asked to leave.
+func (r *SQLRepl) HandleLine(ctx context.Context, line string) error {
+	trimmed := strings.TrimSpace(line)
+	if len(r.pending) == 0 {
+		if trimmed == "" {
+			return nil
+		}
+		if strings.HasPrefix(trimmed, ".") {
+			r.record(trimmed)
+			return r.meta(ctx, trimmed)
+		}
+	}
+
+	r.pending = append(r.pending, line)
+	statement := strings.Join(r.pending, "\n")
+	if !statementComplete(statement) {
+		return nil
+	}
+	r.pending = nil
+
+	r.record(statement)
+	r.run(ctx, statement)
+	return nil
+}
+
+// Flush runs a statement that was started but not ended with ";", for when
+// input ends.
+func (r *SQLRepl) Flush(ctx context.Context) {
+	statement := strings.Join(r.pending, "\n")
+	r.pending = nil
+	if strings.TrimSpace(statement) == "" {
+		return
+	}
+	r.record(statement)
+	r.run(ctx, statement)
+}
+
+func (r *SQLRepl) record(entry string) {
+	if r.history == nil {
+		return
+	}
+	if err := r.history.Record(entry); err != nil {
+		r.log.Warn().Err(err).Msg("failed to save SQL history")
+	}
+}
+
+// run executes a statement and writes its results or error.
+func (r *SQLRepl) run(ctx context.Context, query string) {
+	results, err := r.notes.ExecuteSQLSafe(ctx, query)
+	if err != nil {
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+		return
+	}
+	r.render(results)
+}
+
+func (r *SQLRepl) render(results *ResultSet) {
+	var err error
+	if r.format == OutputText {
+		err = r.display.RenderSQLResultsTo(r.out, results)
+	} else {
+		err = WriteSQLResults(r.out, r.format, results)
+	}
+	if err != nil {
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+	}
+}
+
+// meta runs a meta-command.
+func (r *SQLRepl) meta(ctx context.Context, command string) error {
+	name, arg, _ := strings.Cut(command, " ")
+	arg = strings.TrimSuffix(strings.TrimSpace(arg), ";")
+
+	switch name {
+	case ".quit", ".exit", ".q":
+		return ErrQuit
+	case ".help":
+		fmt.Fprint(r.out, sqlReplHelp)
+	case ".tables":
+		r.run(ctx, tablesQuery)
+	case ".schema":
+		r.run(ctx, schemaQuery(arg))
+	default:
+		fmt.Fprintf(r.out, "Error: unknown command %s, enter .help for help\n", name)
+	}
+	return nil
+}
+
+// tablesQuery lists the views in main and the tables of the attached index.
+const tablesQuery = `SELECT CASE WHEN table_catalog = '` + IndexCatalog + `' THEN '` + IndexCatalog + `.' || table_name ELSE table_name END AS name,
+	lower(replace(table_type, 'BASE ', '')) AS type
+FROM information_schema.tables
+WHERE table_schema = 'main' AND table_catalog IN (current_database(), '` + IndexCatalog + `')
+ORDER BY table_catalog <> current_database(), table_name`
+
+// schemaQuery returns the query for .schema. name may be catalog-qualified,
+// e.g. notebook.notes.
+func schemaQuery(name string) string {
+	query := `SELECT CASE WHEN table_catalog = '` + IndexCatalog + `' THEN '` + IndexCatalog + `.' || table_name ELSE table_name END AS "table",
+	column_name AS "column",
+	data_type AS type
+FROM information_schema.columns
+WHERE table_schema = 'main' AND table_catalog IN (current_database(), '` + IndexCatalog + `')`
+
+	if name != "" {
+		catalog, table, qualified := strings.Cut(name, ".")
+		if qualified {
+			query += " AND table_catalog = " + quoteSQLString(catalog) + " AND table_name = " + quoteSQLString(table)
+		} else {
+			query += " AND table_catalog = current_database() AND table_name = " + quoteSQLString(name)
+		}
+	}
+
+	return query + "\nORDER BY table_catalog <> current_database(), table_name, ordinal_position"
+}
+
+// LoadCompletions adds the notebook's table, view and column names and the
+// frontmatter keys in use to the tab completion candidates.
+func (r *SQLRepl) LoadCompletions(ctx context.Context) error {
+	queries := []string{
+		"SELECT DISTINCT table_name FROM information_schema.tables WHERE table_schema = 'main'",
+		"SELECT DISTINCT column_name FROM information_schema.columns WHERE table_schema = 'main'",
+		"SELECT DISTINCT unnest(map_keys(metadata)) FROM notes",
+	}
+
+	seen := make(map[string]bool, len(r.words))
+	for _, word := range r.words {
+		seen[word] = true
+	}
+	for _, query := range queries {
+		results, err := r.notes.ExecuteSQLSafe(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to load completions: %w", err)
+		}
+		for _, row := range results.Rows {
+			word, ok := row[0].(string)
+			if ok && word != "" && !seen[word] {
+				seen[word] = true
+				r.words = append(r.words, word)
+			}
+		}
+	}
+
+	sort.Strings(r.words)
+	return nil
+}
+
+// Complete completes the word before pos in line. It matches the signature of
+// the golang.org/x/term AutoCompleteCallback and only acts on tab. A unique
+// match is completed in full; otherwise the longest common prefix is filled in.
+func (r *SQLRepl) Complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	start := pos
+	for start > 0 && isWordByte(line[start-1]) {
+		start--
+	}
+	prefix := line[start:pos]
+	if prefix == "" {
+		return "", 0, false
+	}
+
+	var matches []string
+	for _, word := range r.words {
+		if len(word) > len(prefix) && strings.EqualFold(word[:len(prefix)], prefix) {
+			matches = append(matches, word)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	if len(completion) <= len(prefix) {
+		return "", 0, false
+	}
+	// Keep what was typed, keywords are completed in the case they were started in
+	completion = prefix + completion[len(prefix):]
+	if isKeyword(matches[0]) && strings.ToLower(prefix) == prefix {
+		completion = strings.ToLower(completion)
+	}
+
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func isKeyword(word string) bool {
+	for _, keyword := range sqlKeywords {
+		if keyword == word {
+			return true
+		}
+	}
+	return false
+}
+
+// commonPrefix returns the longest prefix shared by words, ignoring case.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		n := 0
+		for n < len(prefix) && n < len(word) && unicode.ToLower(rune(prefix[n])) == unicode.ToLower(rune(word[n])) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// statementComplete reports whether sql ends with a ";" that isn't inside a
+// string, quoted identifier or comment.
+func statementComplete(sql string) bool {
+	var quote byte
+	complete := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			complete = false
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			// Skip a line comment
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == ';':
+			complete = true
+		case !unicode.IsSpace(rune(c)):
+			complete = false
+		}
+	}
+	return complete && quote == 0
+}
+
+// SQLHistory is the REPL's statement history, saved to a file. It implements
+// the golang.org/x/term History interface, but entries are only added by
+// Record so a statement entered over several lines is recalled as one.
+type SQLHistory struct {
+	path    string
+	max     int
+	entries []string
+}
+
+// DefaultSQLHistoryFile returns the default history file path.
+func DefaultSQLHistoryFile() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cacheDir, "opennotes", "sql_history")
+}
+
+// LoadSQLHistory reads the history file at path, keeping the last max
+// entries. A missing file is an empty history.
+func LoadSQLHistory(path string, max int) (*SQLHistory, error) {
+	if max <= 0 {
+		max = DefaultSQLHistorySize
+	}
+	h := &SQLHistory{path: path, max: max}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry := decodeHistoryEntry(scanner.Text()); entry != "" {
+			h.entries = append(h.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read SQL history: %w", err)
+	}
+
+	if len(h.entries) > max {
+		// Drop the old entries from the file too, so it doesn't grow forever
+		h.entries = h.entries[len(h.entries)-max:]
+		var b strings.Builder
+		for _, entry := range h.entries {
+			b.WriteString(encodeHistoryEntry(entry))
+		}
+		if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+			return nil, fmt.Errorf("failed to trim SQL history: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// Record adds a statement to the history and appends it to the history file.
+// The statement is kept as entered, line breaks included.
+func (h *SQLHistory) Record(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return nil
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(encodeHistoryEntry(entry)); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// encodeHistoryEntry returns entry as a line of the history file: a JSON
+// string, so that statements over several lines stay on one.
+func encodeHistoryEntry(entry string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(entry)
+	return b.String()
+}
+
+// decodeHistoryEntry reads a line of the history file. Lines that aren't
+// JSON strings are from older versions, which wrote statements as they were.
+func decodeHistoryEntry(line string) string {
+	var entry string
+	if strings.HasPrefix(line, `"`) && json.Unmarshal([]byte(line), &entry) == nil {
+		return entry
+	}
+	return line
+}
+
+// Add is called by the line editor for every line read. It does nothing,
+// statements are added whole with Record.
+func (h *SQLHistory) Add(string) {}
+
+// Len returns the number of entries.
+func (h *SQLHistory) Len() int {
+	return len(h.entries)
+}
+
+// At returns an entry, 0 being the most recent.
+func (h *SQLHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+// Entries returns the entries, oldest first.
+func (h *SQLHistory) Entries() []string {
+	return append([]string(nil), h.entries...)
+}
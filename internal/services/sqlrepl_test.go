@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLRepl(t *testing.T, format OutputFormat, history *SQLHistory) (*SQLRepl, *bytes.Buffer) {
+	t.Helper()
+	notes, _ := newTestNoteService(t, viewsNotes)
+	display, err := NewDisplay()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	return NewSQLRepl(notes, display, format, &out, history), &out
+}
+
+func TestSQLRepl_MultiLineStatement(t *testing.T) {
+	repl, out := newTestSQLRepl(t, OutputJSON, nil)
+	ctx := context.Background()
+
+	require.NoError(t, repl.HandleLine(ctx, "SELECT relative"))
+	assert.Equal(t, SQLContinuationPrompt, repl.Prompt())
+	assert.Empty(t, out.String())
+
+	require.NoError(t, repl.HandleLine(ctx, "FROM notes WHERE title = 'a;b' OR relative = 'daily-log.md';"))
+	assert.Equal(t, SQLPrompt, repl.Prompt())
+	assert.JSONEq(t, `[{"relative":"daily-log.md"}]`, out.String())
+}
+
+func TestSQLRepl_ErrorsDontEndSession(t *testing.T) {
+	repl, out := newTestSQLRepl(t, OutputText, nil)
+	ctx := context.Background()
+
+	require.NoError(t, repl.HandleLine(ctx, "SELECT * FROM missing_table;"))
+	assert.Contains(t, out.String(), "Error: query execution failed")
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, "DROP TABLE notes;"))
+	assert.Contains(t, out.String(), "Error: invalid query")
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, "SELECT count(*) AS n FROM notes;"))
+	assert.Contains(t, out.String(), "1 row")
+}
+
+func TestSQLRepl_MetaCommands(t *testing.T) {
+	repl, out := newTestSQLRepl(t, OutputText, nil)
+	ctx := context.Background()
+
+	require.NoError(t, repl.HandleLine(ctx, ".tables"))
+	for _, name := range []string{"notes", "links", "headings", "code_blocks", "tasks", "notebook.notes"} {
+		assert.Contains(t, out.String(), name)
+	}
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, ".schema tasks"))
+	assert.Contains(t, out.String(), "done")
+	assert.NotContains(t, out.String(), "metadata")
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, ".schema notebook.notes"))
+	assert.Contains(t, out.String(), "frontmatter")
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, ".help"))
+	assert.Contains(t, out.String(), ".schema")
+
+	out.Reset()
+	require.NoError(t, repl.HandleLine(ctx, ".bogus"))
+	assert.Contains(t, out.String(), "unknown command .bogus")
+
+	assert.ErrorIs(t, repl.HandleLine(ctx, ".quit"), ErrQuit)
+}
+
+func TestSQLRepl_Flush(t *testing.T) {
+	repl, out := newTestSQLRepl(t, OutputCSV, nil)
+	ctx := context.Background()
+
+	require.NoError(t, repl.HandleLine(ctx, "SELECT 1 AS one"))
+	repl.Flush(ctx)
+	assert.Equal(t, "one\n1\n", out.String())
+	assert.Equal(t, SQLPrompt, repl.Prompt())
+}
+
+func TestSQLRepl_Complete(t *testing.T) {
+	repl, _ := newTestSQLRepl(t, OutputText, nil)
+	require.NoError(t, repl.LoadCompletions(context.Background()))
+
+	tests := []struct {
+		line    string
+		newLine string
+		ok      bool
+	}{
+		{"sel", "select", true},
+		{"SEL", "SELECT", true},
+		{"SELECT * FROM code_", "SELECT * FROM code_blocks", true},
+		{"SELECT line_n", "SELECT line_number", true},
+		{"SELECT metadata['tit", "SELECT metadata['title", true},
+		// Ambiguous: LIMIT, links, line_number... share nothing beyond "l"
+		{"SELECT * FROM notes WHERE l", "", false},
+		{"SELECT ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			newLine, pos, ok := repl.Complete(tt.line, len(tt.line), '\t')
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.newLine, newLine)
+				assert.Equal(t, len(tt.newLine), pos)
+			}
+		})
+	}
+
+	_, _, ok := repl.Complete("sel", 3, 'x')
+	assert.False(t, ok, "only tab completes")
+}
+
+func TestSQLRepl_RecordsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	history, err := LoadSQLHistory(path, 0)
+	require.NoError(t, err)
+
+	repl, _ := newTestSQLRepl(t, OutputText, history)
+	ctx := context.Background()
+	require.NoError(t, repl.HandleLine(ctx, "SELECT title"))
+	require.NoError(t, repl.HandleLine(ctx, "  FROM notes;"))
+	require.NoError(t, repl.HandleLine(ctx, ".tables"))
+
+	assert.Equal(t, []string{"SELECT title\n  FROM notes;", ".tables"}, history.Entries())
+	assert.Equal(t, ".tables", history.At(0))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\"SELECT title\\n  FROM notes;\"\n\".tables\"\n", string(data))
+}
+
+func TestSQLHistory_KeepsStatements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	history, err := LoadSQLHistory(path, 0)
+	require.NoError(t, err)
+
+	statement := "SELECT title -- the title\nFROM notes\nWHERE title = 'a  <b>';"
+	require.NoError(t, history.Record("\n"+statement+"\n"))
+
+	reloaded, err := LoadSQLHistory(path, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{statement}, reloaded.Entries())
+}
+
+func TestSQLHistory_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("one\nold entry\n\n\"three\\nlines\"\n"), 0600))
+
+	history, err := LoadSQLHistory(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old entry", "three\nlines"}, history.Entries())
+
+	// The file is trimmed to the limit too
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\"old entry\"\n\"three\\nlines\"\n", string(data))
+
+	// Repeating the last entry doesn't add it again
+	require.NoError(t, history.Record("three\nlines"))
+	history.Add("ignored")
+	assert.Equal(t, 2, history.Len())
+}
+
+func TestSQLHistory_Missing(t *testing.T) {
+	history, err := LoadSQLHistory(filepath.Join(t.TempDir(), "missing", "history"), 0)
+	require.NoError(t, err)
+	assert.Zero(t, history.Len())
+
+	require.NoError(t, history.Record("SELECT 1;"))
+	assert.Equal(t, 1, history.Len())
+}
+
+func TestStatementComplete(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT 1;":                    true,
+		"SELECT 1;  ":                  true,
+		"SELECT 1":                     false,
+		"SELECT ';'":                   false,
+		"SELECT ';';":                  true,
+		`SELECT 1 AS ";"`:              false,
+		"SELECT 1; -- done":            true,
+		"SELECT 1 -- not done;":        false,
+		"SELECT 1 -- comment\n;":       true,
+		strings.Repeat("SELECT 1;", 2): true,
+	}
+
+	for sql, expected := range tests {
+		assert.Equal(t, expected, statementComplete(sql), sql)
+	}
+}
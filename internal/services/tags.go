@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zenobi-us/opennotes/internal/core"
+)
+
+// TagCount is a tag and how many notes have it.
+type TagCount struct {
+	Tag string `json:"tag"`
+	// Count is the number of notes with the tag or a tag under it
+	Count int `json:"count"`
+}
+
+// Tags returns every tag in the notebook with its note count, in tag order.
+// The tags above a nested tag are included, so a note tagged project/alpha
+// counts for project and project/alpha.
+func (s *NoteService) Tags(ctx context.Context) ([]TagCount, error) {
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, note := range notes {
+		seen := make(map[string]bool)
+		for _, tag := range note.Tags {
+			for _, level := range core.TagLevels(tag) {
+				if !seen[level] {
+					seen[level] = true
+					counts[level]++
+				}
+			}
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags, nil
+}
+
+// TagRenamePlan is a tag rename and the notes it changes.
+type TagRenamePlan struct {
+	// From and To are the normalised tags
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Edits are the notes whose tags change
+	Edits []TagEdit `json:"edits"`
+	// Warnings are notes that couldn't be changed, e.g. with invalid frontmatter
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// TagEdit is new content for a note with renamed tags.
+type TagEdit struct {
+	Path     string `json:"path"`
+	Relative string `json:"relative"`
+	// Tags is the number of tags renamed
+	Tags   int    `json:"tags"`
+	Before string `json:"-"`
+	After  string `json:"-"`
+}
+
+// TagCount returns the number of tags the plan renames.
+func (p *TagRenamePlan) TagCount() int {
+	count := 0
+	for _, edit := range p.Edits {
+		count += edit.Tags
+	}
+	return count
+}
+
+// PlanTagRename works out renaming the tag from to to across the notebook, in
+// frontmatter tags fields and #hashtags. Tags under from move with it, so
+// renaming project to work turns project/alpha into work/alpha. Renaming to
+// a tag that's already used merges the two. Nothing is changed until the plan
+// is applied.
+func (s *NoteService) PlanTagRename(ctx context.Context, from, to string) (*TagRenamePlan, error) {
+	plan := &TagRenamePlan{From: core.NormalizeTag(from), To: core.NormalizeTag(to)}
+	if plan.From == "" {
+		return nil, fmt.Errorf("invalid tag %q", from)
+	}
+	if !core.ValidTag(plan.To) {
+		return nil, fmt.Errorf("invalid tag %q, tags are letters, digits, _, - and / with at least one letter", to)
+	}
+	if plan.From == plan.To {
+		return nil, fmt.Errorf("%s and %s are the same tag", from, to)
+	}
+
+	notes, err := s.loadNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rename := func(tag string) (string, bool) {
+		if !core.HasTagPrefix(tag, plan.From) {
+			return "", false
+		}
+		return plan.To + strings.TrimPrefix(tag, plan.From), true
+	}
+
+	found := false
+	for _, note := range notes {
+		tagged := false
+		for _, tag := range note.Tags {
+			tagged = tagged || core.HasTagPrefix(tag, plan.From)
+		}
+		if !tagged {
+			continue
+		}
+		found = true
+
+		data, err := os.ReadFile(note.File.Filepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", note.File.Relative, err)
+		}
+		after, count, err := core.RewriteTags(string(data), rename)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %v", note.File.Relative, err))
+			continue
+		}
+		if count > 0 {
+			plan.Edits = append(plan.Edits, TagEdit{
+				Path:     note.File.Filepath,
+				Relative: note.File.Relative,
+				Tags:     count,
+				Before:   string(data),
+				After:    after,
+			})
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no notes are tagged %s", plan.From)
+	}
+	return plan, nil
+}
+
+// Diff returns the plan as a unified diff of every edited note.
+func (p *TagRenamePlan) Diff() string {
+	var b strings.Builder
+	for _, edit := range p.Edits {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", edit.Relative, edit.Relative)
+		b.WriteString(unifiedDiff(edit.Relative, edit.Relative, edit.Before, edit.After))
+	}
+	return b.String()
+}
+
+// Apply writes the edited notes.
+func (p *TagRenamePlan) Apply() error {
+	for _, edit := range p.Edits {
+		info, err := os.Stat(edit.Path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", edit.Relative, err)
+		}
+		if err := os.WriteFile(edit.Path, []byte(edit.After), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to update tags in %s: %w", edit.Relative, err)
+		}
+	}
+	return nil
+}
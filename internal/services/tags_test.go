@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+// tagsNotes is a notebook of tagged notes, one with invalid frontmatter.
+var tagsNotes = map[string]string{
+	"plan.md":   "---\ntitle: Plan\ntags: [Project/Alpha, work]\n---\n# Plan\n\nShip #project/alpha by Friday. #urgent\n",
+	"retro.md":  "---\ntags:\n  - project/beta\n  - work\n---\nSee `#project` and #projects.\n",
+	"log.md":    "Worked on #project today.\n",
+	"broken.md": "---\ntags: [unclosed\n---\n#project\n",
+}
+
+func TestNoteService_LoadsTags(t *testing.T) {
+	notes, _ := newTestNoteService(t, tagsNotes)
+
+	note, err := notes.FindNote(context.Background(), "plan.md")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project/alpha", "urgent", "work"}, note.Tags)
+}
+
+func TestNoteService_Tags(t *testing.T) {
+	notes, _ := newTestNoteService(t, tagsNotes)
+
+	tags, err := notes.Tags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []TagCount{
+		{Tag: "project", Count: 4},
+		{Tag: "project/alpha", Count: 1},
+		{Tag: "project/beta", Count: 1},
+		{Tag: "projects", Count: 1},
+		{Tag: "urgent", Count: 1},
+		{Tag: "work", Count: 2},
+	}, tags)
+}
+
+func TestNoteService_PlanTagRename(t *testing.T) {
+	notes, root := newTestNoteService(t, tagsNotes)
+
+	ctx := context.Background()
+
+	plan, err := notes.PlanTagRename(ctx, "#Project", "client")
+	require.NoError(t, err)
+	assert.Equal(t, "project", plan.From)
+	assert.Equal(t, "client", plan.To)
+	assert.Equal(t, []string{"log.md", "plan.md", "retro.md"}, tagEditRelatives(plan))
+	assert.Equal(t, 4, plan.TagCount())
+	require.Len(t, plan.Warnings, 1)
+	assert.Contains(t, plan.Warnings[0], "broken.md")
+
+	assert.Contains(t, plan.Diff(), "diff --git a/log.md b/log.md\n--- a/log.md\n+++ b/log.md\n@@ -1 +1 @@\n-Worked on #project today.\n+Worked on #client today.\n")
+	assert.Equal(t, "Worked on #project today.\n", testutil.ReadNote(t, root, "log.md"), "nothing changes until applied")
+
+	require.NoError(t, plan.Apply())
+	assert.Equal(t, "---\ntitle: Plan\ntags: [client/alpha, work]\n---\n# Plan\n\nShip #client/alpha by Friday. #urgent\n", testutil.ReadNote(t, root, "plan.md"))
+	assert.Equal(t, "---\ntags:\n  - client/beta\n  - work\n---\nSee `#project` and #projects.\n", testutil.ReadNote(t, root, "retro.md"))
+	assert.Equal(t, "Worked on #client today.\n", testutil.ReadNote(t, root, "log.md"))
+}
+
+func TestNoteService_PlanTagRename_Merge(t *testing.T) {
+	notes, root := newTestNoteService(t, tagsNotes)
+
+	plan, err := notes.PlanTagRename(context.Background(), "project/alpha", "work")
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply())
+	assert.Equal(t, "---\ntitle: Plan\ntags: [work]\n---\n# Plan\n\nShip #work by Friday. #urgent\n", testutil.ReadNote(t, root, "plan.md"))
+}
+
+func TestNoteService_PlanTagRename_Errors(t *testing.T) {
+	notes, _ := newTestNoteService(t, tagsNotes)
+
+	ctx := context.Background()
+
+	_, err := notes.PlanTagRename(ctx, "missing", "other")
+	assert.EqualError(t, err, "no notes are tagged missing")
+
+	_, err = notes.PlanTagRename(ctx, "work", "#Work")
+	assert.EqualError(t, err, "work and #Work are the same tag")
+
+	_, err = notes.PlanTagRename(ctx, "work", "a.b")
+	assert.ErrorContains(t, err, `invalid tag "a.b"`)
+
+	_, err = notes.PlanTagRename(ctx, "#", "work")
+	assert.ErrorContains(t, err, `invalid tag "#"`)
+}
+
+func tagEditRelatives(plan *TagRenamePlan) []string {
+	relatives := make([]string, len(plan.Edits))
+	for i, edit := range plan.Edits {
+		relatives[i] = edit.Relative
+	}
+	return relatives
+}
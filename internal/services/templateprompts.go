@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplatePrompt is a value a template asks for when a note is created from
+// it, declared in the template's frontmatter:
+//
+//	prompts:
+//	  - attendees
+//	  - name: project
+//	    message: Which project?
+//	    default: opennotes
+//	  - name: kind
+//	    choices: [planning, retro, standup]
+//
+// The value is used in the template as {{ .Vars.name }}, or
+// {{ yaml .Vars.name }} in frontmatter.
+type TemplatePrompt struct {
+	Name string `yaml:"name" json:"name"`
+	// Message is the question asked, the name when empty
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// Default is used when no value is given. Without one the prompt is required.
+	Default *string `yaml:"default,omitempty" json:"default,omitempty"`
+	// Choices, when set, are the only values accepted
+	Choices []string `yaml:"choices,omitempty" json:"choices,omitempty"`
+}
+
+// UnmarshalYAML accepts a prompt's name on its own as well as a mapping.
+func (p *TemplatePrompt) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		p.Name = node.Value
+		return nil
+	}
+
+	type plain TemplatePrompt
+	return node.Decode((*plain)(p))
+}
+
+// Prompts returns the prompts of the named template and the templates it
+// extends. A prompt replaces the prompt of the same name in the template it
+// extends.
+func (t *NoteTemplates) Prompts(name string) ([]TemplatePrompt, error) {
+	chain, err := t.chain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompts []TemplatePrompt
+	for _, tmpl := range chain {
+		own, err := templatePrompts(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		for _, prompt := range own {
+			replaced := false
+			for i := range prompts {
+				if prompts[i].Name == prompt.Name {
+					prompts[i] = prompt
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				prompts = append(prompts, prompt)
+			}
+		}
+	}
+	return prompts, nil
+}
+
+// templatePrompts parses the prompts field of a template's frontmatter.
+func templatePrompts(tmpl *NoteTemplate) ([]TemplatePrompt, error) {
+	field := templateField(tmpl.Content, templatePromptsKey)
+	if field == "" {
+		return nil, nil
+	}
+
+	var parsed struct {
+		Prompts []TemplatePrompt `yaml:"prompts"`
+	}
+	if err := yaml.Unmarshal([]byte(field), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid prompts in template %s: %w", tmpl.Name, err)
+	}
+	for _, prompt := range parsed.Prompts {
+		if prompt.Name == "" {
+			return nil, fmt.Errorf("invalid prompts in template %s: prompt without a name", tmpl.Name)
+		}
+	}
+	return parsed.Prompts, nil
+}
+
+// AskTemplatePrompts returns vars with a value for every prompt. Prompts
+// that already have a value in vars aren't asked, but the value must be one
+// of their choices.
+//
+// The rest are asked on out, reading answers from in a line at a time: an
+// empty answer takes the default, and a choice can be answered with its
+// number. Invalid answers are asked again. When in is nil nothing is asked,
+// prompts take their defaults and prompts without one are errors.
+func AskTemplatePrompts(prompts []TemplatePrompt, vars map[string]string, in io.Reader, out io.Writer) (map[string]string, error) {
+	result := make(map[string]string, len(vars)+len(prompts))
+	for name, value := range vars {
+		result[name] = value
+	}
+
+	var reader *bufio.Reader
+	if in != nil {
+		reader = bufio.NewReader(in)
+	}
+
+	for _, prompt := range prompts {
+		if value, ok := result[prompt.Name]; ok {
+			if !prompt.accepts(value) {
+				return nil, fmt.Errorf("invalid value %q for %s, expected one of: %s", value, prompt.Name, strings.Join(prompt.Choices, ", "))
+			}
+			continue
+		}
+
+		if reader == nil {
+			if prompt.Default == nil {
+				return nil, fmt.Errorf("missing value for %s, set it with --var %s=value", prompt.Name, prompt.Name)
+			}
+			result[prompt.Name] = *prompt.Default
+			continue
+		}
+
+		value, err := prompt.ask(reader, out)
+		if err != nil {
+			return nil, err
+		}
+		result[prompt.Name] = value
+	}
+	return result, nil
+}
+
+// accepts reports whether value is one of the prompt's choices, when it has
+// any.
+func (p TemplatePrompt) accepts(value string) bool {
+	if len(p.Choices) == 0 {
+		return true
+	}
+	for _, choice := range p.Choices {
+		if choice == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ask asks for the prompt's value until it gets a valid answer.
+func (p TemplatePrompt) ask(reader *bufio.Reader, out io.Writer) (string, error) {
+	message := p.Message
+	if message == "" {
+		message = p.Name
+	}
+	for i, choice := range p.Choices {
+		if i == 0 {
+			fmt.Fprintf(out, "%s:\n", message)
+		}
+		fmt.Fprintf(out, "  %d) %s\n", i+1, choice)
+	}
+
+	for {
+		if len(p.Choices) > 0 {
+			fmt.Fprint(out, "Choose")
+		} else {
+			fmt.Fprint(out, message)
+		}
+		if p.Default != nil {
+			fmt.Fprintf(out, " [%s]", *p.Default)
+		}
+		fmt.Fprint(out, ": ")
+
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read %s: %w", p.Name, err)
+		}
+		answer := strings.TrimSpace(line)
+
+		switch {
+		case answer == "" && p.Default != nil:
+			return *p.Default, nil
+		case answer == "":
+			fmt.Fprintln(out, "A value is required.")
+		case p.accepts(answer):
+			return answer, nil
+		default:
+			if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(p.Choices) {
+				return p.Choices[n-1], nil
+			}
+			fmt.Fprintf(out, "Choose one of: %s\n", strings.Join(p.Choices, ", "))
+		}
+	}
+}
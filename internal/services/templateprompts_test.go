@@ -0,0 +1,92 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoteTemplates_Prompts(t *testing.T) {
+	templates, err := LoadNoteTemplates(map[string]string{
+		"base": "---\ntitle: {{ .Title }}\nprompts:\n  - attendees\n  - name: project\n    message: Which project?\n    default: opennotes\n---\n",
+		"meeting": "---\nextends: base\nprompts:\n  - name: project\n    choices: [api, web]\n  - name: kind\n    default: sync\n---\n" +
+			"Project: {{ .Vars.project }} ({{ .Vars.kind }}) with {{ .Vars.attendees }}\n",
+		"plain":   "# {{ .Title }}\n",
+		"invalid": "---\nprompts:\n  - message: no name\n---\n",
+	}, "", "")
+	require.NoError(t, err)
+
+	prompts, err := templates.Prompts("meeting")
+	require.NoError(t, err)
+	assert.Equal(t, []TemplatePrompt{
+		{Name: "attendees"},
+		{Name: "project", Choices: []string{"api", "web"}},
+		{Name: "kind", Default: stringPtr("sync")},
+	}, prompts)
+
+	prompts, err = templates.Prompts("plain")
+	require.NoError(t, err)
+	assert.Empty(t, prompts)
+
+	_, err = templates.Prompts("invalid")
+	assert.EqualError(t, err, "invalid prompts in template invalid: prompt without a name")
+
+	result, err := templates.Render("base", NoteTemplateData{Title: "Sync", Vars: map[string]string{"project": "api"}})
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Sync\n---\n", result)
+
+	result, err = templates.Render("meeting", NoteTemplateData{
+		Title: "Sync",
+		Date:  time.Date(2025, 3, 28, 9, 0, 0, 0, time.UTC),
+		Vars:  map[string]string{"project": "api", "kind": "retro", "attendees": "ana, bo"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Sync\n---\nProject: api (retro) with ana, bo\n", result)
+}
+
+func TestAskTemplatePrompts(t *testing.T) {
+	prompts := []TemplatePrompt{
+		{Name: "attendees", Message: "Who's coming?"},
+		{Name: "project", Default: stringPtr("opennotes")},
+		{Name: "kind", Choices: []string{"planning", "retro"}},
+	}
+
+	t.Run("asks for missing values", func(t *testing.T) {
+		var out strings.Builder
+		vars, err := AskTemplatePrompts(prompts, map[string]string{"owner": "ana"}, strings.NewReader("\nana, bo\n\nstandup\n2\n"), &out)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"owner": "ana", "attendees": "ana, bo", "project": "opennotes", "kind": "retro"}, vars)
+		assert.Equal(t, "Who's coming?: A value is required.\nWho's coming?: project [opennotes]: kind:\n  1) planning\n  2) retro\nChoose: Choose one of: planning, retro\nChoose: ", out.String())
+	})
+
+	t.Run("skips values given", func(t *testing.T) {
+		var out strings.Builder
+		vars, err := AskTemplatePrompts(prompts, map[string]string{"attendees": "bo", "kind": "planning"}, strings.NewReader("api\n"), &out)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"attendees": "bo", "project": "api", "kind": "planning"}, vars)
+		assert.Equal(t, "project [opennotes]: ", out.String())
+	})
+
+	t.Run("without input", func(t *testing.T) {
+		vars, err := AskTemplatePrompts(prompts, map[string]string{"attendees": "bo", "kind": "retro"}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"attendees": "bo", "project": "opennotes", "kind": "retro"}, vars)
+
+		_, err = AskTemplatePrompts(prompts, map[string]string{"kind": "retro"}, nil, nil)
+		assert.EqualError(t, err, "missing value for attendees, set it with --var attendees=value")
+	})
+
+	t.Run("invalid choice", func(t *testing.T) {
+		_, err := AskTemplatePrompts(prompts, map[string]string{"attendees": "bo", "kind": "standup"}, nil, nil)
+		assert.EqualError(t, err, `invalid value "standup" for kind, expected one of: planning, retro`)
+	})
+
+	t.Run("input ends", func(t *testing.T) {
+		var out strings.Builder
+		_, err := AskTemplatePrompts(prompts, nil, strings.NewReader(""), &out)
+		assert.ErrorContains(t, err, "failed to read attendees")
+	})
+}
@@ -253,6 +253,7 @@ func TestTuiRender_NoteDetail(t *testing.T) {
 		"Title":    "My Note",
 		"File":     note.File,
 		"Metadata": note.Metadata,
+		"Tags":     []string{"example", "test"},
 		"Content":  note.Content,
 	}
 
@@ -272,6 +273,10 @@ func TestTuiRender_NoteDetail(t *testing.T) {
 	if !strings.Contains(result, "note content") {
 		t.Errorf("TuiRender() result = %q, want to contain 'note content'", result)
 	}
+
+	if !strings.Contains(result, "#example, #test") {
+		t.Errorf("TuiRender() result = %q, want to contain '#example, #test'", result)
+	}
 }
 
 func TestTemplates_Loaded(t *testing.T) {
@@ -347,3 +352,17 @@ func TestTuiRender_ValidTemplate_ValidContext(t *testing.T) {
 		t.Errorf("output should contain note reference, got: %s", output)
 	}
 }
+
+func TestTuiRender_NoteList_WithSnippet(t *testing.T) {
+	note := Note{Snippet: "planning the **release** today"}
+	note.File.Relative = "release.md"
+
+	result, err := TuiRender("note-list", map[string]any{"Notes": []Note{note}})
+	if err != nil {
+		t.Fatalf("TuiRender() failed: %v", err)
+	}
+
+	if !strings.Contains(result, "release") || !strings.Contains(result, "planning the") {
+		t.Errorf("TuiRender() result = %q, want to contain the snippet", result)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// elementViews are the views over markdown elements in notes, built from the
+// md_extract_* functions. Each row also has the note's filepath and relative path.
+var elementViews = []struct {
+	name     string
+	function string
+	fields   []string
+}{
+	{"links", "md_extract_links", []string{"text", "url", "line_number"}},
+	{"headings", "md_extract_headers", []string{"level", "text", "line_number"}},
+	{"code_blocks", "md_extract_code_blocks", []string{"language", "code", "line_number"}},
+	{"tasks", "md_extract_tasks", []string{"done", "text", "line_number"}},
+}
+
+// createViews (re)creates the notes view, the note_links view, the markdown
+// element views and the saved query views on db for the current notebook.
+// With the index attached the notes view reads from it, otherwise it reads
+// the notebook's files with read_markdown. Views are created in main,
+// temporary views would only exist on one connection.
+func (s *NoteService) createViews(ctx context.Context, db *sql.DB, indexAttached bool) error {
+	for _, stmt := range notebookViews(s.notebookPath, s.groups, indexAttached) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create notebook views: %w", err)
+		}
+	}
+	// Saved query views only refer to other views by name, so they're made once
+	s.queryViews.Do(func() { s.createQueryViews(ctx, db) })
+	return nil
+}
+
+// notebookViews returns the CREATE VIEW statements for the notebook at root.
+func notebookViews(root string, groups []NotebookGroup, indexAttached bool) []string {
+	source := IndexCatalog + ".notes"
+	if !indexAttached {
+		root = filepath.Clean(root)
+		source = fmt.Sprintf(`(SELECT filepath,
+				substr(filepath, %d) AS relative,
+				content,
+				metadata,
+				NULL::TIMESTAMP AS mtime,
+				NULL::BIGINT AS size
+			FROM read_markdown(%s, include_filepath := true))`,
+			len(root)+2,
+			quoteSQLString(filepath.Join(root, "**", "*.md")),
+		)
+	}
+
+	// Links between notes are resolved by the index, so without it there are none
+	links := IndexCatalog + ".links"
+	linkCounts := `(SELECT count(*) FROM main.note_links l WHERE l.source = n.relative AND l.target IS NOT NULL) AS link_count,
+				(SELECT count(*) FROM main.note_links l WHERE l.target = n.relative AND l.source <> n.relative) AS backlink_count`
+	if !indexAttached {
+		links = `(SELECT NULL::VARCHAR AS source, NULL::VARCHAR AS target, NULL::VARCHAR AS kind,
+				NULL::VARCHAR AS link, NULL::VARCHAR AS fragment, NULL::BIGINT AS line
+			WHERE false)`
+		linkCounts = "NULL::BIGINT AS link_count, NULL::BIGINT AS backlink_count"
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE VIEW main.note_links AS
+			SELECT source, target, kind, link, fragment, line FROM %s`, links),
+		fmt.Sprintf(`CREATE OR REPLACE VIEW main.notes AS
+			SELECT filepath,
+				relative,
+				coalesce(nullif(metadata['title'], ''), opennotes_slugify(parse_filename(relative, true))) AS title,
+				metadata,
+				content,
+				mtime,
+				size,
+				%s AS groups,
+				groups[1] AS "group",
+				opennotes_note_tags(metadata['tags'], content) AS tags,
+				%s
+			FROM %s n`, groupsExpression(groups), linkCounts, source),
+	}
+
+	for _, view := range elementViews {
+		columns := make([]string, len(view.fields))
+		for i, field := range view.fields {
+			columns[i] = "e." + field
+		}
+		statements = append(statements, fmt.Sprintf(
+			`CREATE OR REPLACE VIEW main.%s AS
+			SELECT n.filepath, n.relative, %s
+			FROM main.notes n, LATERAL UNNEST(%s(n.content)) AS u(e)`,
+			view.name, strings.Join(columns, ", "), view.function,
+		))
+	}
+
+	return statements
+}
+
+// groupsExpression returns a SQL expression listing the groups whose globs
+// match the relative column, in config order, as groupsOf does.
+func groupsExpression(groups []NotebookGroup) string {
+	var names []string
+	for _, group := range groups {
+		if len(group.Globs) == 0 {
+			continue
+		}
+		matches := make([]string, len(group.Globs))
+		for i, glob := range group.Globs {
+			matches[i] = fmt.Sprintf("opennotes_match_glob(%s, relative)", quoteSQLString(glob))
+		}
+		names = append(names, fmt.Sprintf("CASE WHEN %s THEN %s END", strings.Join(matches, " OR "), quoteSQLString(group.Name)))
+	}
+
+	if len(names) == 0 {
+		return "[]::VARCHAR[]"
+	}
+	return "list_filter([" + strings.Join(names, ", ") + "], g -> g IS NOT NULL)"
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+const viewsSample = `---
+title: Release Plan
+---
+# Release
+
+See [the spec](specs/release.md).
+
+- [x] Write notes
+- [ ] Ship it
+
+` + "```go\nfmt.Println(\"hi\")\n```\n"
+
+// viewsNotes is a notebook with one project note and one note outside it.
+var viewsNotes = map[string]string{
+	"projects/release.md": viewsSample,
+	"daily-log.md":        "Nothing yet\n",
+}
+
+// viewsGroups puts the project note in Projects and every note in Default.
+var viewsGroups = []NotebookGroup{
+	{Name: "Projects", Globs: []string{"projects/**/*.md"}},
+	{Name: "Default", Globs: []string{"**/*.md"}},
+}
+
+func TestNotesView(t *testing.T) {
+	notes, root := newTestNoteService(t, viewsNotes)
+	notes.groups = viewsGroups
+
+	results, err := notes.ExecuteSQLSafe(context.Background(),
+		`SELECT filepath, relative, title, groups, "group", size > 0 FROM notes ORDER BY relative`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"filepath", "relative", "title", "groups", "group", "(size > 0)"}, results.ColumnNames())
+	assert.Equal(t, [][]any{
+		{filepath.Join(root, "daily-log.md"), "daily-log.md", "daily-log", []any{"Default"}, "Default", true},
+		{filepath.Join(root, "projects", "release.md"), "projects/release.md", "Release Plan", []any{"Projects", "Default"}, "Projects", true},
+	}, results.Rows)
+}
+
+func TestNotesView_Tags(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteNote(t, root, "plan.md", "---\ntags: [Work, project/alpha]\n---\nAbout #home.\n")
+	testutil.WriteNote(t, root, "log.md", "---\ntags: work\n---\n")
+	testutil.WriteNote(t, root, "empty.md", "Nothing yet\n")
+	notes := NewNoteService(nil, newTestDbService(t, DbOptions{}), root)
+
+	results, err := notes.ExecuteSQLSafe(context.Background(),
+		"SELECT relative, array_to_string(tags, ' '), len(tags) FROM notes ORDER BY relative")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"empty.md", nil, int64(0)},
+		{"log.md", "work", int64(1)},
+		{"plan.md", "home project/alpha work", int64(3)},
+	}, results.Rows)
+}
+
+func TestNotesView_TitleFilter(t *testing.T) {
+	notes, _ := newTestNoteService(t, viewsNotes)
+
+	results, err := notes.ExecuteSQLSafe(context.Background(),
+		"SELECT relative FROM notes WHERE title = 'Release Plan'")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"projects/release.md"}}, results.Rows)
+}
+
+func TestElementViews(t *testing.T) {
+	notes, _ := newTestNoteService(t, viewsNotes)
+	ctx := context.Background()
+
+	tests := []struct {
+		query    string
+		expected [][]any
+	}{
+		{"SELECT relative, text, url FROM links", [][]any{{"projects/release.md", "the spec", "specs/release.md"}}},
+		{"SELECT level, text FROM headings", [][]any{{int32(1), "Release"}}},
+		{"SELECT language, code FROM code_blocks", [][]any{{"go", "fmt.Println(\"hi\")\n"}}},
+		{"SELECT done, text FROM tasks ORDER BY line_number", [][]any{{true, "Write notes"}, {false, "Ship it"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := notes.ExecuteSQLSafe(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, results.Rows)
+		})
+	}
+}
+
+func TestNotebookViews_WithoutIndex(t *testing.T) {
+	notes, root := newTestNoteService(t, viewsNotes)
+	notes.groups = viewsGroups
+	ctx := context.Background()
+
+	db, err := notes.dbService.GetReadOnlyDB(ctx, root)
+	require.NoError(t, err)
+	require.NoError(t, notes.createViews(ctx, db, false))
+
+	rows, err := db.QueryContext(ctx, `SELECT relative, title, "group", mtime FROM notes ORDER BY relative`)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+
+	results, err := NewResultSet(rows)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"daily-log.md", "daily-log", "Default", nil},
+		{"projects/release.md", "Release Plan", "Projects", nil},
+	}, results.Rows)
+}
+
+func TestNotesView_GroupsMatchNoteGroups(t *testing.T) {
+	notes, _ := newTestNoteService(t, viewsNotes)
+	// The catch-all group comes first, so "group" is Default for every note
+	notes.groups = []NotebookGroup{viewsGroups[1], viewsGroups[0]}
+	ctx := context.Background()
+
+	results, err := notes.ExecuteSQLSafe(ctx, `SELECT relative, groups, "group" FROM notes ORDER BY relative`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{"daily-log.md", []any{"Default"}, "Default"},
+		{"projects/release.md", []any{"Default", "Projects"}, "Default"},
+	}, results.Rows)
+
+	listed, err := notes.loadNotes(ctx)
+	require.NoError(t, err)
+	for i, note := range listed {
+		assert.Equal(t, results.Rows[i][1], toAnySlice(note.Groups), note.File.Relative)
+	}
+
+	results, err = notes.ExecuteSQLSafe(ctx, "SELECT relative FROM notes WHERE list_contains(groups, 'Projects')")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"projects/release.md"}}, results.Rows)
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+func TestGroupsExpression(t *testing.T) {
+	assert.Equal(t, "[]::VARCHAR[]", groupsExpression(nil))
+	assert.Equal(t,
+		"list_filter([CASE WHEN opennotes_match_glob('a/*.md', relative) OR opennotes_match_glob('b/*.md', relative) THEN 'It''s' END], g -> g IS NOT NULL)",
+		groupsExpression([]NotebookGroup{{Name: "Empty"}, {Name: "It's", Globs: []string{"a/*.md", "b/*.md"}}}),
+	)
+}
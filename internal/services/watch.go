@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// Watch event types.
+const (
+	WatchEventCreated  = "created"
+	WatchEventModified = "modified"
+	WatchEventRenamed  = "renamed"
+	WatchEventDeleted  = "deleted"
+)
+
+// defaultWatchDebounce is how long the watcher waits for a burst of
+// filesystem events (e.g. an editor's save dance) to settle.
+const defaultWatchDebounce = 200 * time.Millisecond
+
+// WatchEvent describes a change to a note on disk.
+// For renames, Path is the note's old location; the new location is reported
+// as a separate created event.
+type WatchEvent struct {
+	Type     string    `json:"type"`
+	Path     string    `json:"path"`
+	Relative string    `json:"relative"`
+	Time     time.Time `json:"time"`
+}
+
+// NoteWatcher watches a notebook for note changes and keeps its index fresh.
+type NoteWatcher struct {
+	root     string
+	index    *NoteIndex
+	debounce time.Duration
+	log      zerolog.Logger
+}
+
+// NewNoteWatcher creates a watcher for the notes under root.
+// The index is refreshed after every batch of changes.
+func NewNoteWatcher(index *NoteIndex, root string) *NoteWatcher {
+	return &NoteWatcher{
+		root:     root,
+		index:    index,
+		debounce: defaultWatchDebounce,
+		log:      Log("NoteWatcher"),
+	}
+}
+
+// Watch blocks until ctx is cancelled, calling handler for every note change.
+// Events are batched, the index is refreshed, and then the batch is delivered
+// in path order, so handlers querying the index see the new state.
+// Between batches the index file is detached so other processes can use it.
+func (w *NoteWatcher) Watch(ctx context.Context, handler func(WatchEvent)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer func() {
+		if err := watcher.Close(); err != nil {
+			w.log.Warn().Err(err).Msg("failed to close watcher")
+		}
+	}()
+
+	if err := w.addTree(watcher, w.root); err != nil {
+		return err
+	}
+
+	if _, err := w.index.Refresh(ctx); err != nil {
+		return err
+	}
+	w.releaseIndex(ctx)
+
+	w.log.Debug().Str("root", w.root).Msg("watching notebook")
+
+	pending := make(map[string]WatchEvent)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.log.Warn().Err(err).Msg("watch error")
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if w.handleEvent(watcher, event, pending) {
+				timer.Reset(w.debounce)
+			}
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+
+			if _, err := w.index.Refresh(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				w.log.Warn().Err(err).Msg("failed to refresh note index")
+			}
+			w.releaseIndex(ctx)
+
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			for _, path := range paths {
+				handler(pending[path])
+			}
+			pending = make(map[string]WatchEvent)
+		}
+	}
+}
+
+// releaseIndex detaches the index between batches. Holding it attached would
+// keep DuckDB's write lock and push other processes onto an in-memory index.
+func (w *NoteWatcher) releaseIndex(ctx context.Context) {
+	if err := w.index.release(ctx); err != nil && ctx.Err() == nil {
+		w.log.Warn().Err(err).Msg("failed to release note index")
+	}
+}
+
+// handleEvent records a filesystem event in pending.
+// Returns true if the event concerned a note.
+func (w *NoteWatcher) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event, pending map[string]WatchEvent) bool {
+	// New directories need their own watches since fsnotify isn't recursive
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(watcher, event.Name); err != nil {
+				w.log.Warn().Err(err).Str("path", event.Name).Msg("failed to watch directory")
+			}
+			// Notes moved in with the directory produce no events of their own
+			w.addExisting(event.Name, pending)
+			return len(pending) > 0
+		}
+	}
+
+	if !strings.HasSuffix(event.Name, ".md") {
+		return false
+	}
+
+	var eventType string
+	switch {
+	case event.Has(fsnotify.Create):
+		eventType = WatchEventCreated
+	case event.Has(fsnotify.Write):
+		eventType = WatchEventModified
+	case event.Has(fsnotify.Rename):
+		eventType = WatchEventRenamed
+	case event.Has(fsnotify.Remove):
+		eventType = WatchEventDeleted
+	default:
+		// Chmod alone doesn't change a note
+		return false
+	}
+
+	// A note created then written within one batch is still just created
+	if prev, ok := pending[event.Name]; ok && prev.Type == WatchEventCreated && eventType == WatchEventModified {
+		eventType = WatchEventCreated
+	}
+
+	pending[event.Name] = WatchEvent{
+		Type:     eventType,
+		Path:     event.Name,
+		Relative: w.index.relative(event.Name),
+		Time:     time.Now(),
+	}
+	return true
+}
+
+// addTree watches dir and every directory below it.
+func (w *NoteWatcher) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// addExisting records a created event for every note under dir.
+func (w *NoteWatcher) addExisting(dir string, pending map[string]WatchEvent) {
+	files, err := scanMarkdownFiles(dir)
+	if err != nil {
+		return
+	}
+	for path := range files {
+		pending[path] = WatchEvent{
+			Type:     WatchEventCreated,
+			Path:     path,
+			Relative: w.index.relative(path),
+			Time:     time.Now(),
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zenobi-us/opennotes/internal/testutil"
+)
+
+// startWatcher runs a watcher on root and returns its event channel.
+func startWatcher(t *testing.T, svc *DbService, root string) <-chan WatchEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan WatchEvent, 32)
+	done := make(chan error, 1)
+
+	watcher := NewNoteWatcher(svc.NoteIndex(root), root)
+	watcher.debounce = 20 * time.Millisecond
+
+	go func() {
+		done <- watcher.Watch(ctx, func(event WatchEvent) {
+			events <- event
+		})
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	// Give the watcher time to register its watches
+	time.Sleep(100 * time.Millisecond)
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan WatchEvent) WatchEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+		return WatchEvent{}
+	}
+}
+
+func TestNoteWatcher_CreateModifyDelete(t *testing.T) {
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	events := startWatcher(t, svc, root)
+
+	path := testutil.WriteNote(t, root, "note.md", "# Note\n")
+
+	event := nextEvent(t, events)
+	assert.Equal(t, WatchEventCreated, event.Type)
+	assert.Equal(t, path, event.Path)
+	assert.Equal(t, "note.md", event.Relative)
+
+	// The index is refreshed before events are delivered
+	count, err := NewNoteService(nil, svc, root).Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	testutil.WriteNote(t, root, "note.md", "# Note\n\nMore.\n")
+	assert.Equal(t, WatchEventModified, nextEvent(t, events).Type)
+
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, WatchEventDeleted, nextEvent(t, events).Type)
+}
+
+func TestNoteWatcher_IgnoresNonMarkdown(t *testing.T) {
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	events := startWatcher(t, svc, root)
+
+	testutil.WriteNote(t, root, "scratch.txt", "ignored")
+	testutil.WriteNote(t, root, "note.md", "# Note\n")
+
+	assert.Equal(t, "note.md", nextEvent(t, events).Relative)
+}
+
+func TestNoteWatcher_WatchesNewDirectories(t *testing.T) {
+	root := t.TempDir()
+	svc := newTestDbService(t, DbOptions{})
+	events := startWatcher(t, svc, root)
+
+	require.NoError(t, os.Mkdir(filepath.Join(root, "projects"), 0755))
+	time.Sleep(100 * time.Millisecond)
+
+	testutil.WriteNote(t, root, "projects/plan.md", "# Plan\n")
+
+	event := nextEvent(t, events)
+	assert.Equal(t, WatchEventCreated, event.Type)
+	assert.Equal(t, "projects/plan.md", event.Relative)
+}
+
+func TestNoteWatcher_Rename(t *testing.T) {
+	root := t.TempDir()
+	oldPath := testutil.WriteNote(t, root, "old.md", "# Note\n")
+
+	svc := newTestDbService(t, DbOptions{})
+	events := startWatcher(t, svc, root)
+
+	require.NoError(t, os.Rename(oldPath, filepath.Join(root, "new.md")))
+
+	first := nextEvent(t, events)
+	second := nextEvent(t, events)
+	assert.Equal(t, WatchEvent{Type: WatchEventCreated, Path: filepath.Join(root, "new.md"), Relative: "new.md", Time: first.Time}, first)
+	assert.Equal(t, WatchEventRenamed, second.Type)
+	assert.Equal(t, "old.md", second.Relative)
+}
+
+func TestNoteService_Watch_NoNotebook(t *testing.T) {
+	svc := newTestDbService(t, DbOptions{})
+
+	err := NewNoteService(nil, svc, "").Watch(context.Background(), func(WatchEvent) {})
+	assert.ErrorContains(t, err, "no notebook selected")
+}
@@ -5,13 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
-
-	"github.com/zenobi-us/opennotes/internal/services"
 )
 
-// CreateTestConfig creates a test config file in a temporary directory.
+// CreateTestConfig creates a test config file in a temporary directory from
+// cfg, usually a services.Config. It takes any value so that the services
+// package's own tests can use testutil without an import cycle.
 // Returns the path to the config file.
-func CreateTestConfig(t *testing.T, dir string, cfg services.Config) string {
+func CreateTestConfig(t *testing.T, dir string, cfg any) string {
 	t.Helper()
 
 	configPath := filepath.Join(dir, "opennotes", "config.json")
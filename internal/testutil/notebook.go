@@ -72,6 +72,34 @@ func CreateTestNote(t *testing.T, notebookDir, filename, content string) string
 	return notePath
 }
 
+// WriteNote writes a file at rel, a slash-separated path under root,
+// creating the directories it's in. Returns the path to the file.
+func WriteNote(t *testing.T, root, rel, content string) string {
+	t.Helper()
+
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create note directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write note file: %v", err)
+	}
+
+	return path
+}
+
+// ReadNote returns the content of the file at rel, a slash-separated path
+// under root.
+func ReadNote(t *testing.T, root, rel string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatalf("failed to read note file: %v", err)
+	}
+	return string(data)
+}
+
 // CreateTestNoteWithFrontmatter creates a note with YAML frontmatter.
 func CreateTestNoteWithFrontmatter(t *testing.T, notebookDir, filename string, frontmatter map[string]interface{}, body string) string {
 	t.Helper()
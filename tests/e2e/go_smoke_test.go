@@ -76,15 +76,7 @@ func (e *testEnv) run(args ...string) (stdout, stderr string, exitCode int) {
 func (e *testEnv) runInDir(dir string, args ...string) (stdout, stderr string, exitCode int) {
 	e.t.Helper()
 
-	cmd := exec.Command(e.binaryPath, args...)
-	cmd.Dir = dir
-
-	// Use isolated config directory
-	configDir := filepath.Join(e.tmpDir, ".config")
-	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("HOME=%s", e.tmpDir),
-		fmt.Sprintf("XDG_CONFIG_HOME=%s", configDir),
-	)
+	cmd := e.command(dir, args...)
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
@@ -104,6 +96,22 @@ func (e *testEnv) runInDir(dir string, args ...string) (stdout, stderr string, e
 	return stdoutBuf.String(), stderrBuf.String(), exitCode
 }
 
+// command prepares the CLI with given args to run in dir, using the test
+// environment's isolated config and cache directories.
+func (e *testEnv) command(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command(e.binaryPath, args...)
+	cmd.Dir = dir
+
+	// Use isolated config directory
+	configDir := filepath.Join(e.tmpDir, ".config")
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("HOME=%s", e.tmpDir),
+		fmt.Sprintf("XDG_CONFIG_HOME=%s", configDir),
+		fmt.Sprintf("XDG_CACHE_HOME=%s", filepath.Join(e.tmpDir, ".cache")),
+	)
+	return cmd
+}
+
 // createNotebook creates a test notebook directory.
 func (e *testEnv) createNotebook(name string) string {
 	e.t.Helper()
@@ -415,6 +423,36 @@ func TestCLI_NotesSearch_FiltersNotes(t *testing.T) {
 	}
 }
 
+func TestCLI_NotesSearch_LeadingNegatedFilter(t *testing.T) {
+	env := newTestEnv(t)
+
+	notebookDir := env.createNotebook("negated-search-test")
+	env.createNoteWithFrontmatter(notebookDir, "open.md", map[string]interface{}{"status": "open"}, "# Open\n")
+	env.createNoteWithFrontmatter(notebookDir, "done.md", map[string]interface{}{"status": "done"}, "# Done\n")
+
+	stdout, stderr, exitCode := env.runInDir(notebookDir, "notes", "search", "--", "-status:done")
+
+	if exitCode != 0 {
+		t.Errorf("notes search failed with exit code %d, stderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "open.md") {
+		t.Errorf("expected open.md in output, got: %s", stdout)
+	}
+	if strings.Contains(stdout, "done.md") {
+		t.Errorf("unexpected done.md in output: %s", stdout)
+	}
+
+	// Without -- the query is read as flags; the error explains the fix
+	_, stderr, exitCode = env.runInDir(notebookDir, "notes", "search", "-status:done")
+
+	if exitCode == 0 {
+		t.Error("expected notes search to fail without --")
+	}
+	if !strings.Contains(stderr, "notes search -- ") {
+		t.Errorf("expected a hint to use --, got: %s", stderr)
+	}
+}
+
 func TestCLI_NotesAdd_CreatesNote(t *testing.T) {
 	env := newTestEnv(t)
 
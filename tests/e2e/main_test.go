@@ -0,0 +1,25 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain keeps note indexes created by tests out of the user's cache dir.
+func TestMain(m *testing.M) {
+	cacheDir, err := os.MkdirTemp("", "opennotes-cache-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create cache dir: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.Setenv("XDG_CACHE_HOME", cacheDir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set XDG_CACHE_HOME: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	_ = os.RemoveAll(cacheDir)
+	os.Exit(code)
+}
@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLI_NotesWatch_OtherCommandsUseIndex(t *testing.T) {
+	env := newTestEnv(t)
+
+	notebookDir := env.createNotebook("watch-test")
+	env.createNote(notebookDir, "existing.md", "# Existing\n")
+
+	watch := env.command(notebookDir, "notes", "watch", "--format", "ndjson")
+	stdout, err := watch.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to pipe watch output: %v", err)
+	}
+	if err := watch.Start(); err != nil {
+		t.Fatalf("failed to start notes watch: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = watch.Process.Signal(os.Interrupt)
+		_ = watch.Wait()
+	})
+
+	events := make(chan map[string]any, 8)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			var event map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &event); err == nil {
+				events <- event
+			}
+		}
+		close(events)
+	}()
+
+	// Give the watcher time to index the notebook and register its watches
+	time.Sleep(time.Second)
+	env.createNote(notebookDir, "new.md", "# New\n")
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("notes watch exited before reporting an event")
+		}
+		if event["relative"] != "new.md" {
+			t.Fatalf("expected an event for new.md, got %v", event)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+	}
+
+	list := env.command(notebookDir, "notes", "list")
+	list.Env = append(list.Env, "LOG_LEVEL=debug")
+	output, err := list.CombinedOutput()
+	if err != nil {
+		t.Fatalf("notes list failed while watching: %v\n%s", err, output)
+	}
+
+	if strings.Contains(string(output), "in-memory index") {
+		t.Errorf("notes list fell back to an in-memory index while watching:\n%s", output)
+	}
+	// The watcher already indexed every note, so nothing is parsed again
+	if strings.Contains(string(output), "note index refreshed") {
+		t.Errorf("notes list re-indexed notes while watching:\n%s", output)
+	}
+	if !strings.Contains(string(output), "new.md") {
+		t.Errorf("expected new.md in notes list output:\n%s", output)
+	}
+}